@@ -6,6 +6,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/afero"
@@ -23,6 +24,10 @@ const (
 	defaultGroupsLimit = 100
 	defaultConnsLimit  = 1000
 
+	defaultPerIPConnsLimit    = 0
+	defaultPerIPGamesLimit    = 0
+	defaultPerIPRequestsLimit = 0
+
 	defaultLogEnableJSON = false
 	defaultLogLevel      = "info"
 
@@ -33,6 +38,33 @@ const (
 
 	defaultSentryEnable = false
 	defaultSentryDSN    = ""
+
+	defaultSnapshotEnable = false
+	defaultSnapshotPath   = "snake-server-snapshot.json"
+
+	defaultHighScoresEnable = false
+	defaultHighScoresPath   = "snake-server-highscores.jsonl"
+
+	defaultAuthEnable = false
+
+	defaultReaperEnable = false
+	defaultReaperTTL    = time.Minute * 30
+
+	defaultShutdownDrainTimeout = time.Second * 30
+
+	defaultWebSocketCompressionEnable = false
+	defaultWebSocketCompressionLevel  = 1
+
+	defaultBackpressurePolicy   = "drop"
+	defaultBackpressureMaxDrops = 100
+
+	defaultPlayground = "cmap"
+
+	defaultCorpseDecayInterval     = time.Second * 2
+	defaultCorpseFreshnessInterval = time.Second * 5
+	defaultCorpseNutritionalValue  = 2
+
+	defaultSnakeInvulnerability = time.Second * 2
 )
 
 // Flag labels
@@ -46,6 +78,12 @@ const (
 	flagLabelGroupsLimit = "groups-limit"
 	flagLabelConnsLimit  = "conns-limit"
 
+	flagLabelPerIPConnsLimit    = "per-ip-conns-limit"
+	flagLabelPerIPGamesLimit    = "per-ip-games-limit"
+	flagLabelPerIPRequestsLimit = "per-ip-requests-limit"
+
+	flagLabelTrustedProxies = "trusted-proxies"
+
 	flagLabelSeed = "seed"
 
 	flagLabelLogEnableJSON = "log-json"
@@ -58,6 +96,33 @@ const (
 
 	flagLabelSentryEnable = "sentry-enable"
 	flagLabelSentryDSN    = "sentry-dsn"
+
+	flagLabelSnapshotEnable = "snapshot-enable"
+	flagLabelSnapshotPath   = "snapshot-path"
+
+	flagLabelHighScoresEnable = "highscores-enable"
+	flagLabelHighScoresPath   = "highscores-path"
+
+	flagLabelAuthEnable = "auth-enable"
+
+	flagLabelReaperEnable = "reaper-enable"
+	flagLabelReaperTTL    = "reaper-ttl"
+
+	flagLabelShutdownDrainTimeout = "shutdown-drain-timeout"
+
+	flagLabelWebSocketCompressionEnable = "ws-compression-enable"
+	flagLabelWebSocketCompressionLevel  = "ws-compression-level"
+
+	flagLabelBackpressurePolicy   = "backpressure-policy"
+	flagLabelBackpressureMaxDrops = "backpressure-max-drops"
+
+	flagLabelPlayground = "playground"
+
+	flagLabelCorpseDecayInterval     = "corpse-decay-interval"
+	flagLabelCorpseFreshnessInterval = "corpse-freshness-interval"
+	flagLabelCorpseNutritionalValue  = "corpse-nutritional-value"
+
+	flagLabelSnakeInvulnerability = "snake-invulnerability"
 )
 
 // Flag usage descriptions
@@ -71,6 +136,12 @@ const (
 	flagUsageGroupsLimit = "game groups limit"
 	flagUsageConnsLimit  = "web-socket connections limit"
 
+	flagUsagePerIPConnsLimit    = "concurrent web-socket connections limit per client IP, 0 - no limit"
+	flagUsagePerIPGamesLimit    = "created games per hour limit per client IP, 0 - no limit"
+	flagUsagePerIPRequestsLimit = "API requests per second limit per client IP, 0 - no limit"
+
+	flagUsageTrustedProxies = "comma separated IPs or CIDRs of proxies trusted to set X-Forwarded-For"
+
 	flagUsageSeed = "random seed"
 
 	flagUsageLogEnableJSON = "use json format for logger"
@@ -83,6 +154,33 @@ const (
 
 	flagUsageSentryEnable = "enable sending logs to sentry"
 	flagUsageSentryDSN    = "sentry's DSN"
+
+	flagUsageSnapshotEnable = "save games on shutdown and restore them on start"
+	flagUsageSnapshotPath   = "path to games snapshot file"
+
+	flagUsageHighScoresEnable = "enable recording of players' high scores"
+	flagUsageHighScoresPath   = "path to high scores storage file"
+
+	flagUsageAuthEnable = "require tokens for admin API methods"
+
+	flagUsageReaperEnable = "delete games which have been empty longer than the TTL"
+	flagUsageReaperTTL    = "time after which an empty game is deleted"
+
+	flagUsageShutdownDrainTimeout = "time to wait for players to leave games on shutdown"
+
+	flagUsageWebSocketCompressionEnable = "negotiate permessage-deflate compression with web-socket clients"
+	flagUsageWebSocketCompressionLevel  = "web-socket compression level from -2 (huffman only) to 9 (best compression)"
+
+	flagUsageBackpressurePolicy   = "default policy for slow connections: drop, disconnect, resync or coalesce"
+	flagUsageBackpressureMaxDrops = "dropped messages count after which a slow connection is closed by the disconnect policy"
+
+	flagUsagePlayground = "default playground implementation of new games: cmap or lockfree"
+
+	flagUsageCorpseDecayInterval     = "period after which a corpse loses its tail dot"
+	flagUsageCorpseFreshnessInterval = "period after which a corpse loses a unit of nutritional value"
+	flagUsageCorpseNutritionalValue  = "nutritional value of a fresh corpse"
+
+	flagUsageSnakeInvulnerability = "default invulnerability window of new snakes in new games, 0 - disabled"
 )
 
 // Label names
@@ -96,6 +194,12 @@ const (
 	fieldLabelGroupsLimit = "groups-limit"
 	fieldLabelConnsLimit  = "conns-limit"
 
+	fieldLabelPerIPConnsLimit    = "per-ip-conns-limit"
+	fieldLabelPerIPGamesLimit    = "per-ip-games-limit"
+	fieldLabelPerIPRequestsLimit = "per-ip-requests-limit"
+
+	fieldLabelTrustedProxies = "trusted-proxies"
+
 	fieldLabelSeed = "seed"
 
 	fieldLabelLogEnableJSON = "log-json"
@@ -108,6 +212,36 @@ const (
 
 	fieldLabelSentryEnable = "sentry-enable"
 	fieldLabelSentryDSN    = "sentry-dsn"
+
+	fieldLabelSnapshotEnable = "snapshot-enable"
+	fieldLabelSnapshotPath   = "snapshot-path"
+
+	fieldLabelHighScoresEnable = "highscores-enable"
+	fieldLabelHighScoresPath   = "highscores-path"
+
+	fieldLabelAuthEnable = "auth-enable"
+	fieldLabelAuthTokens = "auth-tokens"
+
+	fieldLabelReaperEnable = "reaper-enable"
+	fieldLabelReaperTTL    = "reaper-ttl"
+
+	fieldLabelShutdownDrainTimeout = "shutdown-drain-timeout"
+
+	fieldLabelWebSocketCompressionEnable = "ws-compression-enable"
+	fieldLabelWebSocketCompressionLevel  = "ws-compression-level"
+
+	fieldLabelBackpressurePolicy   = "backpressure-policy"
+	fieldLabelBackpressureMaxDrops = "backpressure-max-drops"
+
+	fieldLabelPlayground = "playground"
+
+	fieldLabelListeners = "listeners"
+
+	fieldLabelCorpseDecayInterval     = "corpse-decay-interval"
+	fieldLabelCorpseFreshnessInterval = "corpse-freshness-interval"
+	fieldLabelCorpseNutritionalValue  = "corpse-nutritional-value"
+
+	fieldLabelSnakeInvulnerability = "snake-invulnerability"
 )
 
 const envVarSnakeServerConfigPath = "SNAKE_SERVER_CONFIG_PATH"
@@ -123,10 +257,22 @@ type TLS struct {
 	Key    string `yaml:"key"`
 }
 
+// PerIP structure sets up limits for a single client IP. Zero means no limit
+type PerIP struct {
+	// Conns limits concurrent web-socket connections
+	Conns int `yaml:"conns"`
+	// Games limits created games per hour
+	Games int `yaml:"games"`
+	// Requests limits API requests per second
+	Requests int `yaml:"requests"`
+}
+
 // Limits structure sets up server limits
 type Limits struct {
 	Groups int `yaml:"groups"`
 	Conns  int `yaml:"conns"`
+
+	PerIP PerIP `yaml:"per_ip"`
 }
 
 // Log structure defines preferences for logging
@@ -147,6 +293,121 @@ type Sentry struct {
 	DSN    string `yaml:"dsn"`
 }
 
+// Snapshot structure defines where games are saved on shutdown
+type Snapshot struct {
+	Enable bool   `yaml:"enable"`
+	Path   string `yaml:"path"`
+}
+
+// HighScores structure defines where players' records are stored
+type HighScores struct {
+	Enable bool   `yaml:"enable"`
+	Path   string `yaml:"path"`
+}
+
+// Token structure defines an API token and scopes granted to it
+type Token struct {
+	Token  string   `yaml:"token"`
+	Scopes []string `yaml:"scopes"`
+}
+
+// Auth structure defines tokens for admin API methods
+type Auth struct {
+	Enable bool    `yaml:"enable"`
+	Tokens []Token `yaml:"tokens"`
+}
+
+type errInvalidAuth string
+
+func (e errInvalidAuth) Error() string {
+	return "invalid auth: " + string(e)
+}
+
+// Validate returns an error if a token is empty or grants a scope which is
+// not one of the known scopes
+func (a Auth) Validate(knownScopes []string) error {
+	known := make(map[string]struct{}, len(knownScopes))
+	for _, scope := range knownScopes {
+		known[scope] = struct{}{}
+	}
+
+	for i, token := range a.Tokens {
+		if strings.TrimSpace(token.Token) == "" {
+			return errInvalidAuth(fmt.Sprintf("token %d is empty", i+1))
+		}
+		for _, scope := range token.Scopes {
+			if _, ok := known[scope]; !ok {
+				return errInvalidAuth(fmt.Sprintf("token %d grants unknown scope %q", i+1, scope))
+			}
+		}
+	}
+
+	return nil
+}
+
+// TokenScopes returns granted scopes by tokens
+func (a Auth) TokenScopes() map[string][]string {
+	tokens := make(map[string][]string, len(a.Tokens))
+	for _, token := range a.Tokens {
+		tokens[token.Token] = append(tokens[token.Token], token.Scopes...)
+	}
+	return tokens
+}
+
+// Reaper structure defines when idle games are deleted
+type Reaper struct {
+	Enable bool          `yaml:"enable"`
+	TTL    time.Duration `yaml:"ttl"`
+}
+
+// Shutdown structure defines how the server shuts down
+type Shutdown struct {
+	// DrainTimeout is the time players are given to leave games before
+	// they are disconnected
+	DrainTimeout time.Duration `yaml:"drain_timeout"`
+}
+
+// Compression structure defines web-socket per-message compression
+type Compression struct {
+	Enable bool `yaml:"enable"`
+	Level  int  `yaml:"level"`
+}
+
+// Backpressure structure defines the default policy for slow connections of
+// new games
+type Backpressure struct {
+	Policy   string `yaml:"policy"`
+	MaxDrops int    `yaml:"max_drops"`
+}
+
+// WebSocket structure contains web-socket connection settings
+type WebSocket struct {
+	Compression Compression `yaml:"compression"`
+}
+
+// Corpse structure defines how fast corpses of snakes decay and go stale
+type Corpse struct {
+	DecayInterval     time.Duration `yaml:"decay_interval"`
+	FreshnessInterval time.Duration `yaml:"freshness_interval"`
+	NutritionalValue  int           `yaml:"nutritional_value"`
+}
+
+// Snake structure defines the default settings of snakes in new games
+type Snake struct {
+	Invulnerability time.Duration `yaml:"invulnerability"`
+}
+
+// Listener structure defines an address to serve a set of routes. Unix
+// domain sockets are set with addresses like unix:/path/to/socket
+type Listener struct {
+	Name    string `yaml:"name"`
+	Address string `yaml:"address"`
+	TLS     TLS    `yaml:"tls"`
+	// Routes contains names of route sets served by the listener. It is
+	// required, only the implicit default listener serves all routes
+	Routes []string `yaml:"routes"`
+}
+
 // Server structure contains configurations for the server
 type Server struct {
 	Address string `yaml:"address"`
@@ -154,11 +415,59 @@ type Server struct {
 	TLS    TLS    `yaml:"tls"`
 	Limits Limits `yaml:"limits"`
 	Seed   int64  `yaml:"seed"`
-	Log    Log    `yaml:"log"`
+
+	// TrustedProxies contains IPs and CIDRs of proxies which are trusted to
+	// set X-Forwarded-For header
+	TrustedProxies []string `yaml:"trusted_proxies"`
+
+	Log Log `yaml:"log"`
 
 	Flags Flags `yaml:"flags"`
 
 	Sentry `yaml:"sentry"`
+
+	Snapshot Snapshot `yaml:"snapshot"`
+
+	HighScores HighScores `yaml:"highscores"`
+
+	Auth Auth `yaml:"auth"`
+
+	Reaper Reaper `yaml:"reaper"`
+
+	Shutdown Shutdown `yaml:"shutdown"`
+
+	WebSocket WebSocket `yaml:"websocket"`
+
+	Backpressure Backpressure `yaml:"backpressure"`
+
+	// Playground is the default playground implementation of new games
+	Playground string `yaml:"playground"`
+
+	// Listeners replace the address and TLS settings above if there are any
+	Listeners []Listener `yaml:"listeners"`
+
+	Corpse Corpse `yaml:"corpse"`
+
+	Snake Snake `yaml:"snake"`
+}
+
+// defaultListenerName is the name of the listener made of the server's
+// address and TLS settings
+const defaultListenerName = "default"
+
+// EffectiveListeners returns the listeners of the server. If there are no
+// listeners set, it returns a listener of all routes at the server's address
+func (s Server) EffectiveListeners() []Listener {
+	if len(s.Listeners) > 0 {
+		return s.Listeners
+	}
+	return []Listener{
+		{
+			Name:    defaultListenerName,
+			Address: s.Address,
+			TLS:     s.TLS,
+		},
+	}
 }
 
 // Config is a base server configuration structure
@@ -178,6 +487,12 @@ func (c Config) Fields() map[string]interface{} {
 		fieldLabelGroupsLimit: c.Server.Limits.Groups,
 		fieldLabelConnsLimit:  c.Server.Limits.Conns,
 
+		fieldLabelPerIPConnsLimit:    c.Server.Limits.PerIP.Conns,
+		fieldLabelPerIPGamesLimit:    c.Server.Limits.PerIP.Games,
+		fieldLabelPerIPRequestsLimit: c.Server.Limits.PerIP.Requests,
+
+		fieldLabelTrustedProxies: c.Server.TrustedProxies,
+
 		fieldLabelSeed: c.Server.Seed,
 
 		fieldLabelLogEnableJSON: c.Server.Log.EnableJSON,
@@ -190,6 +505,37 @@ func (c Config) Fields() map[string]interface{} {
 
 		fieldLabelSentryEnable: c.Server.Sentry.Enable,
 		fieldLabelSentryDSN:    c.Server.Sentry.DSN,
+
+		fieldLabelSnapshotEnable: c.Server.Snapshot.Enable,
+		fieldLabelSnapshotPath:   c.Server.Snapshot.Path,
+
+		fieldLabelHighScoresEnable: c.Server.HighScores.Enable,
+		fieldLabelHighScoresPath:   c.Server.HighScores.Path,
+
+		fieldLabelAuthEnable: c.Server.Auth.Enable,
+		// Do not expose the tokens
+		fieldLabelAuthTokens: len(c.Server.Auth.Tokens),
+
+		fieldLabelReaperEnable: c.Server.Reaper.Enable,
+		fieldLabelReaperTTL:    c.Server.Reaper.TTL,
+
+		fieldLabelShutdownDrainTimeout: c.Server.Shutdown.DrainTimeout,
+
+		fieldLabelWebSocketCompressionEnable: c.Server.WebSocket.Compression.Enable,
+		fieldLabelWebSocketCompressionLevel:  c.Server.WebSocket.Compression.Level,
+
+		fieldLabelBackpressurePolicy:   c.Server.Backpressure.Policy,
+		fieldLabelBackpressureMaxDrops: c.Server.Backpressure.MaxDrops,
+
+		fieldLabelPlayground: c.Server.Playground,
+
+		fieldLabelListeners: c.Server.Listeners,
+
+		fieldLabelCorpseDecayInterval:     c.Server.Corpse.DecayInterval,
+		fieldLabelCorpseFreshnessInterval: c.Server.Corpse.FreshnessInterval,
+		fieldLabelCorpseNutritionalValue:  c.Server.Corpse.NutritionalValue,
+
+		fieldLabelSnakeInvulnerability: c.Server.Snake.Invulnerability,
 	}
 }
 
@@ -209,6 +555,12 @@ var defaultConfig = Config{
 		Limits: Limits{
 			Groups: defaultGroupsLimit,
 			Conns:  defaultConnsLimit,
+
+			PerIP: PerIP{
+				Conns:    defaultPerIPConnsLimit,
+				Games:    defaultPerIPGamesLimit,
+				Requests: defaultPerIPRequestsLimit,
+			},
 		},
 
 		Seed: seed,
@@ -229,6 +581,53 @@ var defaultConfig = Config{
 			Enable: defaultSentryEnable,
 			DSN:    defaultSentryDSN,
 		},
+
+		Snapshot: Snapshot{
+			Enable: defaultSnapshotEnable,
+			Path:   defaultSnapshotPath,
+		},
+
+		HighScores: HighScores{
+			Enable: defaultHighScoresEnable,
+			Path:   defaultHighScoresPath,
+		},
+
+		Auth: Auth{
+			Enable: defaultAuthEnable,
+		},
+
+		Reaper: Reaper{
+			Enable: defaultReaperEnable,
+			TTL:    defaultReaperTTL,
+		},
+
+		Shutdown: Shutdown{
+			DrainTimeout: defaultShutdownDrainTimeout,
+		},
+
+		WebSocket: WebSocket{
+			Compression: Compression{
+				Enable: defaultWebSocketCompressionEnable,
+				Level:  defaultWebSocketCompressionLevel,
+			},
+		},
+
+		Backpressure: Backpressure{
+			Policy:   defaultBackpressurePolicy,
+			MaxDrops: defaultBackpressureMaxDrops,
+		},
+
+		Playground: defaultPlayground,
+
+		Corpse: Corpse{
+			DecayInterval:     defaultCorpseDecayInterval,
+			FreshnessInterval: defaultCorpseFreshnessInterval,
+			NutritionalValue:  defaultCorpseNutritionalValue,
+		},
+
+		Snake: Snake{
+			Invulnerability: defaultSnakeInvulnerability,
+		},
 	},
 }
 
@@ -248,6 +647,24 @@ func ParseYAML(input []byte, defaults Config) (Config, error) {
 	return config, nil
 }
 
+// stringsValue is a flag value of a comma separated list
+type stringsValue []string
+
+func (s *stringsValue) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsValue) Set(value string) error {
+	values := make([]string, 0)
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); len(v) > 0 {
+			values = append(values, v)
+		}
+	}
+	*s = values
+	return nil
+}
+
 // ParseFlags parses flags and returns a config based on the default configuration
 func ParseFlags(flagSet *flag.FlagSet, args []string, defaults Config) (Config, error) {
 	if flagSet.Parsed() {
@@ -267,6 +684,27 @@ func ParseFlags(flagSet *flag.FlagSet, args []string, defaults Config) (Config,
 	// Limits
 	flagSet.IntVar(&config.Server.Limits.Groups, flagLabelGroupsLimit, defaults.Server.Limits.Groups, flagUsageGroupsLimit)
 	flagSet.IntVar(&config.Server.Limits.Conns, flagLabelConnsLimit, defaults.Server.Limits.Conns, flagUsageConnsLimit)
+	flagSet.IntVar(
+		&config.Server.Limits.PerIP.Conns,
+		flagLabelPerIPConnsLimit,
+		defaults.Server.Limits.PerIP.Conns,
+		flagUsagePerIPConnsLimit,
+	)
+	flagSet.IntVar(
+		&config.Server.Limits.PerIP.Games,
+		flagLabelPerIPGamesLimit,
+		defaults.Server.Limits.PerIP.Games,
+		flagUsagePerIPGamesLimit,
+	)
+	flagSet.IntVar(
+		&config.Server.Limits.PerIP.Requests,
+		flagLabelPerIPRequestsLimit,
+		defaults.Server.Limits.PerIP.Requests,
+		flagUsagePerIPRequestsLimit,
+	)
+
+	// Proxies
+	flagSet.Var((*stringsValue)(&config.Server.TrustedProxies), flagLabelTrustedProxies, flagUsageTrustedProxies)
 
 	// Random
 	flagSet.Int64Var(&config.Server.Seed, flagLabelSeed, defaults.Server.Seed, flagUsageSeed)
@@ -305,6 +743,88 @@ func ParseFlags(flagSet *flag.FlagSet, args []string, defaults Config) (Config,
 	flagSet.BoolVar(&config.Server.Sentry.Enable, flagLabelSentryEnable, defaults.Server.Sentry.Enable, flagUsageSentryEnable)
 	flagSet.StringVar(&config.Server.Sentry.DSN, flagLabelSentryDSN, defaults.Server.Sentry.DSN, flagUsageSentryDSN)
 
+	// Snapshot
+	flagSet.BoolVar(&config.Server.Snapshot.Enable, flagLabelSnapshotEnable, defaults.Server.Snapshot.Enable, flagUsageSnapshotEnable)
+	flagSet.StringVar(&config.Server.Snapshot.Path, flagLabelSnapshotPath, defaults.Server.Snapshot.Path, flagUsageSnapshotPath)
+
+	// High scores
+	flagSet.BoolVar(&config.Server.HighScores.Enable, flagLabelHighScoresEnable, defaults.Server.HighScores.Enable, flagUsageHighScoresEnable)
+	flagSet.StringVar(&config.Server.HighScores.Path, flagLabelHighScoresPath, defaults.Server.HighScores.Path, flagUsageHighScoresPath)
+
+	// Auth
+	flagSet.BoolVar(&config.Server.Auth.Enable, flagLabelAuthEnable, defaults.Server.Auth.Enable, flagUsageAuthEnable)
+
+	// Reaper
+	flagSet.BoolVar(&config.Server.Reaper.Enable, flagLabelReaperEnable, defaults.Server.Reaper.Enable, flagUsageReaperEnable)
+	flagSet.DurationVar(&config.Server.Reaper.TTL, flagLabelReaperTTL, defaults.Server.Reaper.TTL, flagUsageReaperTTL)
+
+	// Shutdown
+	flagSet.DurationVar(
+		&config.Server.Shutdown.DrainTimeout,
+		flagLabelShutdownDrainTimeout,
+		defaults.Server.Shutdown.DrainTimeout,
+		flagUsageShutdownDrainTimeout,
+	)
+
+	// WebSocket
+	flagSet.BoolVar(
+		&config.Server.WebSocket.Compression.Enable,
+		flagLabelWebSocketCompressionEnable,
+		defaults.Server.WebSocket.Compression.Enable,
+		flagUsageWebSocketCompressionEnable,
+	)
+	flagSet.IntVar(
+		&config.Server.WebSocket.Compression.Level,
+		flagLabelWebSocketCompressionLevel,
+		defaults.Server.WebSocket.Compression.Level,
+		flagUsageWebSocketCompressionLevel,
+	)
+
+	// Backpressure
+	flagSet.StringVar(
+		&config.Server.Backpressure.Policy,
+		flagLabelBackpressurePolicy,
+		defaults.Server.Backpressure.Policy,
+		flagUsageBackpressurePolicy,
+	)
+	flagSet.IntVar(
+		&config.Server.Backpressure.MaxDrops,
+		flagLabelBackpressureMaxDrops,
+		defaults.Server.Backpressure.MaxDrops,
+		flagUsageBackpressureMaxDrops,
+	)
+
+	// Playground
+	flagSet.StringVar(&config.Server.Playground, flagLabelPlayground, defaults.Server.Playground, flagUsagePlayground)
+
+	// Corpse
+	flagSet.DurationVar(
+		&config.Server.Corpse.DecayInterval,
+		flagLabelCorpseDecayInterval,
+		defaults.Server.Corpse.DecayInterval,
+		flagUsageCorpseDecayInterval,
+	)
+	flagSet.DurationVar(
+		&config.Server.Corpse.FreshnessInterval,
+		flagLabelCorpseFreshnessInterval,
+		defaults.Server.Corpse.FreshnessInterval,
+		flagUsageCorpseFreshnessInterval,
+	)
+	flagSet.IntVar(
+		&config.Server.Corpse.NutritionalValue,
+		flagLabelCorpseNutritionalValue,
+		defaults.Server.Corpse.NutritionalValue,
+		flagUsageCorpseNutritionalValue,
+	)
+
+	// Snake
+	flagSet.DurationVar(
+		&config.Server.Snake.Invulnerability,
+		flagLabelSnakeInvulnerability,
+		defaults.Server.Snake.Invulnerability,
+		flagUsageSnakeInvulnerability,
+	)
+
 	if err := flagSet.Parse(args); err != nil {
 		return defaults, fmt.Errorf("cannot parse flags: %s", err)
 	}
@@ -343,7 +863,9 @@ func (e *errConfigurate) Error() string {
 	return fmt.Sprintf("cannot configurate: %s", e.err)
 }
 
-// Configurate gathers a config from a config file and a flag set
+// Configurate gathers a config from a config file, environment variables and
+// a flag set. Flags take precedence over environment variables, environment
+// variables take precedence over the file and the file over the defaults
 func Configurate(fs afero.Fs, flagSet *flag.FlagSet, args []string) (Config, error) {
 	defaults := DefaultConfig()
 	config := defaults
@@ -364,7 +886,12 @@ func Configurate(fs afero.Fs, flagSet *flag.FlagSet, args []string) (Config, err
 		}
 	}
 
-	config, err := ParseFlags(flagSet, args, config)
+	config, err := ParseEnv(os.LookupEnv, config)
+	if err != nil {
+		return defaults, &errConfigurate{err}
+	}
+
+	config, err = ParseFlags(flagSet, args, config)
 	if err != nil {
 		return defaults, &errConfigurate{err}
 	}
@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
@@ -197,6 +198,154 @@ func Test_ParseFlags_ParsesFlagsCorrectly(t *testing.T) {
 		expectErr:    false,
 	})
 
+	// Test case 11
+	configTest11 := defaultConfig
+	configTest11.Server.Limits.PerIP.Conns = 5
+	configTest11.Server.Limits.PerIP.Games = 3
+	configTest11.Server.Limits.PerIP.Requests = 20
+	configTest11.Server.TrustedProxies = []string{"10.0.0.0/8", "192.168.1.1"}
+
+	tests = append(tests, &Test{
+		msg: "per ip limits and trusted proxies",
+
+		args: []string{
+			"-per-ip-conns-limit", "5",
+			"-per-ip-games-limit", "3",
+			"-per-ip-requests-limit", "20",
+			"-trusted-proxies", "10.0.0.0/8, 192.168.1.1",
+		},
+		defaults: defaultConfig,
+
+		expectConfig: configTest11,
+		expectErr:    false,
+	})
+
+	// Test case 12
+	configTest12 := defaultConfig
+	configTest12.Server.Reaper.Enable = true
+	configTest12.Server.Reaper.TTL = time.Minute * 10
+
+	tests = append(tests, &Test{
+		msg: "reaper",
+
+		args: []string{
+			"-reaper-enable",
+			"-reaper-ttl", "10m",
+		},
+		defaults: defaultConfig,
+
+		expectConfig: configTest12,
+		expectErr:    false,
+	})
+
+	// Test case 13
+	configTest13 := defaultConfig
+	configTest13.Server.Shutdown.DrainTimeout = time.Minute
+
+	tests = append(tests, &Test{
+		msg: "shutdown drain timeout",
+
+		args: []string{
+			"-shutdown-drain-timeout", "1m",
+		},
+		defaults: defaultConfig,
+
+		expectConfig: configTest13,
+		expectErr:    false,
+	})
+
+	// Test case 14
+	configTest14 := defaultConfig
+	configTest14.Server.WebSocket.Compression.Enable = true
+	configTest14.Server.WebSocket.Compression.Level = 6
+
+	tests = append(tests, &Test{
+		msg: "web-socket compression",
+
+		args: []string{
+			"-ws-compression-enable",
+			"-ws-compression-level", "6",
+		},
+		defaults: defaultConfig,
+
+		expectConfig: configTest14,
+		expectErr:    false,
+	})
+
+	// Test case 15
+	configTest15 := defaultConfig
+	configTest15.Server.Backpressure.Policy = "coalesce"
+	configTest15.Server.Backpressure.MaxDrops = 10
+
+	tests = append(tests, &Test{
+		msg: "backpressure",
+
+		args: []string{
+			"-backpressure-policy", "coalesce",
+			"-backpressure-max-drops", "10",
+		},
+		defaults: defaultConfig,
+
+		expectConfig: configTest15,
+		expectErr:    false,
+	})
+
+	// Test case 16
+	configTest16 := defaultConfig
+	configTest16.Server.Playground = "lockfree"
+
+	tests = append(tests, &Test{
+		msg: "playground",
+
+		args: []string{
+			"-playground", "lockfree",
+		},
+		defaults: defaultConfig,
+
+		expectConfig: configTest16,
+		expectErr:    false,
+	})
+
+	// Test case 17
+	configTest17 := defaultConfig
+	configTest17.Server.Corpse = Corpse{
+		DecayInterval:     time.Second,
+		FreshnessInterval: time.Second * 10,
+		NutritionalValue:  5,
+	}
+
+	tests = append(tests, &Test{
+		msg: "corpse",
+
+		args: []string{
+			"-corpse-decay-interval", "1s",
+			"-corpse-freshness-interval", "10s",
+			"-corpse-nutritional-value", "5",
+		},
+		defaults: defaultConfig,
+
+		expectConfig: configTest17,
+		expectErr:    false,
+	})
+
+	// Test case 18
+	configTest18 := defaultConfig
+	configTest18.Server.Snake = Snake{
+		Invulnerability: time.Millisecond * 500,
+	}
+
+	tests = append(tests, &Test{
+		msg: "snake",
+
+		args: []string{
+			"-snake-invulnerability", "500ms",
+		},
+		defaults: defaultConfig,
+
+		expectConfig: configTest18,
+		expectErr:    false,
+	})
+
 	for n, test := range tests {
 		t.Log(test.msg)
 
@@ -309,6 +458,219 @@ func Test_ParseYAML_ParsesYAMLCorrectly(t *testing.T) {
 		expectErr:    false,
 	})
 
+	// Test case 7
+	configTest7 := defaultConfig
+	configTest7.Server.Snapshot.Enable = true
+	configTest7.Server.Snapshot.Path = "/var/lib/snake-server/snapshot.json"
+
+	tests = append(tests, &Test{
+		msg: "snapshot",
+
+		input:    ConfigYAMLSampleSnapshot,
+		defaults: defaultConfig,
+
+		expectConfig: configTest7,
+		expectErr:    false,
+	})
+
+	// Test case 8
+	configTest8 := defaultConfig
+	configTest8.Server.HighScores.Enable = true
+	configTest8.Server.HighScores.Path = "/var/lib/snake-server/highscores.jsonl"
+
+	tests = append(tests, &Test{
+		msg: "high scores",
+
+		input:    ConfigYAMLSampleHighScores,
+		defaults: defaultConfig,
+
+		expectConfig: configTest8,
+		expectErr:    false,
+	})
+
+	// Test case 9
+	configTest9 := defaultConfig
+	configTest9.Server.Auth.Enable = true
+	configTest9.Server.Auth.Tokens = []Token{
+		{
+			Token:  "admin-secret",
+			Scopes: []string{"*"},
+		},
+		{
+			Token:  "moderator-secret",
+			Scopes: []string{"games:create", "games:delete"},
+		},
+	}
+
+	tests = append(tests, &Test{
+		msg: "auth",
+
+		input:    ConfigYAMLSampleAuth,
+		defaults: defaultConfig,
+
+		expectConfig: configTest9,
+		expectErr:    false,
+	})
+
+	// Test case 10
+	configTest10 := defaultConfig
+	configTest10.Server.Limits.PerIP.Conns = 4
+	configTest10.Server.Limits.PerIP.Games = 2
+	configTest10.Server.Limits.PerIP.Requests = 10
+	configTest10.Server.TrustedProxies = []string{"127.0.0.1", "10.0.0.0/8"}
+
+	tests = append(tests, &Test{
+		msg: "per ip limits",
+
+		input:    ConfigYAMLSamplePerIPLimits,
+		defaults: defaultConfig,
+
+		expectConfig: configTest10,
+		expectErr:    false,
+	})
+
+	// Test case 11
+	configTest11 := defaultConfig
+	configTest11.Server.Reaper.Enable = true
+	configTest11.Server.Reaper.TTL = time.Hour + time.Minute*30
+
+	tests = append(tests, &Test{
+		msg: "reaper",
+
+		input:    ConfigYAMLSampleReaper,
+		defaults: defaultConfig,
+
+		expectConfig: configTest11,
+		expectErr:    false,
+	})
+
+	// Test case 12
+	configTest12 := defaultConfig
+	configTest12.Server.Shutdown.DrainTimeout = time.Second * 45
+
+	tests = append(tests, &Test{
+		msg: "shutdown",
+
+		input:    ConfigYAMLSampleShutdown,
+		defaults: defaultConfig,
+
+		expectConfig: configTest12,
+		expectErr:    false,
+	})
+
+	// Test case 13
+	configTest13 := defaultConfig
+	configTest13.Server.WebSocket.Compression.Enable = true
+	configTest13.Server.WebSocket.Compression.Level = -2
+
+	tests = append(tests, &Test{
+		msg: "web-socket",
+
+		input:    ConfigYAMLSampleWebSocket,
+		defaults: defaultConfig,
+
+		expectConfig: configTest13,
+		expectErr:    false,
+	})
+
+	// Test case 14
+	configTest14 := defaultConfig
+	configTest14.Server.Backpressure.Policy = "disconnect"
+	configTest14.Server.Backpressure.MaxDrops = 50
+
+	tests = append(tests, &Test{
+		msg: "backpressure",
+
+		input:    ConfigYAMLSampleBackpressure,
+		defaults: defaultConfig,
+
+		expectConfig: configTest14,
+		expectErr:    false,
+	})
+
+	// Test case 15
+	configTest15 := defaultConfig
+	configTest15.Server.Playground = "lockfree"
+
+	tests = append(tests, &Test{
+		msg: "playground",
+
+		input:    ConfigYAMLSamplePlayground,
+		defaults: defaultConfig,
+
+		expectConfig: configTest15,
+		expectErr:    false,
+	})
+
+	// Test case 16
+	configTest16 := defaultConfig
+	configTest16.Server.Listeners = []Listener{
+		{
+			Name:    "public",
+			Address: ":443",
+			TLS: TLS{
+				Enable: true,
+				Cert:   "path/to/cert",
+				Key:    "path/to/key",
+			},
+			Routes: []string{"game", "admin"},
+		},
+		{
+			Name:    "internal",
+			Address: "127.0.0.1:9090",
+			Routes:  []string{"metrics", "debug"},
+		},
+		{
+			Name:    "socket",
+			Address: "unix:/run/snake-server.sock",
+			Routes:  []string{"admin"},
+		},
+	}
+
+	tests = append(tests, &Test{
+		msg: "listeners",
+
+		input:    ConfigYAMLSampleListeners,
+		defaults: defaultConfig,
+
+		expectConfig: configTest16,
+		expectErr:    false,
+	})
+
+	// Test case 17
+	configTest17 := defaultConfig
+	configTest17.Server.Corpse = Corpse{
+		DecayInterval:     time.Second * 3,
+		FreshnessInterval: time.Minute,
+		NutritionalValue:  4,
+	}
+
+	tests = append(tests, &Test{
+		msg: "corpse",
+
+		input:    ConfigYAMLSampleCorpse,
+		defaults: defaultConfig,
+
+		expectConfig: configTest17,
+		expectErr:    false,
+	})
+
+	// Test case 18
+	configTest18 := defaultConfig
+	configTest18.Server.Snake = Snake{
+		Invulnerability: 0,
+	}
+
+	tests = append(tests, &Test{
+		msg: "snake",
+
+		input:    ConfigYAMLSampleSnake,
+		defaults: defaultConfig,
+
+		expectConfig: configTest18,
+		expectErr:    false,
+	})
+
 	for n, test := range tests {
 		t.Log(test.msg)
 
@@ -336,6 +698,12 @@ func Test_Config_Fields_ReturnsFieldsOfTheConfig(t *testing.T) {
 		fieldLabelGroupsLimit: 1000,
 		fieldLabelConnsLimit:  10000,
 
+		fieldLabelPerIPConnsLimit:    10,
+		fieldLabelPerIPGamesLimit:    2,
+		fieldLabelPerIPRequestsLimit: 50,
+
+		fieldLabelTrustedProxies: []string{"10.0.0.0/8"},
+
 		fieldLabelSeed: int64(321),
 
 		fieldLabelLogEnableJSON: false,
@@ -348,6 +716,42 @@ func Test_Config_Fields_ReturnsFieldsOfTheConfig(t *testing.T) {
 
 		fieldLabelSentryEnable: true,
 		fieldLabelSentryDSN:    "https://public@sentry.example.com/1",
+
+		fieldLabelSnapshotEnable: true,
+		fieldLabelSnapshotPath:   "/var/lib/snake-server/snapshot.json",
+
+		fieldLabelHighScoresEnable: true,
+		fieldLabelHighScoresPath:   "/var/lib/snake-server/highscores.jsonl",
+
+		fieldLabelAuthEnable: true,
+		fieldLabelAuthTokens: 1,
+
+		fieldLabelReaperEnable: true,
+		fieldLabelReaperTTL:    time.Hour,
+
+		fieldLabelShutdownDrainTimeout: time.Second * 5,
+
+		fieldLabelWebSocketCompressionEnable: true,
+		fieldLabelWebSocketCompressionLevel:  9,
+
+		fieldLabelBackpressurePolicy:   "resync",
+		fieldLabelBackpressureMaxDrops: 20,
+
+		fieldLabelPlayground: "lockfree",
+
+		fieldLabelListeners: []Listener{
+			{
+				Name:    "internal",
+				Address: "unix:/run/snake-server.sock",
+				Routes:  []string{"admin", "metrics"},
+			},
+		},
+
+		fieldLabelCorpseDecayInterval:     time.Second,
+		fieldLabelCorpseFreshnessInterval: time.Second * 10,
+		fieldLabelCorpseNutritionalValue:  3,
+
+		fieldLabelSnakeInvulnerability: time.Second,
 	}, Config{
 		Server: Server{
 			Address: ":9999",
@@ -361,10 +765,18 @@ func Test_Config_Fields_ReturnsFieldsOfTheConfig(t *testing.T) {
 			Limits: Limits{
 				Groups: 1000,
 				Conns:  10000,
+
+				PerIP: PerIP{
+					Conns:    10,
+					Games:    2,
+					Requests: 50,
+				},
 			},
 
 			Seed: 321,
 
+			TrustedProxies: []string{"10.0.0.0/8"},
+
 			Log: Log{
 				EnableJSON: false,
 				Level:      "warning",
@@ -381,6 +793,67 @@ func Test_Config_Fields_ReturnsFieldsOfTheConfig(t *testing.T) {
 				Enable: true,
 				DSN:    "https://public@sentry.example.com/1",
 			},
+
+			Snapshot: Snapshot{
+				Enable: true,
+				Path:   "/var/lib/snake-server/snapshot.json",
+			},
+
+			HighScores: HighScores{
+				Enable: true,
+				Path:   "/var/lib/snake-server/highscores.jsonl",
+			},
+
+			Auth: Auth{
+				Enable: true,
+				Tokens: []Token{
+					{
+						Token:  "secret",
+						Scopes: []string{"*"},
+					},
+				},
+			},
+
+			Reaper: Reaper{
+				Enable: true,
+				TTL:    time.Hour,
+			},
+
+			Shutdown: Shutdown{
+				DrainTimeout: time.Second * 5,
+			},
+
+			WebSocket: WebSocket{
+				Compression: Compression{
+					Enable: true,
+					Level:  9,
+				},
+			},
+
+			Backpressure: Backpressure{
+				Policy:   "resync",
+				MaxDrops: 20,
+			},
+
+			Playground: "lockfree",
+
+			Listeners: []Listener{
+				{
+					Name:    "internal",
+					Address: "unix:/run/snake-server.sock",
+					Routes:  []string{"admin", "metrics"},
+				},
+			},
+
+			Corpse: Corpse{
+				DecayInterval:     time.Second,
+				FreshnessInterval: time.Second * 10,
+				NutritionalValue:  3,
+			},
+
+			Snake: Snake{
+				Invulnerability: time.Second,
+			},
 		},
 	}.Fields())
 }
@@ -524,6 +997,9 @@ func Test_Configurate_ReturnsCorrectConfig(t *testing.T) {
 
 		setEnv     bool
 		saveConfig bool
+
+		// env contains environment variables with configurations
+		env map[string]string
 	}
 
 	var tests = make([]*Test, 0)
@@ -650,6 +1126,103 @@ func Test_Configurate_ReturnsCorrectConfig(t *testing.T) {
 		saveConfig: true,
 	})
 
+	// Test case 7
+	configTest7 := defaultConfig
+	configTest7.Server.Address = ":9999"
+	configTest7.Server.TLS.Enable = true
+	configTest7.Server.TLS.Cert = "/env/cert"
+	configTest7.Server.TLS.Key = "path/to/key"
+	configTest7.Server.Limits.Groups = 422
+	configTest7.Server.Limits.Conns = 10
+	configTest7.Server.Flags.EnableBroadcast = true
+
+	tests = append(tests, &Test{
+		msg: "flags override environment variables which override file config",
+
+		input: ConfigYAMLSampleAddressAndTLSAndLimits,
+		args: []string{
+			"-groups-limit", "422",
+		},
+
+		expectConfig: configTest7,
+		expectErr:    false,
+
+		setEnv:     true,
+		saveConfig: true,
+
+		env: map[string]string{
+			"SNAKE_SERVER_LIMITS_GROUPS": "300",
+			"SNAKE_SERVER_LIMITS_CONNS":  "10",
+			"SNAKE_SERVER_TLS_CERT":      "/env/cert",
+		},
+	})
+
+	// Test case 8
+	tests = append(tests, &Test{
+		msg: "environment variable has invalid value",
+
+		input: ConfigYAMLSampleDefault,
+		args:  []string{},
+
+		expectConfig: defaultConfig,
+		expectErr:    true,
+
+		setEnv:     false,
+		saveConfig: true,
+
+		env: map[string]string{
+			"SNAKE_SERVER_LIMITS_CONNS": "many",
+		},
+	})
+
+	// Test case 9
+	configTest9 := defaultConfig
+	configTest9.Server.Address = ":5000"
+	configTest9.Server.TLS.Enable = true
+	configTest9.Server.TLS.Cert = "path/to/cert"
+	configTest9.Server.TLS.Key = "path/to/key"
+	configTest9.Server.Limits.Groups = 144
+	configTest9.Server.Limits.Conns = 4123
+	configTest9.Server.Flags.EnableBroadcast = true
+
+	tests = append(tests, &Test{
+		msg: "variable PORT overrides the address of file config",
+
+		input: ConfigYAMLSampleAddressAndTLSAndLimits,
+		args:  []string{},
+
+		expectConfig: configTest9,
+		expectErr:    false,
+
+		setEnv:     true,
+		saveConfig: true,
+
+		env: map[string]string{
+			"PORT": "5000",
+		},
+	})
+
+	// Test case 10
+	configTest10 := configTest9
+	configTest10.Server.Address = "localhost:7070"
+
+	tests = append(tests, &Test{
+		msg: "flag address overrides variable PORT",
+
+		input: ConfigYAMLSampleAddressAndTLSAndLimits,
+		args:  []string{"-address", "localhost:7070"},
+
+		expectConfig: configTest10,
+		expectErr:    false,
+
+		setEnv:     true,
+		saveConfig: true,
+
+		env: map[string]string{
+			"PORT": "5000",
+		},
+	})
+
 	for n, test := range tests {
 		t.Log(test.msg)
 
@@ -657,6 +1230,10 @@ func Test_Configurate_ReturnsCorrectConfig(t *testing.T) {
 
 		fs := afero.NewMemMapFs()
 
+		for name, value := range test.env {
+			require.Nil(t, os.Setenv(name, value))
+		}
+
 		if test.saveConfig {
 			err := afero.WriteFile(fs, configPath, test.input, perm)
 			require.Nil(t, err, label)
@@ -683,5 +1260,201 @@ func Test_Configurate_ReturnsCorrectConfig(t *testing.T) {
 			err := os.Unsetenv(envVarSnakeServerConfigPath)
 			require.Nil(t, err)
 		}
+
+		for name := range test.env {
+			require.Nil(t, os.Unsetenv(name))
+		}
+	}
+}
+
+func Test_ParseEnv_ParsesEnvironmentVariablesCorrectly(t *testing.T) {
+	env := map[string]string{
+		"SNAKE_SERVER_ADDRESS":                        "localhost:7070",
+		"SNAKE_SERVER_TLS_ENABLE":                     "true",
+		"SNAKE_SERVER_LIMITS_CONNS":                   "10",
+		"SNAKE_SERVER_LIMITS_PER_IP_REQUESTS":         "5",
+		"SNAKE_SERVER_SEED":                           "42",
+		"SNAKE_SERVER_TRUSTED_PROXIES":                "10.0.0.1, 10.1.0.0/16",
+		"SNAKE_SERVER_SENTRY_DSN":                     "https://key@sentry.example.com/1",
+		"SNAKE_SERVER_REAPER_TTL":                     "1h",
+		"SNAKE_SERVER_WEBSOCKET_COMPRESSION_LEVEL":    "3",
+		"SNAKE_SERVER_AUTH_TOKENS":                    `[{token: secret, scopes: ["*"]}]`,
+		"SNAKE_SERVER_BACKPRESSURE_POLICY":            "resync",
+		"SNAKE_SERVER_PLAYGROUND":                     "lockfree",
+		"SNAKE_SERVER_UNKNOWN":                        "ignored",
+		"SNAKE_SERVER_WEBSOCKET_COMPRESSION_ENABLE_X": "ignored",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	expect := defaultConfig
+	expect.Server.Address = "localhost:7070"
+	expect.Server.TLS.Enable = true
+	expect.Server.Limits.Conns = 10
+	expect.Server.Limits.PerIP.Requests = 5
+	expect.Server.Seed = 42
+	expect.Server.TrustedProxies = []string{"10.0.0.1", "10.1.0.0/16"}
+	expect.Server.Sentry.DSN = "https://key@sentry.example.com/1"
+	expect.Server.Reaper.TTL = time.Hour
+	expect.Server.WebSocket.Compression.Level = 3
+	expect.Server.Auth.Tokens = []Token{{Token: "secret", Scopes: []string{"*"}}}
+	expect.Server.Backpressure.Policy = "resync"
+	expect.Server.Playground = "lockfree"
+
+	config, err := ParseEnv(lookup, defaultConfig)
+	require.Nil(t, err)
+	require.Equal(t, expect, config)
+
+	// The variable PORT does not override the address variable
+	env["PORT"] = "5000"
+	config, err = ParseEnv(lookup, defaultConfig)
+	require.Nil(t, err)
+	require.Equal(t, expect, config)
+
+	delete(env, "SNAKE_SERVER_ADDRESS")
+	expect.Server.Address = ":5000"
+	config, err = ParseEnv(lookup, defaultConfig)
+	require.Nil(t, err)
+	require.Equal(t, expect, config)
+
+	for _, value := range []string{"yes please", ""} {
+		env = map[string]string{
+			"SNAKE_SERVER_FLAGS_DEBUG": value,
+		}
+		config, err = ParseEnv(lookup, defaultConfig)
+		require.NotNil(t, err)
+		require.Equal(t, defaultConfig, config)
+	}
+}
+
+func Test_EnvVarNames_ReturnsNameOfEveryField(t *testing.T) {
+	names := EnvVarNames()
+
+	require.Contains(t, names, "SNAKE_SERVER_LIMITS_CONNS")
+	require.Contains(t, names, "SNAKE_SERVER_LIMITS_PER_IP_CONNS")
+	require.Contains(t, names, "SNAKE_SERVER_SENTRY_ENABLE")
+	require.Contains(t, names, "SNAKE_SERVER_SHUTDOWN_DRAIN_TIMEOUT")
+	require.Len(t, names, len(defaultConfig.Fields()))
+}
+
+func Test_Auth_TokenScopes_ReturnsScopesByTokens(t *testing.T) {
+	require.Equal(t, map[string][]string{
+		"admin-secret":     {"*"},
+		"moderator-secret": {"games:create", "games:delete"},
+	}, Auth{
+		Enable: true,
+		Tokens: []Token{
+			{
+				Token:  "admin-secret",
+				Scopes: []string{"*"},
+			},
+			{
+				Token:  "moderator-secret",
+				Scopes: []string{"games:create"},
+			},
+			{
+				Token:  "moderator-secret",
+				Scopes: []string{"games:delete"},
+			},
+		},
+	}.TokenScopes())
+}
+
+func Test_Config_Diff_ReturnsLabelsOfChangedFields(t *testing.T) {
+	require.Empty(t, defaultConfig.Diff(defaultConfig))
+
+	other := defaultConfig
+	other.Server.Limits.Conns = 10
+	other.Server.TLS.Enable = true
+	other.Server.TrustedProxies = []string{"10.0.0.1"}
+	require.Equal(t, []string{
+		fieldLabelConnsLimit,
+		fieldLabelTLSEnable,
+		fieldLabelTrustedProxies,
+	}, defaultConfig.Diff(other))
+
+	withToken := defaultConfig
+	withToken.Server.Auth.Tokens = []Token{{Token: "old", Scopes: []string{"*"}}}
+	other = withToken
+	other.Server.Auth.Tokens = []Token{{Token: "new", Scopes: []string{"*"}}}
+	require.Equal(t, []string{fieldLabelAuthTokens}, withToken.Diff(other))
+}
+
+func Test_Config_Reload_AppliesReloadableFieldsOnly(t *testing.T) {
+	next := defaultConfig
+	next.Server.Log.Level = "debug"
+	next.Server.Limits.Groups = 5
+	next.Server.Limits.Conns = 50
+	next.Server.Flags.ForbidCORS = true
+	next.Server.Flags.EnableBroadcast = true
+	next.Server.Address = "localhost:7070"
+	next.Server.Seed = defaultConfig.Server.Seed + 1
+
+	expect := defaultConfig
+	expect.Server.Log.Level = "debug"
+	expect.Server.Limits.Groups = 5
+	expect.Server.Limits.Conns = 50
+	expect.Server.Flags.ForbidCORS = true
+	expect.Server.Flags.EnableBroadcast = true
+
+	config, rejected := defaultConfig.Reload(next)
+	require.Equal(t, expect, config)
+	require.Equal(t, []string{fieldLabelAddress, fieldLabelSeed}, rejected)
+
+	config, rejected = defaultConfig.Reload(defaultConfig)
+	require.Equal(t, defaultConfig, config)
+	require.Empty(t, rejected)
+}
+
+func Test_Server_EffectiveListeners_ReturnsDefaultListener(t *testing.T) {
+	server := defaultConfig.Server
+	server.Address = ":9999"
+	server.TLS = TLS{
+		Enable: true,
+		Cert:   "path/to/cert",
+		Key:    "path/to/key",
+	}
+
+	require.Equal(t, []Listener{
+		{
+			Name:    defaultListenerName,
+			Address: ":9999",
+			TLS:     server.TLS,
+		},
+	}, server.EffectiveListeners())
+
+	server.Listeners = []Listener{
+		{
+			Name:    "internal",
+			Address: "unix:/run/snake-server.sock",
+			Routes:  []string{"metrics"},
+		},
+	}
+	require.Equal(t, server.Listeners, server.EffectiveListeners())
+}
+
+func Test_Auth_Validate_RejectsEmptyTokensAndUnknownScopes(t *testing.T) {
+	knownScopes := []string{"*", "games:create", "games:delete"}
+
+	require.Nil(t, Auth{}.Validate(knownScopes))
+	require.Nil(t, Auth{
+		Enable: true,
+		Tokens: []Token{
+			{Token: "admin-secret", Scopes: []string{"*"}},
+			{Token: "moderator-secret", Scopes: []string{"games:create", "games:delete"}},
+		},
+	}.Validate(knownScopes))
+
+	for _, token := range []Token{
+		{Token: "", Scopes: []string{"*"}},
+		{Token: "  ", Scopes: []string{"*"}},
+		{Token: "secret", Scopes: []string{"games:remove"}},
+	} {
+		require.NotNil(t, Auth{
+			Enable: true,
+			Tokens: []Token{token},
+		}.Validate(knownScopes), token.Token)
 	}
 }
@@ -85,3 +85,107 @@ server:
   flags:
     debug: True
 `)
+
+var ConfigYAMLSampleSnapshot = []byte(`
+server:
+  snapshot:
+    enable: True
+    path: /var/lib/snake-server/snapshot.json
+`)
+
+var ConfigYAMLSampleHighScores = []byte(`
+server:
+  highscores:
+    enable: True
+    path: /var/lib/snake-server/highscores.jsonl
+`)
+
+var ConfigYAMLSampleAuth = []byte(`
+server:
+  auth:
+    enable: True
+    tokens:
+      - token: admin-secret
+        scopes: ["*"]
+      - token: moderator-secret
+        scopes:
+          - games:create
+          - games:delete
+`)
+
+var ConfigYAMLSamplePerIPLimits = []byte(`
+server:
+  limits:
+    per_ip:
+      conns: 4
+      games: 2
+      requests: 10
+  trusted_proxies:
+    - 127.0.0.1
+    - 10.0.0.0/8
+`)
+
+var ConfigYAMLSampleReaper = []byte(`
+server:
+  reaper:
+    enable: True
+    ttl: 1h30m
+`)
+
+var ConfigYAMLSampleShutdown = []byte(`
+server:
+  shutdown:
+    drain_timeout: 45s
+`)
+
+var ConfigYAMLSampleWebSocket = []byte(`
+server:
+  websocket:
+    compression:
+      enable: True
+      level: -2
+`)
+
+var ConfigYAMLSampleBackpressure = []byte(`
+server:
+  backpressure:
+    policy: disconnect
+    max_drops: 50
+`)
+
+var ConfigYAMLSamplePlayground = []byte(`
+server:
+  playground: lockfree
+`)
+
+var ConfigYAMLSampleListeners = []byte(`
+server:
+  listeners:
+    - name: public
+      address: :443
+      tls:
+        enable: true
+        cert: path/to/cert
+        key: path/to/key
+      routes: [game, admin]
+    - name: internal
+      address: 127.0.0.1:9090
+      routes: [metrics, debug]
+    - name: socket
+      address: unix:/run/snake-server.sock
+      routes: [admin]
+`)
+
+var ConfigYAMLSampleCorpse = []byte(`
+server:
+  corpse:
+    decay_interval: 3s
+    freshness_interval: 1m
+    nutritional_value: 4
+`)
+
+var ConfigYAMLSampleSnake = []byte(`
+server:
+  snake:
+    invulnerability: 0s
+`)
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// envVarPrefix is prepended to paths of YAML keys to get environment variable
+// names. For example, server.limits.conns is set with SNAKE_SERVER_LIMITS_CONNS
+const envVarPrefix = "SNAKE"
+
+const envVarSeparator = "_"
+
+// envVarPort is set by hosting platforms. It sets the address to listen on
+// the port unless the address is set with envVarServerAddress
+const (
+	envVarPort          = "PORT"
+	envVarServerAddress = envVarPrefix + envVarSeparator + "SERVER" + envVarSeparator + "ADDRESS"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// envVar is a configuration field which can be set with an environment variable
+type envVar struct {
+	name  string
+	field []int
+}
+
+// envVars returns environment variables of all configuration fields in the
+// order of the fields
+func envVars() []envVar {
+	return appendEnvVars(nil, reflect.TypeOf(Config{}), envVarPrefix, nil)
+}
+
+func appendEnvVars(vars []envVar, t reflect.Type, prefix string, index []int) []envVar {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		key := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+
+		name := prefix + envVarSeparator + strings.ToUpper(key)
+		fieldIndex := append(append([]int{}, index...), i)
+
+		if field.Type.Kind() == reflect.Struct && field.Type != durationType {
+			vars = appendEnvVars(vars, field.Type, name, fieldIndex)
+			continue
+		}
+
+		vars = append(vars, envVar{
+			name:  name,
+			field: fieldIndex,
+		})
+	}
+
+	return vars
+}
+
+// EnvVarNames returns names of environment variables which set configuration fields
+func EnvVarNames() []string {
+	vars := envVars()
+	names := make([]string, 0, len(vars))
+	for _, v := range vars {
+		names = append(names, v.name)
+	}
+	return names
+}
+
+type errParseEnv struct {
+	name string
+	err  error
+}
+
+func (e *errParseEnv) Error() string {
+	return fmt.Sprintf("cannot parse environment variable %s: %s", e.name, e.err)
+}
+
+// setEnvValue sets the field by the value of an environment variable.
+// Lists of strings are comma separated and other lists are YAML sequences
+func setEnvValue(field reflect.Value, value string) error {
+	if field.Type() == durationType {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(duration))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() == reflect.String {
+			var s stringsValue
+			if err := s.Set(value); err != nil {
+				return err
+			}
+			field.Set(reflect.ValueOf([]string(s)))
+			return nil
+		}
+		return yaml.Unmarshal([]byte(value), field.Addr().Interface())
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+
+	return nil
+}
+
+// ParseEnv returns a config based on the defaults with fields overridden by
+// environment variables. The lookup function returns values of variables
+func ParseEnv(lookup func(name string) (string, bool), defaults Config) (Config, error) {
+	config := defaults
+
+	for _, v := range envVars() {
+		value, ok := lookup(v.name)
+		if !ok {
+			continue
+		}
+
+		field := reflect.ValueOf(&config).Elem().FieldByIndex(v.field)
+		if err := setEnvValue(field, value); err != nil {
+			return defaults, &errParseEnv{
+				name: v.name,
+				err:  err,
+			}
+		}
+	}
+
+	if port, ok := lookup(envVarPort); ok {
+		if _, ok := lookup(envVarServerAddress); !ok {
+			config.Server.Address = ":" + port
+		}
+	}
+
+	return config, nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+)
+
+// reloadableFields are the labels of fields which can be changed while the
+// server is running
+var reloadableFields = map[string]struct{}{
+	fieldLabelLogLevel:             {},
+	fieldLabelGroupsLimit:          {},
+	fieldLabelConnsLimit:           {},
+	fieldLabelFlagsForbidCORS:      {},
+	fieldLabelFlagsEnableBroadcast: {},
+}
+
+// Diff returns sorted labels of the fields which differ in the configs
+func (c Config) Diff(other Config) []string {
+	fields := c.Fields()
+	otherFields := other.Fields()
+	labels := make([]string, 0)
+
+	for label, value := range fields {
+		if label == fieldLabelAuthTokens {
+			// Fields contain the number of tokens only
+			if !reflect.DeepEqual(c.Server.Auth.Tokens, other.Server.Auth.Tokens) {
+				labels = append(labels, label)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(value, otherFields[label]) {
+			labels = append(labels, label)
+		}
+	}
+
+	sort.Strings(labels)
+
+	return labels
+}
+
+// Reload returns the config with the reloadable fields taken from the next
+// config. It also returns sorted labels of the fields which differ in the next
+// config but cannot be changed at runtime
+func (c Config) Reload(next Config) (Config, []string) {
+	config := c
+	config.Server.Log.Level = next.Server.Log.Level
+	config.Server.Limits.Groups = next.Server.Limits.Groups
+	config.Server.Limits.Conns = next.Server.Limits.Conns
+	config.Server.Flags.ForbidCORS = next.Server.Flags.ForbidCORS
+	config.Server.Flags.EnableBroadcast = next.Server.Flags.EnableBroadcast
+
+	rejected := make([]string, 0)
+	for _, label := range c.Diff(next) {
+		if _, ok := reloadableFields[label]; !ok {
+			rejected = append(rejected, label)
+		}
+	}
+
+	return config, rejected
+}
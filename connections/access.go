@@ -0,0 +1,86 @@
+package connections
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+)
+
+const (
+	inviteTokenSize  = 16
+	passwordSaltSize = 16
+)
+
+// Access defines who is allowed to join a group. Anyone can join a public
+// group. A private group can be joined with an invite token or a password if
+// the password is set
+type Access struct {
+	Private      bool   `json:"private"`
+	InviteToken  string `json:"invite_token,omitempty"`
+	PasswordSalt []byte `json:"password_salt,omitempty"`
+	PasswordHash []byte `json:"password_hash,omitempty"`
+}
+
+type errAccess string
+
+func (e errAccess) Error() string {
+	return "access error: " + string(e)
+}
+
+func randomBytes(size int) ([]byte, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return nil, errAccess(err.Error())
+	}
+	return b, nil
+}
+
+func hashPassword(salt []byte, password string) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(password))
+	return h.Sum(nil)
+}
+
+// newPrivateAccess creates a private access with a new invite token. The
+// password is optional
+func newPrivateAccess(password string) (Access, error) {
+	token, err := randomBytes(inviteTokenSize)
+	if err != nil {
+		return Access{}, err
+	}
+
+	access := Access{
+		Private:     true,
+		InviteToken: hex.EncodeToString(token),
+	}
+
+	if len(password) > 0 {
+		salt, err := randomBytes(passwordSaltSize)
+		if err != nil {
+			return Access{}, err
+		}
+		access.PasswordSalt = salt
+		access.PasswordHash = hashPassword(salt, password)
+	}
+
+	return access, nil
+}
+
+// allowed returns true if the invite token or the password grants the access
+func (a Access) allowed(inviteToken, password string) bool {
+	if !a.Private {
+		return true
+	}
+
+	if len(inviteToken) > 0 && subtle.ConstantTimeCompare([]byte(a.InviteToken), []byte(inviteToken)) == 1 {
+		return true
+	}
+
+	if len(password) > 0 && len(a.PasswordHash) > 0 {
+		return subtle.ConstantTimeCompare(a.PasswordHash, hashPassword(a.PasswordSalt, password)) == 1
+	}
+
+	return false
+}
@@ -0,0 +1,31 @@
+package connections
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Access_allowed(t *testing.T) {
+	require.True(t, Access{}.allowed("", ""))
+
+	access, err := newPrivateAccess("")
+	require.Nil(t, err)
+	require.True(t, access.Private)
+	require.Len(t, access.InviteToken, inviteTokenSize*2)
+	require.Empty(t, access.PasswordHash)
+
+	require.True(t, access.allowed(access.InviteToken, ""))
+	require.False(t, access.allowed("", ""))
+	require.False(t, access.allowed("", "password"))
+	require.False(t, access.allowed(access.InviteToken[1:], ""))
+
+	access, err = newPrivateAccess("password")
+	require.Nil(t, err)
+	require.NotEmpty(t, access.PasswordHash)
+
+	require.True(t, access.allowed(access.InviteToken, ""))
+	require.True(t, access.allowed("", "password"))
+	require.True(t, access.allowed("invalid", "password"))
+	require.False(t, access.allowed("", "Password"))
+}
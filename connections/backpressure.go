@@ -0,0 +1,300 @@
+package connections
+
+import (
+	"container/list"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pquerna/ffjson/ffjson"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ivan1993spb/snake-server/game"
+	"github.com/ivan1993spb/snake-server/player"
+)
+
+// BackpressurePolicy defines what happens to a connection which cannot
+// receive game messages as fast as they are produced
+type BackpressurePolicy uint8
+
+const (
+	// BackpressurePolicyDrop drops messages which cannot be sent in time
+	BackpressurePolicyDrop BackpressurePolicy = iota
+	// BackpressurePolicyDisconnect drops messages and disconnects the client
+	// when the limit of dropped messages is reached
+	BackpressurePolicyDisconnect
+	// BackpressurePolicyResync drops messages and sends the client a fresh
+	// snapshot of all objects as soon as it catches up
+	BackpressurePolicyResync
+	// BackpressurePolicyCoalesce queues messages and keeps only the latest
+	// update of every object in the queue
+	BackpressurePolicyCoalesce
+)
+
+var backpressurePolicyLabels = map[BackpressurePolicy]string{
+	BackpressurePolicyDrop:       "drop",
+	BackpressurePolicyDisconnect: "disconnect",
+	BackpressurePolicyResync:     "resync",
+	BackpressurePolicyCoalesce:   "coalesce",
+}
+
+func (p BackpressurePolicy) String() string {
+	if label, ok := backpressurePolicyLabels[p]; ok {
+		return label
+	}
+	return "unknown"
+}
+
+var ErrUnknownBackpressurePolicy = errors.New("unknown backpressure policy")
+
+// ParseBackpressurePolicy returns the policy by its label
+func ParseBackpressurePolicy(label string) (BackpressurePolicy, error) {
+	for policy, policyLabel := range backpressurePolicyLabels {
+		if strings.EqualFold(policyLabel, label) {
+			return policy, nil
+		}
+	}
+	return 0, ErrUnknownBackpressurePolicy
+}
+
+func (p BackpressurePolicy) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + p.String() + `"`), nil
+}
+
+func (p *BackpressurePolicy) UnmarshalJSON(data []byte) error {
+	policy, err := ParseBackpressurePolicy(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*p = policy
+	return nil
+}
+
+// Backpressure configures how a group treats slow connections
+type Backpressure struct {
+	Policy BackpressurePolicy `json:"policy"`
+	// MaxDrops is the number of dropped messages after which a client is
+	// disconnected with BackpressurePolicyDisconnect
+	MaxDrops int `json:"max_drops"`
+}
+
+const defaultBackpressureMaxDrops = 100
+
+// DefaultBackpressure returns the backpressure configuration of new groups
+func DefaultBackpressure() Backpressure {
+	return Backpressure{
+		Policy:   BackpressurePolicyDrop,
+		MaxDrops: defaultBackpressureMaxDrops,
+	}
+}
+
+const (
+	// chanPreparedMessageCoalesceBuffer is small to keep messages in the
+	// coalescing queue rather than in channels
+	chanPreparedMessageCoalesceBuffer = 16
+
+	resyncCheckDelay = time.Millisecond * 100
+)
+
+const closeReasonTooSlow = "connection is too slow"
+
+// coalescingKey returns a key to coalesce update events of the same object or
+// nil if the event cannot be coalesced. Objects are identified by pointers
+func coalescingKey(event game.Event) interface{} {
+	if event.Type != game.EventTypeObjectUpdate || event.Payload == nil {
+		return nil
+	}
+	if reflect.TypeOf(event.Payload).Kind() != reflect.Ptr {
+		return nil
+	}
+	return event.Payload
+}
+
+// coalescingQueue is a queue of messages in which a new message with a key
+// replaces the queued message with the same key. The new message is put in
+// the end of the queue so that the messages keep the order of the sequence
+type coalescingQueue struct {
+	messages *list.List
+	keys     map[interface{}]*list.Element
+	limit    int
+}
+
+func newCoalescingQueue(limit int) *coalescingQueue {
+	return &coalescingQueue{
+		messages: list.New(),
+		keys:     make(map[interface{}]*list.Element),
+		limit:    limit,
+	}
+}
+
+// Push adds the message to the queue. It returns false if the queue is full
+func (q *coalescingQueue) Push(pm *preparedMessage) bool {
+	if pm.key != nil {
+		if element, ok := q.keys[pm.key]; ok {
+			q.messages.Remove(element)
+			q.keys[pm.key] = q.messages.PushBack(pm)
+			return true
+		}
+	}
+
+	if q.messages.Len() >= q.limit {
+		return false
+	}
+
+	element := q.messages.PushBack(pm)
+	if pm.key != nil {
+		q.keys[pm.key] = element
+	}
+
+	return true
+}
+
+// Front returns the first message or nil if the queue is empty
+func (q *coalescingQueue) Front() *preparedMessage {
+	if element := q.messages.Front(); element != nil {
+		return element.Value.(*preparedMessage)
+	}
+	return nil
+}
+
+// Pop removes the first message
+func (q *coalescingQueue) Pop() {
+	if element := q.messages.Front(); element != nil {
+		pm := q.messages.Remove(element).(*preparedMessage)
+		if pm.key != nil {
+			delete(q.keys, pm.key)
+		}
+	}
+}
+
+func (q *coalescingQueue) Len() int {
+	return q.messages.Len()
+}
+
+// proxyDrop passes messages from the group channel to the connection. Messages
+// which cannot be sent in time are dropped and the policy is applied
+func (cg *ConnectionGroup) proxyDrop(stop <-chan struct{}, chin <-chan *preparedMessage,
+	chout chan *preparedMessage, chSlow chan struct{}, bp Backpressure) {
+	var (
+		drops  = 0
+		resync = false
+	)
+
+	ticker := time.NewTicker(resyncCheckDelay)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-cg.stop:
+			return
+		case <-ticker.C:
+			if resync && cg.resync(chout) {
+				resync = false
+			}
+		case message, ok := <-chin:
+			if !ok {
+				return
+			}
+
+			if resync {
+				// The message is covered by the objects snapshot
+				if cg.resync(chout) {
+					resync = false
+				}
+				continue
+			}
+
+			if cg.sendTimeout(chout, message, stop, sendPreparedMessageTimeout) {
+				continue
+			}
+
+			drops++
+
+			switch bp.Policy {
+			case BackpressurePolicyDisconnect:
+				if drops >= bp.MaxDrops {
+					cg.logger.WithField("drops", drops).Warn("disconnect slow connection")
+					close(chSlow)
+					return
+				}
+			case BackpressurePolicyResync:
+				resync = true
+			}
+		}
+	}
+}
+
+// proxyCoalesce passes messages from the group channel to the connection
+// through a coalescing queue. Messages are dropped only if the queue is full
+func (cg *ConnectionGroup) proxyCoalesce(stop <-chan struct{}, chin <-chan *preparedMessage,
+	chout chan *preparedMessage, limit int) {
+	queue := newCoalescingQueue(limit)
+	drops := 0
+
+	for {
+		var (
+			out  chan *preparedMessage
+			next *preparedMessage
+		)
+
+		if queue.Len() > 0 {
+			out = chout
+			next = queue.Front()
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-cg.stop:
+			return
+		case message, ok := <-chin:
+			if !ok {
+				return
+			}
+
+			if !queue.Push(message) {
+				drops++
+				cg.logger.WithField("drops", drops).Warn("coalescing queue is overflow")
+			}
+		case out <- next:
+			queue.Pop()
+		}
+	}
+}
+
+// resync sends a snapshot of all objects in the game to the connection if it
+// has caught up with the group. It returns true if the snapshot has been sent
+func (cg *ConnectionGroup) resync(ch chan *preparedMessage) bool {
+	if len(ch) > cap(ch)/2 {
+		return false
+	}
+
+	data, err := ffjson.Marshal(OutputMessage{
+		Type:    OutputMessageTypePlayer,
+		Payload: player.NewMessageObjects(cg.game.World().GetObjects()),
+		Seq:     cg.GetSeq(),
+	})
+	if err != nil {
+		cg.logger.Errorln("encode objects message error:", err)
+		return false
+	}
+
+	pm, err := newPreparedMessage(data)
+	if err != nil {
+		cg.logger.Errorln("prepare objects message error:", err)
+		return false
+	}
+
+	select {
+	case ch <- pm:
+		cg.logger.WithFields(logrus.Fields{
+			"seq": cg.GetSeq(),
+		}).Debug("connection resynced")
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,214 @@
+package connections
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ivan1993spb/snake-server/game"
+)
+
+func Test_ParseBackpressurePolicy(t *testing.T) {
+	for policy, label := range backpressurePolicyLabels {
+		parsed, err := ParseBackpressurePolicy(label)
+		require.Nil(t, err)
+		require.Equal(t, policy, parsed)
+	}
+
+	_, err := ParseBackpressurePolicy("invalid")
+	require.Equal(t, ErrUnknownBackpressurePolicy, err)
+}
+
+func Test_Backpressure_JSON(t *testing.T) {
+	data, err := json.Marshal(Backpressure{
+		Policy:   BackpressurePolicyCoalesce,
+		MaxDrops: 5,
+	})
+	require.Nil(t, err)
+	require.JSONEq(t, `{"policy":"coalesce","max_drops":5}`, string(data))
+
+	var bp Backpressure
+	require.Nil(t, json.Unmarshal(data, &bp))
+	require.Equal(t, Backpressure{
+		Policy:   BackpressurePolicyCoalesce,
+		MaxDrops: 5,
+	}, bp)
+}
+
+func Test_coalescingQueue(t *testing.T) {
+	first, second := &struct{ n int }{1}, &struct{ n int }{2}
+
+	queue := newCoalescingQueue(3)
+
+	create := &preparedMessage{}
+	update1 := &preparedMessage{key: first}
+	update2 := &preparedMessage{key: second}
+	update3 := &preparedMessage{key: first}
+	broadcast := &preparedMessage{}
+
+	require.True(t, queue.Push(create))
+	require.True(t, queue.Push(update1))
+	require.True(t, queue.Push(update2))
+	// Replaces the first update
+	require.True(t, queue.Push(update3))
+	require.Equal(t, 3, queue.Len())
+	// The queue is full
+	require.False(t, queue.Push(broadcast))
+
+	expected := []*preparedMessage{create, update2, update3}
+	for _, pm := range expected {
+		require.Equal(t, pm, queue.Front())
+		queue.Pop()
+	}
+	require.Equal(t, 0, queue.Len())
+	require.Nil(t, queue.Front())
+
+	// The key has been released
+	require.True(t, queue.Push(update1))
+	require.True(t, queue.Push(update3))
+	require.Equal(t, 1, queue.Len())
+	require.Equal(t, update3, queue.Front())
+}
+
+func Test_coalescingQueue_KeepsSequenceOrder(t *testing.T) {
+	first, second := &struct{ n int }{1}, &struct{ n int }{2}
+	keys := []interface{}{first, second, first, nil, second, first, nil, first}
+
+	queue := newCoalescingQueue(len(keys))
+
+	for i, key := range keys {
+		data, err := json.Marshal(OutputMessage{
+			Type: OutputMessageTypeGame,
+			Seq:  uint64(i + 1),
+		})
+		require.Nil(t, err)
+		require.True(t, queue.Push(newTestPreparedMessage(t, string(data), key)))
+	}
+
+	var seq uint64
+	for queue.Len() > 0 {
+		var message struct {
+			Seq uint64 `json:"seq"`
+		}
+		require.Nil(t, json.Unmarshal(queue.Front().data, &message))
+		require.True(t, message.Seq > seq, "seq %d after %d", message.Seq, seq)
+		seq = message.Seq
+		queue.Pop()
+	}
+	require.Equal(t, uint64(len(keys)), seq)
+}
+
+func newTestPreparedMessage(t *testing.T, data string, key interface{}) *preparedMessage {
+	pm, err := newPreparedMessage([]byte(data))
+	require.Nil(t, err)
+	pm.key = key
+	return pm
+}
+
+func Test_ConnectionGroup_proxyCh_DisconnectsSlowConnection(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	group, err := NewConnectionGroup(logger, 5, 20, 20, game.DefaultConfig())
+	require.Nil(t, err)
+	defer group.Stop()
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	_, chSlow := group.proxyCh(stop, 1, Backpressure{
+		Policy:   BackpressurePolicyDisconnect,
+		MaxDrops: 2,
+	})
+
+	for i := 0; i < 3; i++ {
+		group.doBroadcast(newTestPreparedMessage(t, `{}`, nil))
+	}
+
+	select {
+	case <-chSlow:
+	case <-time.After(time.Second):
+		t.Fatal("slow connection is not disconnected")
+	}
+
+	code, reason := group.getCloseReason(chSlow)
+	require.Equal(t, closeReasonTooSlow, reason)
+	require.NotZero(t, code)
+}
+
+func Test_ConnectionGroup_proxyCh_ResyncsSlowConnection(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	group, err := NewConnectionGroup(logger, 5, 20, 20, game.DefaultConfig())
+	require.Nil(t, err)
+	defer group.Stop()
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	chout, _ := group.proxyCh(stop, 2, Backpressure{
+		Policy: BackpressurePolicyResync,
+	})
+
+	for i := 0; i < 3; i++ {
+		group.doBroadcast(newTestPreparedMessage(t, `{}`, nil))
+	}
+
+	// Two messages are buffered and the third one is dropped
+	require.True(t, eventually(func() bool {
+		return len(chout) == 2
+	}, time.Second))
+	time.Sleep(sendPreparedMessageTimeout * 2)
+	<-chout
+	<-chout
+
+	select {
+	case pm := <-chout:
+		require.Contains(t, string(pm.data), `"objects"`)
+	case <-time.After(time.Second):
+		t.Fatal("resync message is not received")
+	}
+}
+
+func Test_ConnectionGroup_proxyCh_CoalescesUpdates(t *testing.T) {
+	const messagesCount = 100
+
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	group, err := NewConnectionGroup(logger, 5, 20, 20, game.DefaultConfig())
+	require.Nil(t, err)
+	defer group.Stop()
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	chout, _ := group.proxyCh(stop, chanPreparedMessageOutBuffer, Backpressure{
+		Policy: BackpressurePolicyCoalesce,
+	})
+
+	object := &struct{ n int }{}
+	for i := 1; i <= messagesCount; i++ {
+		group.doBroadcast(newTestPreparedMessage(t, strconv.Itoa(i), object))
+	}
+
+	var received []string
+	for {
+		select {
+		case pm := <-chout:
+			received = append(received, string(pm.data))
+			continue
+		case <-time.After(time.Millisecond * 200):
+		}
+		break
+	}
+
+	require.True(t, len(received) < messagesCount, strings.Join(received, ","))
+	require.Equal(t, strconv.Itoa(messagesCount), received[len(received)-1])
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/ivan1993spb/snake-server/broadcast"
 	"github.com/ivan1993spb/snake-server/game"
+	"github.com/ivan1993spb/snake-server/playground"
 )
 
 const (
@@ -35,23 +36,51 @@ const (
 	minimalConnectionLimit = 1
 )
 
+const closeReasonGameStopped = "game stopped"
+
 type ConnectionGroup struct {
+	// seq is the sequence number of the last game message. It is the first
+	// field to be aligned for atomic operations
+	seq uint64
+
 	limit      int
 	counter    int
 	counterMux *sync.RWMutex
 
+	// emptySince is the time when the last player left the group
+	emptySince time.Time
+	persistent int32
+
 	rate uint32
 
+	access    Access
+	accessMux *sync.RWMutex
+
+	metadata    Metadata
+	metadataMux *sync.RWMutex
+
+	backpressure    Backpressure
+	backpressureMux *sync.RWMutex
+
 	logger logrus.FieldLogger
 
 	game      *game.Game
 	broadcast *broadcast.GroupBroadcast
 
-	chs    []chan *websocket.PreparedMessage
+	chs    []chan *preparedMessage
 	chsMux *sync.RWMutex
 
 	stop    chan struct{}
 	stopper *sync.Once
+
+	draining int32
+
+	// disconnect is closed to close all the connections of the group with
+	// the closeCode and the closeReason
+	disconnect   chan struct{}
+	disconnecter *sync.Once
+	closeCode    int
+	closeReason  string
 }
 
 type errCreateConnectionGroup string
@@ -60,28 +89,60 @@ func (e errCreateConnectionGroup) Error() string {
 	return "cannot create connection group: " + string(e)
 }
 
-func NewConnectionGroup(logger logrus.FieldLogger, connectionLimit int, width, height uint8, enableWalls bool) (*ConnectionGroup, error) {
-	g, err := game.NewGame(logger, width, height, game.Config{
-		EnableWalls: enableWalls,
-	})
+// NewConnectionGroup creates a group with a new game of the configuration
+func NewConnectionGroup(logger logrus.FieldLogger, connectionLimit int, width, height uint8,
+	config game.Config) (*ConnectionGroup, error) {
+	g, err := game.NewGame(logger, width, height, config)
 	if err != nil {
 		return nil, errCreateConnectionGroup(err.Error())
 	}
 
+	return newConnectionGroup(logger, connectionLimit, g)
+}
+
+// NewConnectionGroupFromSnapshot creates a group with a game which will be
+// restored from the snapshot on start. The walls and the playground of the
+// configuration are taken from the snapshot
+func NewConnectionGroupFromSnapshot(logger logrus.FieldLogger, connectionLimit int, snapshot *game.Snapshot,
+	config game.Config) (*ConnectionGroup, error) {
+	g, err := game.NewGameFromSnapshot(logger, snapshot, config)
+	if err != nil {
+		return nil, errCreateConnectionGroup(err.Error())
+	}
+
+	return newConnectionGroup(logger, connectionLimit, g)
+}
+
+func newConnectionGroup(logger logrus.FieldLogger, connectionLimit int, g *game.Game) (*ConnectionGroup, error) {
 	if connectionLimit < minimalConnectionLimit {
 		return nil, errCreateConnectionGroup("invalid connection limit")
 	}
 
+	now := time.Now()
+
 	return &ConnectionGroup{
 		limit:      connectionLimit,
 		counterMux: &sync.RWMutex{},
-		game:       g,
-		broadcast:  broadcast.NewGroupBroadcast(),
-		logger:     logger,
-		chs:        make([]chan *websocket.PreparedMessage, 0),
-		chsMux:     &sync.RWMutex{},
-		stop:       make(chan struct{}),
-		stopper:    &sync.Once{},
+		emptySince: now,
+		accessMux:  &sync.RWMutex{},
+		metadata: Metadata{
+			CreatedAt: now,
+		},
+		metadataMux: &sync.RWMutex{},
+
+		backpressure:    DefaultBackpressure(),
+		backpressureMux: &sync.RWMutex{},
+
+		game:      g,
+		broadcast: broadcast.NewGroupBroadcast(),
+		logger:    logger,
+		chs:       make([]chan *preparedMessage, 0),
+		chsMux:    &sync.RWMutex{},
+		stop:      make(chan struct{}),
+		stopper:   &sync.Once{},
+
+		disconnect:   make(chan struct{}),
+		disconnecter: &sync.Once{},
 	}, nil
 }
 
@@ -103,6 +164,90 @@ func (cg *ConnectionGroup) GetCount() int {
 	return cg.counter
 }
 
+// MakePrivate hides the group and restricts joining to players with the
+// returned invite token or with the password if it is not empty
+func (cg *ConnectionGroup) MakePrivate(password string) (string, error) {
+	access, err := newPrivateAccess(password)
+	if err != nil {
+		return "", errCreateConnectionGroup(err.Error())
+	}
+
+	cg.accessMux.Lock()
+	cg.access = access
+	cg.accessMux.Unlock()
+
+	return access.InviteToken, nil
+}
+
+// IsPrivate returns true if the group is private
+func (cg *ConnectionGroup) IsPrivate() bool {
+	cg.accessMux.RLock()
+	defer cg.accessMux.RUnlock()
+	return cg.access.Private
+}
+
+// Allowed returns true if a player with the invite token or the password is
+// allowed to join the group
+func (cg *ConnectionGroup) Allowed(inviteToken, password string) bool {
+	cg.accessMux.RLock()
+	defer cg.accessMux.RUnlock()
+	return cg.access.allowed(inviteToken, password)
+}
+
+func (cg *ConnectionGroup) getAccess() Access {
+	cg.accessMux.RLock()
+	defer cg.accessMux.RUnlock()
+	return cg.access
+}
+
+func (cg *ConnectionGroup) setAccess(access Access) {
+	cg.accessMux.Lock()
+	cg.access = access
+	cg.accessMux.Unlock()
+}
+
+// GetMetadata returns the group's metadata
+func (cg *ConnectionGroup) GetMetadata() Metadata {
+	cg.metadataMux.RLock()
+	defer cg.metadataMux.RUnlock()
+	return cg.metadata.copy()
+}
+
+// SetMetadata sets the group's metadata. If the creation time is not set in
+// the passed metadata, the current creation time of the group is kept
+func (cg *ConnectionGroup) SetMetadata(metadata Metadata) {
+	metadata = metadata.copy()
+
+	cg.metadataMux.Lock()
+	defer cg.metadataMux.Unlock()
+
+	if metadata.CreatedAt.IsZero() {
+		metadata.CreatedAt = cg.metadata.CreatedAt
+	}
+
+	cg.metadata = metadata
+}
+
+// GetBackpressure returns the policy for slow connections of the group
+func (cg *ConnectionGroup) GetBackpressure() Backpressure {
+	cg.backpressureMux.RLock()
+	defer cg.backpressureMux.RUnlock()
+	return cg.backpressure
+}
+
+// SetBackpressure sets the policy for slow connections. It is applied to
+// players which join the group after the call
+func (cg *ConnectionGroup) SetBackpressure(bp Backpressure) {
+	cg.backpressureMux.Lock()
+	cg.backpressure = bp
+	cg.backpressureMux.Unlock()
+}
+
+// GetSeq returns the sequence number of the last game message
+func (cg *ConnectionGroup) GetSeq() uint64 {
+	return atomic.LoadUint64(&cg.seq)
+}
+
 // unsafeIsFull returns true if group is full
 func (cg *ConnectionGroup) unsafeIsFull() bool {
 	return cg.counter == cg.limit
@@ -125,6 +270,58 @@ func (cg *ConnectionGroup) IsEmpty() bool {
 	return cg.unsafeIsEmpty()
 }
 
+// IdleSince returns the time since the group has been empty. The second
+// returned value is false if there are players in the group
+func (cg *ConnectionGroup) IdleSince() (time.Time, bool) {
+	cg.counterMux.RLock()
+	defer cg.counterMux.RUnlock()
+	if !cg.unsafeIsEmpty() {
+		return time.Time{}, false
+	}
+	return cg.emptySince, true
+}
+
+// closeIfIdle stops accepting players if the group is not persistent and has
+// been empty longer than the ttl. The check and the closing are done under the
+// counter lock, so that no player can join in between. closeIfIdle returns how
+// long the group has been empty and true if the group has been closed
+func (cg *ConnectionGroup) closeIfIdle(ttl time.Duration, now time.Time) (time.Duration, bool) {
+	if cg.IsPersistent() {
+		return 0, false
+	}
+
+	cg.counterMux.Lock()
+	defer cg.counterMux.Unlock()
+
+	if !cg.unsafeIsEmpty() {
+		return 0, false
+	}
+
+	idle := now.Sub(cg.emptySince)
+	if idle < ttl {
+		return 0, false
+	}
+
+	cg.Drain()
+
+	return idle, true
+}
+
+// SetPersistent sets the flag which protects the group from being deleted by
+// the idle groups reaper
+func (cg *ConnectionGroup) SetPersistent(persistent bool) {
+	if persistent {
+		atomic.StoreInt32(&cg.persistent, 1)
+	} else {
+		atomic.StoreInt32(&cg.persistent, 0)
+	}
+}
+
+// IsPersistent returns true if the group is never deleted by the reaper
+func (cg *ConnectionGroup) IsPersistent() bool {
+	return atomic.LoadInt32(&cg.persistent) == 1
+}
+
 type ErrHandleConnection struct {
 	Err error
 }
@@ -133,10 +330,19 @@ func (e *ErrHandleConnection) Error() string {
 	return "handle connection error: " + e.Err.Error()
 }
 
-var ErrGroupIsFull = errors.New("group is full")
+var (
+	ErrGroupIsFull     = errors.New("group is full")
+	ErrGroupIsDraining = errors.New("group is draining")
+)
 
 func (cg *ConnectionGroup) Handle(connectionWorker *ConnectionWorker) error {
 	cg.counterMux.Lock()
+	if cg.IsDraining() {
+		cg.counterMux.Unlock()
+		return &ErrHandleConnection{
+			Err: ErrGroupIsDraining,
+		}
+	}
 	if cg.unsafeIsFull() {
 		cg.counterMux.Unlock()
 		return &ErrHandleConnection{
@@ -149,15 +355,24 @@ func (cg *ConnectionGroup) Handle(connectionWorker *ConnectionWorker) error {
 	defer func() {
 		cg.counterMux.Lock()
 		cg.counter -= 1
+		if cg.unsafeIsEmpty() {
+			cg.emptySince = time.Now()
+		}
 		cg.counterMux.Unlock()
 	}()
 
 	chStopHandle := make(chan struct{})
 	defer close(chStopHandle)
 
-	chout := cg.proxyCh(chStopHandle, chanPreparedMessageOutBuffer)
+	chout, chSlow := cg.proxyCh(chStopHandle, chanPreparedMessageOutBuffer, cg.GetBackpressure())
 
-	if err := connectionWorker.Start(cg.stop, cg.game, cg.broadcast, chout); err != nil {
+	err := connectionWorker.Start(cg.stopOrDisconnect(chStopHandle, chSlow), cg.game, cg.broadcast, chout)
+
+	if closeErr := connectionWorker.Close(cg.getCloseReason(chSlow)); closeErr != nil {
+		cg.logger.WithError(closeErr).Debug("close connection error")
+	}
+
+	if err != nil {
 		return &ErrHandleConnection{
 			Err: err,
 		}
@@ -166,6 +381,69 @@ func (cg *ConnectionGroup) Handle(connectionWorker *ConnectionWorker) error {
 	return nil
 }
 
+// stopOrDisconnect returns a channel which is closed when the group is
+// stopped, its connections are disconnected or the connection is too slow
+func (cg *ConnectionGroup) stopOrDisconnect(stop, slow <-chan struct{}) <-chan struct{} {
+	chout := make(chan struct{})
+
+	go func() {
+		defer close(chout)
+
+		select {
+		case <-cg.stop:
+		case <-cg.disconnect:
+		case <-slow:
+		case <-stop:
+		}
+	}()
+
+	return chout
+}
+
+// getCloseReason returns a close code and a reason to be sent to players
+// which are leaving the group
+func (cg *ConnectionGroup) getCloseReason(slow <-chan struct{}) (int, string) {
+	select {
+	case <-slow:
+		return websocket.ClosePolicyViolation, closeReasonTooSlow
+	default:
+	}
+
+	select {
+	case <-cg.disconnect:
+		return cg.closeCode, cg.closeReason
+	default:
+	}
+
+	select {
+	case <-cg.stop:
+		return websocket.CloseGoingAway, closeReasonGameStopped
+	default:
+	}
+
+	return websocket.CloseNormalClosure, ""
+}
+
+// Drain forbids new players to join the group
+func (cg *ConnectionGroup) Drain() {
+	atomic.StoreInt32(&cg.draining, 1)
+}
+
+// IsDraining returns true if new players are not allowed to join the group
+func (cg *ConnectionGroup) IsDraining() bool {
+	return atomic.LoadInt32(&cg.draining) == 1
+}
+
+// Disconnect closes all the connections of the group. Players receive a close
+// frame with the code and the reason
+func (cg *ConnectionGroup) Disconnect(code int, reason string) {
+	cg.disconnecter.Do(func() {
+		cg.closeCode = code
+		cg.closeReason = reason
+		close(cg.disconnect)
+	})
+}
+
 func (cg *ConnectionGroup) Start() {
 	cg.broadcast.Start(cg.stop)
 	cg.game.Start(cg.stop)
@@ -177,7 +455,7 @@ func (cg *ConnectionGroup) Start() {
 	cg.broadcastPreparedMessages(chPreparedMessages)
 }
 
-func (cg *ConnectionGroup) broadcastPreparedMessages(chin <-chan *websocket.PreparedMessage) {
+func (cg *ConnectionGroup) broadcastPreparedMessages(chin <-chan *preparedMessage) {
 	go func() {
 		for {
 			select {
@@ -194,7 +472,7 @@ func (cg *ConnectionGroup) broadcastPreparedMessages(chin <-chan *websocket.Prep
 	}()
 }
 
-func (cg *ConnectionGroup) doBroadcast(pm *websocket.PreparedMessage) {
+func (cg *ConnectionGroup) doBroadcast(pm *preparedMessage) {
 	cg.chsMux.RLock()
 	defer cg.chsMux.RUnlock()
 
@@ -225,8 +503,27 @@ func (cg *ConnectionGroup) GetObjects() interface{} {
 	return cg.game.World().GetObjects()
 }
 
-func (cg *ConnectionGroup) createChan() chan *websocket.PreparedMessage {
-	ch := make(chan *websocket.PreparedMessage, chanPreparedMessageProxyBuffer)
+// GetPlaygroundBackend returns the implementation of the game's playground
+func (cg *ConnectionGroup) GetPlaygroundBackend() playground.Backend {
+	return cg.game.PlaygroundBackend()
+}
+
+// GetInvulnerability returns the invulnerability window of new snakes in the game
+func (cg *ConnectionGroup) GetInvulnerability() time.Duration {
+	return cg.game.SnakeConfig().Invulnerability
+}
+
+// GetIdentifiersCount returns the number of live object identifiers in the game
+func (cg *ConnectionGroup) GetIdentifiersCount() int {
+	return cg.game.World().IdentifierRegistry().Count()
+}
+
+func (cg *ConnectionGroup) Snapshot() (*game.Snapshot, error) {
+	return cg.game.Snapshot()
+}
+
+func (cg *ConnectionGroup) createChan() chan *preparedMessage {
+	ch := make(chan *preparedMessage, chanPreparedMessageProxyBuffer)
 
 	cg.chsMux.Lock()
 	cg.chs = append(cg.chs, ch)
@@ -235,7 +532,7 @@ func (cg *ConnectionGroup) createChan() chan *websocket.PreparedMessage {
 	return ch
 }
 
-func (cg *ConnectionGroup) deleteChan(ch chan *websocket.PreparedMessage) {
+func (cg *ConnectionGroup) deleteChan(ch chan *preparedMessage) {
 	go func() {
 		for range ch {
 		}
@@ -252,38 +549,40 @@ func (cg *ConnectionGroup) deleteChan(ch chan *websocket.PreparedMessage) {
 	cg.chsMux.Unlock()
 }
 
-func (cg *ConnectionGroup) proxyCh(stop <-chan struct{}, buffer uint) <-chan *websocket.PreparedMessage {
+// proxyCh returns a channel of the group messages for a connection and a
+// channel which is closed if the connection is too slow and it has to be
+// disconnected according to the backpressure policy
+func (cg *ConnectionGroup) proxyCh(stop <-chan struct{}, buffer uint, bp Backpressure) (<-chan *preparedMessage, <-chan struct{}) {
 	ch := cg.createChan()
-	chOut := make(chan *websocket.PreparedMessage, buffer)
+	chSlow := make(chan struct{})
+
+	if bp.Policy == BackpressurePolicyCoalesce {
+		buffer = chanPreparedMessageCoalesceBuffer
+	}
+	chOut := make(chan *preparedMessage, buffer)
 
 	go func() {
 		defer close(chOut)
 		defer cg.deleteChan(ch)
 
-		for {
-			select {
-			case <-stop:
-				return
-			case <-cg.stop:
-				return
-			case message, ok := <-ch:
-				if !ok {
-					return
-				}
-				cg.sendTimeout(chOut, message, stop, sendPreparedMessageTimeout)
-			}
+		if bp.Policy == BackpressurePolicyCoalesce {
+			cg.proxyCoalesce(stop, ch, chOut, chanPreparedMessageOutBuffer)
+		} else {
+			cg.proxyDrop(stop, ch, chOut, chSlow, bp)
 		}
 	}()
 
-	return chOut
+	return chOut, chSlow
 }
 
-func (cg *ConnectionGroup) sendTimeout(ch chan *websocket.PreparedMessage, pm *websocket.PreparedMessage, stop <-chan struct{}, timeout time.Duration) {
+// sendTimeout returns true if the message has been sent
+func (cg *ConnectionGroup) sendTimeout(ch chan *preparedMessage, pm *preparedMessage, stop <-chan struct{}, timeout time.Duration) bool {
 	const warnFormat = "game group message was not send to connection: %s"
 	var timer = time.NewTimer(timeout)
 	defer timer.Stop()
 	select {
 	case ch <- pm:
+		return true
 	case <-cg.stop:
 		cg.logger.Warnf(warnFormat, "game group stopped")
 	case <-stop:
@@ -294,6 +593,7 @@ func (cg *ConnectionGroup) sendTimeout(ch chan *websocket.PreparedMessage, pm *w
 			cg.logger.Warn("connection group output channel buffer is overflow for connection")
 		}
 	}
+	return false
 }
 
 func (cg *ConnectionGroup) listenGame(stop <-chan struct{}, chin <-chan game.Event) <-chan OutputMessage {
@@ -327,6 +627,8 @@ func (cg *ConnectionGroup) listenGame(stop <-chan struct{}, chin <-chan game.Eve
 				outputMessage := OutputMessage{
 					Type:    OutputMessageTypeGame,
 					Payload: event,
+					Seq:     atomic.AddUint64(&cg.seq, 1),
+					key:     coalescingKey(event),
 				}
 
 				select {
@@ -400,8 +702,14 @@ func (cg *ConnectionGroup) listenBroadcast(stop <-chan struct{}, chin <-chan bro
 	return chout
 }
 
-func (cg *ConnectionGroup) encode(stop <-chan struct{}, chins ...<-chan OutputMessage) <-chan []byte {
-	chout := make(chan []byte, chanEncodedOutputMessageBuffer)
+// encodedMessage is an encoded output message of the group
+type encodedMessage struct {
+	data []byte
+	key  interface{}
+}
+
+func (cg *ConnectionGroup) encode(stop <-chan struct{}, chins ...<-chan OutputMessage) <-chan encodedMessage {
+	chout := make(chan encodedMessage, chanEncodedOutputMessageBuffer)
 
 	wg := sync.WaitGroup{}
 	wg.Add(len(chins))
@@ -428,7 +736,7 @@ func (cg *ConnectionGroup) encode(stop <-chan struct{}, chins ...<-chan OutputMe
 						cg.logger.Errorln("encode output message error:", err)
 					} else {
 						select {
-						case chout <- data:
+						case chout <- encodedMessage{data: data, key: message.key}:
 							count++
 						case <-stop:
 							return
@@ -457,8 +765,8 @@ func (cg *ConnectionGroup) encode(stop <-chan struct{}, chins ...<-chan OutputMe
 	return chout
 }
 
-func (cg *ConnectionGroup) prepare(stop <-chan struct{}, chin <-chan []byte) <-chan *websocket.PreparedMessage {
-	chout := make(chan *websocket.PreparedMessage, cap(chin))
+func (cg *ConnectionGroup) prepare(stop <-chan struct{}, chin <-chan encodedMessage) <-chan *preparedMessage {
+	chout := make(chan *preparedMessage, cap(chin))
 
 	go func() {
 		defer close(chout)
@@ -470,14 +778,16 @@ func (cg *ConnectionGroup) prepare(stop <-chan struct{}, chin <-chan []byte) <-c
 
 		for {
 			select {
-			case data, ok := <-chin:
+			case message, ok := <-chin:
 				if !ok {
 					return
 				}
 
-				if pm, err := websocket.NewPreparedMessage(websocket.TextMessage, data); err != nil {
+				if pm, err := newPreparedMessage(message.data); err != nil {
 					cg.logger.Errorln("prepare group output message error:", err)
 				} else {
+					pm.key = message.key
+
 					select {
 					case chout <- pm:
 						count++
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"strconv"
 	"sync"
+	"sync/atomic"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
@@ -12,12 +13,18 @@ import (
 const firstGroupId = 1
 
 type ConnectionGroupManager struct {
+	// reapedCount is the number of groups deleted by the reaper. It is the
+	// first field to be aligned for atomic operations
+	reapedCount uint64
+
 	groups      map[int]*ConnectionGroup
 	groupsMutex *sync.RWMutex
 	groupLimit  int
 	connsLimit  int
 	connsCount  int
 	logger      logrus.FieldLogger
+
+	draining int32
 }
 
 func NewConnectionGroupManager(logger logrus.FieldLogger, groupLimit, connsLimit int) (*ConnectionGroupManager, error) {
@@ -35,7 +42,8 @@ func NewConnectionGroupManager(logger logrus.FieldLogger, groupLimit, connsLimit
 }
 
 func (m *ConnectionGroupManager) unsafeIsFull() bool {
-	return len(m.groups) == m.groupLimit
+	// The limit could have been lowered below the number of groups
+	return len(m.groups) >= m.groupLimit
 }
 
 func (m *ConnectionGroupManager) IsFull() bool {
@@ -54,6 +62,7 @@ var (
 	ErrGroupLimitReached = ErrAddGroup("limit group count reached")
 	ErrCannotGetID       = ErrAddGroup("cannot get id for group")
 	ErrConnsLimitReached = ErrAddGroup("cannot reserve connections for group: connections count reached")
+	ErrServerDraining    = ErrAddGroup("server is shutting down")
 )
 
 func (m *ConnectionGroupManager) Add(group *ConnectionGroup) (int, error) {
@@ -66,27 +75,64 @@ func (m *ConnectionGroupManager) Add(group *ConnectionGroup) (int, error) {
 	m.groupsMutex.Lock()
 	defer m.groupsMutex.Unlock()
 
+	if err := m.unsafeReserve(group); err != nil {
+		return 0, err
+	}
+
+	for id := firstGroupId; id <= len(m.groups)+firstGroupId; id++ {
+		if _, occupied := m.groups[id]; !occupied {
+			m.groups[id] = group
+			return id, nil
+		}
+	}
+
+	return 0, ErrCannotGetID
+}
+
+// unsafeReserve checks the limits and reserves connections for the group
+func (m *ConnectionGroupManager) unsafeReserve(group *ConnectionGroup) error {
+	if m.IsDraining() {
+		return ErrServerDraining
+	}
+
 	if m.unsafeIsFull() {
-		return 0, ErrGroupLimitReached
+		return ErrGroupLimitReached
 	}
 
 	if group.GetLimit() > m.connsLimit-m.connsCount {
 		if m.connsLimit-m.connsCount < 1 {
-			return 0, ErrConnsLimitReached
+			return ErrConnsLimitReached
 		}
 		group.SetLimit(m.connsLimit - m.connsCount)
 	}
 
 	m.connsCount += group.GetLimit()
 
-	for id := firstGroupId; id <= len(m.groups)+firstGroupId; id++ {
-		if _, occupied := m.groups[id]; !occupied {
-			m.groups[id] = group
-			return id, nil
-		}
+	return nil
+}
+
+var ErrGroupIDOccupied = ErrAddGroup("group id is occupied")
+
+// AddWithID adds the group under the given identifier
+func (m *ConnectionGroupManager) AddWithID(id int, group *ConnectionGroup) error {
+	m.groupsMutex.Lock()
+	defer m.groupsMutex.Unlock()
+
+	if id < firstGroupId {
+		return ErrCannotGetID
 	}
 
-	return 0, ErrCannotGetID
+	if _, occupied := m.groups[id]; occupied {
+		return ErrGroupIDOccupied
+	}
+
+	if err := m.unsafeReserve(group); err != nil {
+		return err
+	}
+
+	m.groups[id] = group
+
+	return nil
 }
 
 type ErrDeleteGroup string
@@ -147,9 +193,30 @@ func (m *ConnectionGroupManager) Groups() map[int]*ConnectionGroup {
 }
 
 func (m *ConnectionGroupManager) GroupLimit() int {
+	m.groupsMutex.RLock()
+	defer m.groupsMutex.RUnlock()
 	return m.groupLimit
 }
 
+var errInvalidLimits = errors.New("cannot set limits: invalid group limit")
+
+// SetLimits changes the limits of groups and connections. Running groups are
+// kept if the new limits are lower, but new groups are not accepted until
+// the number of groups and reserved connections goes down
+func (m *ConnectionGroupManager) SetLimits(groupLimit, connsLimit int) error {
+	if groupLimit <= 0 {
+		return errInvalidLimits
+	}
+
+	m.groupsMutex.Lock()
+	defer m.groupsMutex.Unlock()
+
+	m.groupLimit = groupLimit
+	m.connsLimit = connsLimit
+
+	return nil
+}
+
 func (m *ConnectionGroupManager) unsafeGroupCount() int {
 	return len(m.groups)
 }
@@ -180,18 +247,23 @@ func (m *ConnectionGroupManager) Capacity() float32 {
 }
 
 const (
-	metricServerCapacityFQName     = "server_capacity"
-	metricServerGamesFQName        = "server_games"
-	metricServerGamesPlayersFQName = "server_games_players"
-	metricServerGamesRateFQName    = "server_games_rate"
-
-	metricServerCapacityHelp     = "Capacity of the server"
-	metricServerGamesHelp        = "Games number"
-	metricServerGamesPlayersHelp = "Players number"
-	metricServerGamesRateHelp    = "Game rate"
-
-	metricServerGamesPlayersGameIdLabel = "game_id"
-	metricServerGamesRateGameIdLabel    = "game_id"
+	metricServerCapacityFQName         = "server_capacity"
+	metricServerGamesFQName            = "server_games"
+	metricServerGamesPlayersFQName     = "server_games_players"
+	metricServerGamesRateFQName        = "server_games_rate"
+	metricServerGamesReapedFQName      = "server_games_reaped_total"
+	metricServerGamesIdentifiersFQName = "server_games_identifiers"
+
+	metricServerCapacityHelp         = "Capacity of the server"
+	metricServerGamesHelp            = "Games number"
+	metricServerGamesPlayersHelp     = "Players number"
+	metricServerGamesRateHelp        = "Game rate"
+	metricServerGamesReapedHelp      = "Number of idle games deleted by the reaper"
+	metricServerGamesIdentifiersHelp = "Number of live object identifiers"
+
+	metricServerGamesPlayersGameIdLabel     = "game_id"
+	metricServerGamesRateGameIdLabel        = "game_id"
+	metricServerGamesIdentifiersGameIdLabel = "game_id"
 )
 
 var (
@@ -219,6 +291,18 @@ var (
 		[]string{metricServerGamesRateGameIdLabel},
 		nil,
 	)
+	metricServerGamesReapedDesc = prometheus.NewDesc(
+		metricServerGamesReapedFQName,
+		metricServerGamesReapedHelp,
+		nil,
+		nil,
+	)
+	metricServerGamesIdentifiersDesc = prometheus.NewDesc(
+		metricServerGamesIdentifiersFQName,
+		metricServerGamesIdentifiersHelp,
+		[]string{metricServerGamesIdentifiersGameIdLabel},
+		nil,
+	)
 )
 
 // Describe implements prometheus.Collector.Describe by sending metrics' descriptors
@@ -228,10 +312,13 @@ func (m *ConnectionGroupManager) Describe(ch chan<- *prometheus.Desc) {
 		metricServerGamesDesc,
 		metricServerGamesPlayersDesc,
 		metricServerGamesRateDesc,
+		metricServerGamesReapedDesc,
+		metricServerGamesIdentifiersDesc,
 	}
 	for _, desc := range descriptors {
 		ch <- desc
 	}
+	metricServerConnectionsRTT.Describe(ch)
 }
 
 // Collect implements prometheus.Collector.Collect by sending const metrics
@@ -250,10 +337,13 @@ func (m *ConnectionGroupManager) Collect(ch chan<- prometheus.Metric) {
 
 	send(metricServerCapacityDesc, prometheus.GaugeValue, float64(m.unsafeCapacity()))
 	send(metricServerGamesDesc, prometheus.GaugeValue, float64(m.unsafeGroupCount()))
+	send(metricServerGamesReapedDesc, prometheus.CounterValue, float64(atomic.LoadUint64(&m.reapedCount)))
+	metricServerConnectionsRTT.Collect(ch)
 
 	for id, group := range m.groups {
 		gameId := strconv.Itoa(id)
 		send(metricServerGamesPlayersDesc, prometheus.GaugeValue, float64(group.GetCount()), gameId)
 		send(metricServerGamesRateDesc, prometheus.GaugeValue, float64(group.GetRate()), gameId)
+		send(metricServerGamesIdentifiersDesc, prometheus.GaugeValue, float64(group.GetIdentifiersCount()), gameId)
 	}
 }
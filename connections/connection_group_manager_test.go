@@ -377,3 +377,48 @@ func Test_ConnectionGroupManager_GroupCount_ReturnsValidGroupCount(t *testing.T)
 
 	require.Equal(t, 2, actualCount)
 }
+
+func Test_ConnectionGroupManager_SetLimits(t *testing.T) {
+	const groupLimit = 2
+	const connsLimit = 100
+
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	m, err := NewConnectionGroupManager(logger, groupLimit, connsLimit)
+	require.Nil(t, err)
+
+	newGroup := func() *ConnectionGroup {
+		return &ConnectionGroup{
+			limit:      10,
+			counterMux: &sync.RWMutex{},
+			logger:     logger,
+		}
+	}
+
+	_, err = m.Add(newGroup())
+	require.Nil(t, err)
+	_, err = m.Add(newGroup())
+	require.Nil(t, err)
+	_, err = m.Add(newGroup())
+	require.Equal(t, ErrGroupLimitReached, err)
+
+	require.Nil(t, m.SetLimits(3, connsLimit))
+	require.Equal(t, 3, m.GroupLimit())
+	_, err = m.Add(newGroup())
+	require.Nil(t, err)
+
+	// Lowered limits keep running groups
+	require.Nil(t, m.SetLimits(1, connsLimit))
+	require.Equal(t, 3, m.GroupCount())
+	require.True(t, m.IsFull())
+	_, err = m.Add(newGroup())
+	require.Equal(t, ErrGroupLimitReached, err)
+
+	require.Nil(t, m.SetLimits(5, 30))
+	_, err = m.Add(newGroup())
+	require.Equal(t, ErrConnsLimitReached, err)
+
+	require.NotNil(t, m.SetLimits(0, connsLimit))
+	require.Equal(t, 5, m.GroupLimit())
+}
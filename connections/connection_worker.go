@@ -1,6 +1,7 @@
 package connections
 
 import (
+	"io"
 	"sync"
 	"time"
 
@@ -17,27 +18,54 @@ const (
 	chanPlayerOutputMessageBuffer  = 256
 	chanPlayerEncodedMessageBuffer = 1024
 
-	chanMergePreparedMessageBuffer = 8192
+	chanMergePreparedMessageBuffer = 1024
 
 	chanReadMessagesBuffer           = 64
 	chanDecodeMessageBuffer          = 64
 	chanProxyInputMessageBuffer      = 64
 	chanInputMessagesSnakeBuffer     = 64
 	chanInputMessagesBroadcastBuffer = 64
+	chanInputMessagesPingBuffer      = 16
+	chanPongOutputMessageBuffer      = 16
 	chanSnakeCommandsBuffer          = 64
 
-	sendInputMessageTimeout  = time.Millisecond * 5
-	sendOutputMessageTimeout = time.Millisecond * 25
+	sendInputMessageTimeout = time.Millisecond * 5
+
+	// pingPeriod is how often clients are checked
+	pingPeriod = time.Second * 15
 
 	broadcastDelay = time.Second * 15
 
 	ignoredBroadcastsCountToDisconnect = 40
 )
 
+const (
+	closeReasonBroadcastsFlood = "too many broadcasts"
+	closeReasonWriteError      = "write error"
+)
+
+// inputFrame is a message read from the connection with the time it arrived
+type inputFrame struct {
+	data       []byte
+	receivedAt time.Time
+}
+
+// pong is a reply to a client's ping. It is encoded right before it is written
+// to the connection to stamp the time it is sent at
+type pong struct {
+	payload    string
+	receivedAt time.Time
+}
+
 type ConnectionWorker struct {
-	conn   *websocket.Conn
+	conn   transport
 	logger logrus.FieldLogger
 
+	// playerName is an optional name of the player
+	playerName string
+
+	latency *latency
+
 	chsInput    []chan InputMessage
 	chsInputMux *sync.RWMutex
 
@@ -45,10 +73,24 @@ type ConnectionWorker struct {
 	startedMux  *sync.Mutex
 }
 
-func NewConnectionWorker(conn *websocket.Conn, logger logrus.FieldLogger) *ConnectionWorker {
+// NewConnectionWorker creates a worker for a web-socket connection
+func NewConnectionWorker(conn *websocket.Conn, logger logrus.FieldLogger, playerName string) *ConnectionWorker {
+	rtt := newLatency()
+	return newConnectionWorker(newWebSocketTransport(conn, rtt), rtt, logger, playerName)
+}
+
+// NewEventStreamConnectionWorker creates a worker for an event stream. The
+// round-trip time is not measured for event streams
+func NewEventStreamConnectionWorker(stream *EventStream, logger logrus.FieldLogger, playerName string) *ConnectionWorker {
+	return newConnectionWorker(stream, newLatency(), logger, playerName)
+}
+
+func newConnectionWorker(conn transport, rtt *latency, logger logrus.FieldLogger, playerName string) *ConnectionWorker {
 	return &ConnectionWorker{
 		conn:        conn,
 		logger:      logger,
+		playerName:  playerName,
+		latency:     rtt,
 		chsInput:    make([]chan InputMessage, 0),
 		chsInputMux: &sync.RWMutex{},
 
@@ -63,7 +105,7 @@ func (e ErrStartConnectionWorker) Error() string {
 	return "error start connection worker: " + string(e)
 }
 
-func (cw *ConnectionWorker) Start(stop <-chan struct{}, game *game.Game, broadcast *broadcast.GroupBroadcast, gamePreparedMessages <-chan *websocket.PreparedMessage) error {
+func (cw *ConnectionWorker) Start(stop <-chan struct{}, game *game.Game, broadcast *broadcast.GroupBroadcast, gamePreparedMessages <-chan *preparedMessage) error {
 	cw.startedMux.Lock()
 	if cw.flagStarted {
 		cw.startedMux.Unlock()
@@ -75,21 +117,21 @@ func (cw *ConnectionWorker) Start(stop <-chan struct{}, game *game.Game, broadca
 	broadcast.BroadcastMessage("user joined your game group")
 
 	// Input
-	chInputBytes, chStop := cw.read()
-	chInputMessages := cw.decode(chInputBytes, chStop)
+	chInputFrames, chStop := cw.read()
+	chInputMessages := cw.decode(chInputFrames, chStop)
 	cw.broadcastInputMessage(chInputMessages, chStop)
 	chCommands := cw.listenSnakeCommands(chStop, cw.input(chStop, chanInputMessagesSnakeBuffer))
 	cw.listenPlayerBroadcasts(chStop, cw.input(chStop, chanInputMessagesBroadcastBuffer), broadcast, broadcastDelay)
+	chPongs := cw.listenPings(chStop, cw.input(chStop, chanInputMessagesPingBuffer))
 
-	p := player.NewPlayer(cw.logger, game.World())
+	p := player.NewPlayer(cw.logger, game.World(), cw.playerName, game.SnakeConfig())
 
 	// Output
 	chPlayer := p.Start(chStop, chCommands)
 	chOutputBytes := cw.encode(chStop, cw.listenPlayer(chStop, chPlayer))
 	chPlayerPreparedMessages := cw.prepare(chStop, chOutputBytes)
 	chPreparedMessages := cw.mergePreparedMessagesChs(chStop, chPlayerPreparedMessages, gamePreparedMessages)
-	chPreparedMessagesTimeout := cw.chPreparedMessageTimeout(chPreparedMessages, chStop, sendOutputMessageTimeout)
-	cw.write(chPreparedMessagesTimeout, chStop)
+	cw.write(chPreparedMessages, chPongs, chStop)
 
 	select {
 	case <-chStop:
@@ -101,11 +143,19 @@ func (cw *ConnectionWorker) Start(stop <-chan struct{}, game *game.Game, broadca
 
 	broadcast.BroadcastMessage("user left your game group")
 
+	cw.logger.WithFields(cw.latency.fields()).Debug("connection latency")
+
 	cw.stopInputs()
 
 	return nil
 }
 
+// Close notifies the client with the code and the reason and closes the
+// connection
+func (cw *ConnectionWorker) Close(code int, reason string) error {
+	return cw.conn.Close(code, reason)
+}
+
 func (cw *ConnectionWorker) stopInputs() {
 	cw.chsInputMux.Lock()
 	defer cw.chsInputMux.Unlock()
@@ -117,8 +167,8 @@ func (cw *ConnectionWorker) stopInputs() {
 	cw.chsInput = cw.chsInput[:0]
 }
 
-func (cw *ConnectionWorker) read() (<-chan []byte, <-chan struct{}) {
-	chout := make(chan []byte, chanReadMessagesBuffer)
+func (cw *ConnectionWorker) read() (<-chan inputFrame, <-chan struct{}) {
+	chout := make(chan inputFrame, chanReadMessagesBuffer)
 	chstop := make(chan struct{}, 0)
 
 	go func() {
@@ -126,27 +176,30 @@ func (cw *ConnectionWorker) read() (<-chan []byte, <-chan struct{}) {
 		defer close(chstop)
 
 		for {
-			messageType, data, err := cw.conn.ReadMessage()
+			data, err := cw.conn.ReadMessage()
+			receivedAt := time.Now()
+			if err == errUnexpectedMessageType {
+				cw.logger.Warning(err)
+				continue
+			}
 			if err != nil {
-				if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				if err != io.EOF && !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
 					cw.logger.Errorln("read input message error:", err)
 				}
 				return
 			}
 
-			if websocket.TextMessage != messageType {
-				cw.logger.Warning("unexpected input message type")
-				continue
+			chout <- inputFrame{
+				data:       data,
+				receivedAt: receivedAt,
 			}
-
-			chout <- data
 		}
 	}()
 
 	return chout, chstop
 }
 
-func (cw *ConnectionWorker) decode(chin <-chan []byte, stop <-chan struct{}) <-chan InputMessage {
+func (cw *ConnectionWorker) decode(chin <-chan inputFrame, stop <-chan struct{}) <-chan InputMessage {
 	chout := make(chan InputMessage, chanDecodeMessageBuffer)
 
 	go func() {
@@ -156,15 +209,17 @@ func (cw *ConnectionWorker) decode(chin <-chan []byte, stop <-chan struct{}) <-c
 
 		for {
 			select {
-			case data, ok := <-chin:
+			case frame, ok := <-chin:
 				if !ok {
 					return
 				}
 
 				var inputMessage InputMessage
-				if err := decoder.Decode(data, &inputMessage); err != nil {
+				if err := decoder.Decode(frame.data, &inputMessage); err != nil {
 					cw.logger.Errorln("decode input message error:", err)
 				} else {
+					inputMessage.receivedAt = frame.receivedAt
+
 					select {
 					case <-stop:
 						return
@@ -324,7 +379,7 @@ func (cw *ConnectionWorker) listenPlayerBroadcasts(stop <-chan struct{}, chin <-
 						if ignored > ignoredBroadcastsCountToDisconnect {
 							// TODO: Send an error message to the connection before closing
 							cw.logger.Warn("ignored broadcasts limit reached")
-							if err := cw.conn.Close(); err != nil {
+							if err := cw.conn.Close(websocket.ClosePolicyViolation, closeReasonBroadcastsFlood); err != nil {
 								cw.logger.WithError(err).Error("close connection error")
 							}
 						}
@@ -337,6 +392,42 @@ func (cw *ConnectionWorker) listenPlayerBroadcasts(stop <-chan struct{}, chin <-
 	}()
 }
 
+// listenPings replies to client pings with pongs which contain server
+// timestamps and the last round-trip time measured by the server
+func (cw *ConnectionWorker) listenPings(stop <-chan struct{}, chin <-chan InputMessage) <-chan pong {
+	chout := make(chan pong, chanPongOutputMessageBuffer)
+
+	go func() {
+		defer close(chout)
+
+		for {
+			select {
+			case message, ok := <-chin:
+				if !ok {
+					return
+				}
+
+				if message.Type == InputMessageTypePing {
+					p := pong{
+						payload:    message.Payload,
+						receivedAt: message.receivedAt,
+					}
+
+					select {
+					case chout <- p:
+					case <-stop:
+						return
+					}
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return chout
+}
+
 func (cw *ConnectionWorker) listenPlayer(stop <-chan struct{}, chin <-chan player.Message) <-chan OutputMessage {
 	chout := make(chan OutputMessage, chanPlayerOutputMessageBuffer)
 
@@ -409,8 +500,8 @@ func (cw *ConnectionWorker) encode(stop <-chan struct{}, chins ...<-chan OutputM
 	return chout
 }
 
-func (cw *ConnectionWorker) prepare(stop <-chan struct{}, chin <-chan []byte) <-chan *websocket.PreparedMessage {
-	chout := make(chan *websocket.PreparedMessage, cap(chin))
+func (cw *ConnectionWorker) prepare(stop <-chan struct{}, chin <-chan []byte) <-chan *preparedMessage {
+	chout := make(chan *preparedMessage, cap(chin))
 
 	go func() {
 		defer close(chout)
@@ -422,7 +513,7 @@ func (cw *ConnectionWorker) prepare(stop <-chan struct{}, chin <-chan []byte) <-
 					return
 				}
 
-				if pm, err := websocket.NewPreparedMessage(websocket.TextMessage, data); err != nil {
+				if pm, err := newPreparedMessage(data); err != nil {
 					cw.logger.Errorln("prepare player output message error:", err)
 				} else {
 					select {
@@ -441,14 +532,14 @@ func (cw *ConnectionWorker) prepare(stop <-chan struct{}, chin <-chan []byte) <-
 	return chout
 }
 
-func (cw *ConnectionWorker) mergePreparedMessagesChs(stop <-chan struct{}, chins ...<-chan *websocket.PreparedMessage) <-chan *websocket.PreparedMessage {
-	chout := make(chan *websocket.PreparedMessage, chanMergePreparedMessageBuffer)
+func (cw *ConnectionWorker) mergePreparedMessagesChs(stop <-chan struct{}, chins ...<-chan *preparedMessage) <-chan *preparedMessage {
+	chout := make(chan *preparedMessage, chanMergePreparedMessageBuffer)
 
 	wg := sync.WaitGroup{}
 	wg.Add(len(chins))
 
 	for _, chin := range chins {
-		go func(chin <-chan *websocket.PreparedMessage) {
+		go func(chin <-chan *preparedMessage) {
 			defer wg.Done()
 
 			for {
@@ -478,11 +569,36 @@ func (cw *ConnectionWorker) mergePreparedMessagesChs(stop <-chan struct{}, chins
 	return chout
 }
 
-func (cw *ConnectionWorker) chPreparedMessageTimeout(chin <-chan *websocket.PreparedMessage, stop <-chan struct{}, timeout time.Duration) <-chan *websocket.PreparedMessage {
-	chout := make(chan *websocket.PreparedMessage, cap(chin))
+// preparePong encodes the pong with the current time as the time it is sent at
+func (cw *ConnectionWorker) preparePong(p pong) (*preparedMessage, error) {
+	data, err := ffjson.Marshal(OutputMessage{
+		Type:    OutputMessageTypePlayer,
+		Payload: player.NewMessagePong(p.payload, p.receivedAt, time.Now(), cw.latency.Last()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newPreparedMessage(data)
+}
 
+// writeMessage writes the message and closes the connection on an error. It
+// returns false if the connection is broken
+func (cw *ConnectionWorker) writeMessage(pm *preparedMessage) bool {
+	if err := cw.conn.WriteMessage(pm); err != nil {
+		if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+			cw.logger.Errorln("write output message error:", err)
+		}
+		cw.closeBroken()
+		return false
+	}
+	return true
+}
+
+func (cw *ConnectionWorker) write(chin <-chan *preparedMessage, chPongs <-chan pong, stop <-chan struct{}) {
 	go func() {
-		defer close(chout)
+		ticker := time.NewTicker(pingPeriod)
+		defer ticker.Stop()
 
 		for {
 			select {
@@ -491,41 +607,29 @@ func (cw *ConnectionWorker) chPreparedMessageTimeout(chin <-chan *websocket.Prep
 					return
 				}
 
-				timer := time.NewTimer(timeout)
-
-				select {
-				case chout <- pm:
-					timer.Stop()
-				case <-timer.C:
-					cw.logger.Warn("send message for writing time is out")
-					timer.Stop()
-					continue
-				case <-stop:
-					timer.Stop()
+				if !cw.writeMessage(pm) {
 					return
 				}
-			case <-stop:
-				return
-			}
-		}
-	}()
+			case p, ok := <-chPongs:
+				if !ok {
+					// Pongs are not sent anymore, keep writing the rest
+					chPongs = nil
+					continue
+				}
 
-	return chout
-}
+				pm, err := cw.preparePong(p)
+				if err != nil {
+					cw.logger.Errorln("prepare pong error:", err)
+					continue
+				}
 
-func (cw *ConnectionWorker) write(chin <-chan *websocket.PreparedMessage, stop <-chan struct{}) {
-	go func() {
-		for {
-			select {
-			case pm, ok := <-chin:
-				if !ok {
+				if !cw.writeMessage(pm) {
 					return
 				}
-
-				if err := cw.conn.WritePreparedMessage(pm); err != nil {
-					if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
-						cw.logger.Errorln("write output message error:", err)
-					}
+			case <-ticker.C:
+				if err := cw.conn.Ping(); err != nil {
+					cw.logger.Warnln("ping error:", err)
+					cw.closeBroken()
 					return
 				}
 			case <-stop:
@@ -534,3 +638,11 @@ func (cw *ConnectionWorker) write(chin <-chan *websocket.PreparedMessage, stop <
 		}
 	}()
 }
+
+// closeBroken closes the connection which cannot be written to. It stops the
+// reading, so the worker stops as well
+func (cw *ConnectionWorker) closeBroken() {
+	if err := cw.conn.Close(websocket.CloseGoingAway, closeReasonWriteError); err != nil {
+		cw.logger.WithError(err).Debug("close broken connection error")
+	}
+}
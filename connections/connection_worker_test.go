@@ -0,0 +1,105 @@
+package connections
+
+import (
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+type testTransport struct {
+	frames  chan []byte
+	written chan *preparedMessage
+}
+
+func newTestTransport() *testTransport {
+	return &testTransport{
+		frames:  make(chan []byte),
+		written: make(chan *preparedMessage, 1),
+	}
+}
+
+func (t *testTransport) ReadMessage() ([]byte, error) {
+	data, ok := <-t.frames
+	if !ok {
+		return nil, io.EOF
+	}
+	return data, nil
+}
+
+func (t *testTransport) WriteMessage(pm *preparedMessage) error {
+	t.written <- pm
+	return nil
+}
+
+func (t *testTransport) Ping() error {
+	return nil
+}
+
+func (t *testTransport) Close(code int, reason string) error {
+	return nil
+}
+
+func Test_ConnectionWorker_read_StampsReceiveTime(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	conn := newTestTransport()
+	cw := newConnectionWorker(conn, newLatency(), logger, "")
+
+	chFrames, chStop := cw.read()
+	chMessages := cw.decode(chFrames, chStop)
+
+	before := time.Now()
+	conn.frames <- []byte(`{"type":"ping","payload":"1"}`)
+	after := time.Now()
+
+	message := <-chMessages
+	require.Equal(t, InputMessageTypePing, message.Type)
+	require.False(t, message.receivedAt.Before(before))
+	require.False(t, message.receivedAt.After(after))
+
+	close(conn.frames)
+	<-chStop
+}
+
+func Test_ConnectionWorker_write_StampsSendTimeOfPongs(t *testing.T) {
+	const delay = time.Second
+
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	conn := newTestTransport()
+	cw := newConnectionWorker(conn, newLatency(), logger, "")
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	chPongs := make(chan pong, 1)
+	receivedAt := time.Now().Add(-delay)
+
+	cw.write(make(chan *preparedMessage), chPongs, stop)
+	chPongs <- pong{
+		payload:    "1",
+		receivedAt: receivedAt,
+	}
+
+	var message struct {
+		Payload struct {
+			Payload struct {
+				Payload    string `json:"payload"`
+				ReceivedAt int64  `json:"received_at"`
+				SentAt     int64  `json:"sent_at"`
+			} `json:"payload"`
+		} `json:"payload"`
+	}
+	require.Nil(t, json.Unmarshal((<-conn.written).data, &message))
+
+	reply := message.Payload.Payload
+	require.Equal(t, "1", reply.Payload)
+	require.Equal(t, receivedAt.UnixNano()/int64(time.Millisecond), reply.ReceivedAt)
+	require.True(t, reply.SentAt-reply.ReceivedAt >= int64(delay/time.Millisecond))
+}
@@ -0,0 +1,109 @@
+package connections
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	drainCheckInterval     = time.Millisecond * 250
+	drainCountdownInterval = time.Second * 10
+	drainBroadcastTimeout  = time.Millisecond * 100
+	drainCloseTimeout      = time.Second * 2
+)
+
+const closeReasonServerShutdown = "server is shutting down"
+
+// IsDraining returns true if the manager doesn't accept new groups
+func (m *ConnectionGroupManager) IsDraining() bool {
+	return atomic.LoadInt32(&m.draining) == 1
+}
+
+func (m *ConnectionGroupManager) connCount() int {
+	m.groupsMutex.RLock()
+	defer m.groupsMutex.RUnlock()
+	return m.unsafeConnCount()
+}
+
+// Drain prepares the server to shut down. It stops accepting new groups and
+// players, notifies players about the shutdown with a countdown and waits
+// until all players leave or the deadline is reached. Then it disconnects the
+// rest of players with a close frame
+func (m *ConnectionGroupManager) Drain(deadline time.Duration) {
+	atomic.StoreInt32(&m.draining, 1)
+
+	groups := m.Groups()
+	for _, group := range groups {
+		group.Drain()
+	}
+
+	m.logger.WithFields(logrus.Fields{
+		"deadline": deadline.String(),
+		"players":  m.connCount(),
+	}).Info("draining games")
+
+	if deadline > 0 && m.connCount() > 0 {
+		m.countdown(groups, deadline)
+	}
+
+	for _, group := range groups {
+		group.Disconnect(websocket.CloseGoingAway, closeReasonServerShutdown)
+	}
+
+	// Give connection workers time to send close frames
+	m.waitPlayersLeave(drainCloseTimeout)
+
+	m.logger.WithField("players", m.connCount()).Info("games drained")
+}
+
+// countdown broadcasts the shutdown countdown to the players until they all
+// leave or the deadline is reached
+func (m *ConnectionGroupManager) countdown(groups map[int]*ConnectionGroup, deadline time.Duration) {
+	end := time.Now().Add(deadline)
+	nextBroadcast := time.Now()
+
+	ticker := time.NewTicker(drainCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now()
+
+		remaining := end.Sub(now)
+		if remaining <= 0 || m.connCount() == 0 {
+			return
+		}
+
+		if !now.Before(nextBroadcast) {
+			message := fmt.Sprintf("%s in %d second(s)", closeReasonServerShutdown, int(math.Ceil(remaining.Seconds())))
+			for _, group := range groups {
+				if !group.IsEmpty() {
+					group.BroadcastMessageTimeout(message, drainBroadcastTimeout)
+				}
+			}
+			nextBroadcast = nextBroadcast.Add(drainCountdownInterval)
+		}
+
+		<-ticker.C
+	}
+}
+
+func (m *ConnectionGroupManager) waitPlayersLeave(timeout time.Duration) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	ticker := time.NewTicker(drainCheckInterval)
+	defer ticker.Stop()
+
+	for m.connCount() > 0 {
+		select {
+		case <-ticker.C:
+		case <-timer.C:
+			return
+		}
+	}
+}
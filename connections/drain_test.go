@@ -0,0 +1,90 @@
+package connections
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ivan1993spb/snake-server/game"
+)
+
+// eventually returns true if the condition is met within the timeout
+func eventually(condition func() bool, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return true
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	return condition()
+}
+
+func Test_ConnectionGroupManager_Drain_DisconnectsPlayers(t *testing.T) {
+	const groupLimit = 10
+	const connsLimit = 100
+
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	m, err := NewConnectionGroupManager(logger, groupLimit, connsLimit)
+	require.Nil(t, err)
+
+	group, err := NewConnectionGroup(logger, 5, 20, 20, game.DefaultConfig())
+	require.Nil(t, err)
+	_, err = m.Add(group)
+	require.Nil(t, err)
+
+	group.Start()
+	defer group.Stop()
+
+	upgrader := &websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		group.Handle(NewConnectionWorker(conn, logger, ""))
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.Nil(t, err)
+	defer conn.Close()
+
+	require.True(t, eventually(func() bool {
+		return group.GetCount() == 1
+	}, time.Second))
+
+	go m.Drain(time.Millisecond * 500)
+
+	require.True(t, eventually(m.IsDraining, time.Second))
+	require.True(t, group.IsDraining())
+
+	_, err = m.Add(group)
+	require.Equal(t, ErrServerDraining, err)
+
+	require.Equal(t, &ErrHandleConnection{Err: ErrGroupIsDraining}, group.Handle(nil))
+
+	var closeErr *websocket.CloseError
+	require.Nil(t, conn.SetReadDeadline(time.Now().Add(time.Second*5)))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			var ok bool
+			closeErr, ok = err.(*websocket.CloseError)
+			require.True(t, ok, err.Error())
+			break
+		}
+	}
+
+	require.Equal(t, websocket.CloseGoingAway, closeErr.Code)
+	require.Equal(t, closeReasonServerShutdown, closeErr.Text)
+
+	require.True(t, eventually(group.IsEmpty, time.Second))
+}
@@ -0,0 +1,319 @@
+package connections
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	eventStreamSessionIDSize = 16
+
+	chanEventStreamInputBuffer = 64
+
+	// eventStreamWriteTimeout is the time allowed to write an event to a client
+	eventStreamWriteTimeout = wsWriteTimeout
+)
+
+const (
+	eventStreamEventSession = "session"
+	eventStreamEventClose   = "close"
+)
+
+// EventStream is a server-sent events transport for a connection worker.
+// Output messages are sent as events and input messages are pushed by the
+// client with separate requests, which are identified by the session ID
+type EventStream struct {
+	session string
+
+	w        io.Writer
+	flusher  http.Flusher
+	writeMux *sync.Mutex
+
+	// conn is the connection of the stream, it is nil if the server has not
+	// passed it with ConnContext. Write deadlines are set on the connection
+	conn         net.Conn
+	writeTimeout time.Duration
+
+	input chan []byte
+
+	done    chan struct{}
+	stopper *sync.Once
+}
+
+type ErrEventStream string
+
+func (e ErrEventStream) Error() string {
+	return "event stream error: " + string(e)
+}
+
+var (
+	ErrEventStreamNotSupported  = ErrEventStream("streaming is not supported")
+	ErrEventStreamClosed        = ErrEventStream("stream is closed")
+	ErrEventStreamInputOverflow = ErrEventStream("input buffer is overflow")
+)
+
+type connContextKey struct{}
+
+// ConnContext stores the connection in the context of its requests. It has to
+// be set as ConnContext of the http.Server to limit the time of writes to
+// event streams
+func ConnContext(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey{}, conn)
+}
+
+// NewEventStream creates an event stream which writes events to the w with a
+// new session ID
+func NewEventStream(w http.ResponseWriter, r *http.Request) (*EventStream, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, ErrEventStreamNotSupported
+	}
+
+	conn, _ := r.Context().Value(connContextKey{}).(net.Conn)
+
+	b := make([]byte, eventStreamSessionIDSize)
+	if _, err := rand.Read(b); err != nil {
+		return nil, ErrEventStream(err.Error())
+	}
+
+	return &EventStream{
+		session: hex.EncodeToString(b),
+
+		w:        w,
+		flusher:  flusher,
+		writeMux: &sync.Mutex{},
+
+		conn:         conn,
+		writeTimeout: eventStreamWriteTimeout,
+
+		input: make(chan []byte, chanEventStreamInputBuffer),
+
+		done:    make(chan struct{}),
+		stopper: &sync.Once{},
+	}, nil
+}
+
+// Session returns the session ID of the stream
+func (s *EventStream) Session() string {
+	return s.session
+}
+
+type eventStreamSession struct {
+	Session string `json:"session"`
+}
+
+// WriteSession sends the session ID to the client
+func (s *EventStream) WriteSession() error {
+	data, err := json.Marshal(&eventStreamSession{
+		Session: s.session,
+	})
+	if err != nil {
+		return ErrEventStream(err.Error())
+	}
+	return s.writeEvent(eventStreamEventSession, data)
+}
+
+// Push passes an input message from the client to the stream
+func (s *EventStream) Push(data []byte) error {
+	select {
+	case <-s.done:
+		return ErrEventStreamClosed
+	default:
+	}
+
+	select {
+	case s.input <- data:
+		return nil
+	case <-s.done:
+		return ErrEventStreamClosed
+	default:
+		return ErrEventStreamInputOverflow
+	}
+}
+
+// ReadMessage returns the next message pushed by the client
+func (s *EventStream) ReadMessage() ([]byte, error) {
+	select {
+	case data := <-s.input:
+		return data, nil
+	case <-s.done:
+		return nil, io.EOF
+	}
+}
+
+// WriteMessage sends the message to the client as an event
+func (s *EventStream) WriteMessage(pm *preparedMessage) error {
+	return s.writeEvent("", pm.data)
+}
+
+type eventStreamClose struct {
+	Code   int    `json:"code"`
+	Reason string `json:"reason"`
+}
+
+// Close sends the close event with the code and the reason and stops the
+// stream
+func (s *EventStream) Close(code int, reason string) error {
+	defer s.Stop()
+
+	if s.isStopped() {
+		return nil
+	}
+
+	data, err := json.Marshal(&eventStreamClose{
+		Code:   code,
+		Reason: reason,
+	})
+	if err != nil {
+		return ErrEventStream(err.Error())
+	}
+
+	return s.writeEvent(eventStreamEventClose, data)
+}
+
+// Stop stops the stream without notifying the client. It is called when the
+// client has gone. Nothing is written to the stream after Stop returns
+func (s *EventStream) Stop() {
+	s.stopper.Do(func() {
+		s.writeMux.Lock()
+		close(s.done)
+		s.writeMux.Unlock()
+	})
+}
+
+func (s *EventStream) isStopped() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Done returns a channel which is closed when the stream is stopped
+func (s *EventStream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Ping sends a comment to the client to keep the connection open through
+// proxies
+func (s *EventStream) Ping() error {
+	return s.writeComment("keep-alive")
+}
+
+func (s *EventStream) writeComment(comment string) error {
+	s.writeMux.Lock()
+	defer s.writeMux.Unlock()
+
+	if s.isStopped() {
+		return ErrEventStreamClosed
+	}
+
+	if err := s.setWriteDeadline(); err != nil {
+		return err
+	}
+	defer s.resetWriteDeadline()
+
+	if _, err := io.WriteString(s.w, ": "+comment+"\n\n"); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+
+	return nil
+}
+
+// writeEvent writes an event. The data must not contain line breaks
+func (s *EventStream) writeEvent(event string, data []byte) error {
+	s.writeMux.Lock()
+	defer s.writeMux.Unlock()
+
+	if s.isStopped() {
+		return ErrEventStreamClosed
+	}
+
+	if err := s.setWriteDeadline(); err != nil {
+		return err
+	}
+	defer s.resetWriteDeadline()
+
+	if len(event) > 0 {
+		if _, err := io.WriteString(s.w, "event: "+event+"\n"); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.WriteString(s.w, "data: "); err != nil {
+		return err
+	}
+	if _, err := s.w.Write(data); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(s.w, "\n\n"); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+
+	return nil
+}
+
+// setWriteDeadline limits the time of the next write, so that a stalled client
+// does not block the writer. A timed out write breaks the stream
+func (s *EventStream) setWriteDeadline() error {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.SetWriteDeadline(time.Now().Add(s.writeTimeout))
+}
+
+// resetWriteDeadline removes the deadline between the writes
+func (s *EventStream) resetWriteDeadline() {
+	if s.conn != nil {
+		s.conn.SetWriteDeadline(time.Time{})
+	}
+}
+
+// EventStreamRegistry keeps opened event streams by session IDs
+type EventStreamRegistry struct {
+	streams map[string]*EventStream
+	mux     *sync.RWMutex
+}
+
+func NewEventStreamRegistry() *EventStreamRegistry {
+	return &EventStreamRegistry{
+		streams: make(map[string]*EventStream),
+		mux:     &sync.RWMutex{},
+	}
+}
+
+func (r *EventStreamRegistry) Add(stream *EventStream) {
+	r.mux.Lock()
+	r.streams[stream.Session()] = stream
+	r.mux.Unlock()
+}
+
+func (r *EventStreamRegistry) Delete(stream *EventStream) {
+	r.mux.Lock()
+	delete(r.streams, stream.Session())
+	r.mux.Unlock()
+}
+
+var ErrNotFoundEventStream = errors.New("not found event stream")
+
+func (r *EventStreamRegistry) Get(session string) (*EventStream, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	if stream, ok := r.streams[session]; ok {
+		return stream, nil
+	}
+
+	return nil, ErrNotFoundEventStream
+}
@@ -0,0 +1,53 @@
+package connections
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EventStream_writeEvent_TimesOutOnStalledClient(t *testing.T) {
+	const writeTimeout = time.Millisecond * 100
+
+	errs := make(chan error, 1)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stream, err := NewEventStream(w, r)
+		if err != nil {
+			errs <- err
+			return
+		}
+		stream.writeTimeout = writeTimeout
+
+		data := bytes.Repeat([]byte("x"), 1<<16)
+		for {
+			if err := stream.writeEvent("", data); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}))
+	server.Config.ConnContext = ConnContext
+	server.Start()
+	defer server.Close()
+
+	// The client sends a request and never reads the response
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	require.Nil(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	require.Nil(t, err)
+
+	select {
+	case err := <-errs:
+		require.NotNil(t, err)
+	case <-time.After(time.Second * 5):
+		t.Fatal("write to the stalled client has not timed out")
+	}
+}
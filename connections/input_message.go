@@ -3,6 +3,7 @@ package connections
 import (
 	"bytes"
 	"errors"
+	"time"
 )
 
 type InputMessageType uint8
@@ -10,11 +11,13 @@ type InputMessageType uint8
 const (
 	InputMessageTypeSnakeCommand InputMessageType = iota
 	InputMessageTypeBroadcast
+	InputMessageTypePing
 )
 
 var inputMessageTypeJSONs = map[InputMessageType][]byte{
 	InputMessageTypeSnakeCommand: []byte(`"snake"`),
 	InputMessageTypeBroadcast:    []byte(`"broadcast"`),
+	InputMessageTypePing:         []byte(`"ping"`),
 }
 
 var ErrUnknownInputMessageType = errors.New("unknown input message type")
@@ -33,6 +36,7 @@ func (t *InputMessageType) UnmarshalJSON(data []byte) error {
 var inputMessageTypeLabels = map[InputMessageType]string{
 	InputMessageTypeSnakeCommand: "snake",
 	InputMessageTypeBroadcast:    "broadcast",
+	InputMessageTypePing:         "ping",
 }
 
 func (t InputMessageType) String() string {
@@ -48,4 +52,7 @@ func (t InputMessageType) String() string {
 type InputMessage struct {
 	Type    InputMessageType `json:"type"`
 	Payload string           `json:"payload"`
+
+	// receivedAt is the time the message has been read from the connection
+	receivedAt time.Time
 }
@@ -37,3 +37,15 @@ func Test_InputMessageType_UnmarshalJSON_BroadcastMessageTypes(t *testing.T) {
 	require.Nil(t, err)
 	require.Equal(t, expected, inputMessage)
 }
+
+func Test_InputMessageType_UnmarshalJSON_PingMessageType(t *testing.T) {
+	data := []byte(`{"type": "ping", "payload": "1"}`)
+	expected := InputMessage{
+		Type:    InputMessageTypePing,
+		Payload: "1",
+	}
+	var inputMessage InputMessage
+	err := ffjson.Unmarshal(data, &inputMessage)
+	require.Nil(t, err)
+	require.Equal(t, expected, inputMessage)
+}
@@ -0,0 +1,84 @@
+package connections
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	metricServerConnectionsRTTFQName = "server_connections_rtt_seconds"
+	metricServerConnectionsRTTHelp   = "Round-trip time between the server and clients"
+)
+
+// metricServerConnectionsRTT is collected by ConnectionGroupManager
+var metricServerConnectionsRTT = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Name:    metricServerConnectionsRTTFQName,
+	Help:    metricServerConnectionsRTTHelp,
+	Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+})
+
+// latency keeps round-trip time statistics of a connection
+type latency struct {
+	last  time.Duration
+	min   time.Duration
+	max   time.Duration
+	sum   time.Duration
+	count int
+
+	mux *sync.RWMutex
+}
+
+func newLatency() *latency {
+	return &latency{
+		mux: &sync.RWMutex{},
+	}
+}
+
+func (l *latency) observe(rtt time.Duration) {
+	if rtt < 0 {
+		return
+	}
+
+	metricServerConnectionsRTT.Observe(rtt.Seconds())
+
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	l.last = rtt
+	if l.count == 0 || rtt < l.min {
+		l.min = rtt
+	}
+	if rtt > l.max {
+		l.max = rtt
+	}
+	l.sum += rtt
+	l.count++
+}
+
+// Last returns the last measured round-trip time or zero if it hasn't been
+// measured yet
+func (l *latency) Last() time.Duration {
+	l.mux.RLock()
+	defer l.mux.RUnlock()
+	return l.last
+}
+
+func (l *latency) fields() logrus.Fields {
+	l.mux.RLock()
+	defer l.mux.RUnlock()
+
+	var avg time.Duration
+	if l.count > 0 {
+		avg = l.sum / time.Duration(l.count)
+	}
+
+	return logrus.Fields{
+		"rtt_min":     l.min.String(),
+		"rtt_avg":     avg.String(),
+		"rtt_max":     l.max.String(),
+		"rtt_samples": l.count,
+	}
+}
@@ -0,0 +1,26 @@
+package connections
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_latency_observe(t *testing.T) {
+	l := newLatency()
+	require.Equal(t, time.Duration(0), l.Last())
+
+	l.observe(time.Millisecond * 30)
+	l.observe(time.Millisecond * 10)
+	l.observe(time.Millisecond * 20)
+	l.observe(-time.Millisecond)
+
+	require.Equal(t, time.Millisecond*20, l.Last())
+
+	fields := l.fields()
+	require.Equal(t, (time.Millisecond * 10).String(), fields["rtt_min"])
+	require.Equal(t, (time.Millisecond * 20).String(), fields["rtt_avg"])
+	require.Equal(t, (time.Millisecond * 30).String(), fields["rtt_max"])
+	require.Equal(t, 3, fields["rtt_samples"])
+}
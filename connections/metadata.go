@@ -0,0 +1,51 @@
+package connections
+
+import (
+	"strings"
+	"time"
+)
+
+// Metadata contains descriptive information about a group which is shown to
+// players in lobbies
+type Metadata struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	Creator     string    `json:"creator,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	Region      string    `json:"region,omitempty"`
+}
+
+// HasTags returns true if the metadata contains all the tags
+func (m Metadata) HasTags(tags []string) bool {
+	for _, tag := range tags {
+		if !m.hasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Metadata) hasTag(tag string) bool {
+	for _, t := range m.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// NameContains returns true if the name contains the search string ignoring
+// the case
+func (m Metadata) NameContains(search string) bool {
+	return strings.Contains(strings.ToLower(m.Name), strings.ToLower(search))
+}
+
+func (m Metadata) copy() Metadata {
+	if m.Tags != nil {
+		tags := make([]string, len(m.Tags))
+		copy(tags, m.Tags)
+		m.Tags = tags
+	}
+	return m
+}
@@ -40,4 +40,10 @@ func (t OutputMessageType) MarshalJSON() ([]byte, error) {
 type OutputMessage struct {
 	Type    OutputMessageType `json:"type"`
 	Payload interface{}       `json:"payload"`
+	// Seq is a sequence number of a game message in the group. Clients detect
+	// missed messages by gaps in the sequence
+	Seq uint64 `json:"seq,omitempty"`
+
+	// key is passed to the prepared message
+	key interface{}
 }
@@ -31,7 +31,7 @@ func (j *OutputMessage) MarshalJSONBuf(buf fflib.EncodingBuffer) error {
 	var obj []byte
 	_ = obj
 	_ = err
-	buf.WriteString(`{"type":`)
+	buf.WriteString(`{ "type":`)
 
 	{
 
@@ -48,6 +48,13 @@ func (j *OutputMessage) MarshalJSONBuf(buf fflib.EncodingBuffer) error {
 	if err != nil {
 		return err
 	}
+	buf.WriteByte(',')
+	if j.Seq != 0 {
+		buf.WriteString(`"seq":`)
+		fflib.FormatBits2(buf, uint64(j.Seq), 10, false)
+		buf.WriteByte(',')
+	}
+	buf.Rewind(1)
 	buf.WriteByte('}')
 	return nil
 }
@@ -0,0 +1,28 @@
+package connections
+
+import (
+	"github.com/gorilla/websocket"
+)
+
+// preparedMessage is an encoded output message which is ready to be sent to
+// clients over any transport
+type preparedMessage struct {
+	data      []byte
+	websocket *websocket.PreparedMessage
+
+	// key is used to coalesce messages about the same object, it is nil if
+	// the message cannot be coalesced
+	key interface{}
+}
+
+func newPreparedMessage(data []byte) (*preparedMessage, error) {
+	pm, err := websocket.NewPreparedMessage(websocket.TextMessage, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &preparedMessage{
+		data:      data,
+		websocket: pm,
+	}, nil
+}
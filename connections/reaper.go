@@ -0,0 +1,92 @@
+package connections
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	minReapInterval = time.Second
+	maxReapInterval = time.Minute
+)
+
+// Reap deletes and stops groups which have been empty longer than the ttl.
+// Persistent groups are never reaped. Reap returns identifiers of the deleted
+// groups
+func (m *ConnectionGroupManager) Reap(ttl time.Duration, now time.Time) []int {
+	type reapedGroup struct {
+		id    int
+		group *ConnectionGroup
+		idle  time.Duration
+	}
+
+	reapedGroups := make([]reapedGroup, 0)
+
+	m.groupsMutex.Lock()
+	for id, group := range m.groups {
+		idle, closed := group.closeIfIdle(ttl, now)
+		if !closed {
+			continue
+		}
+
+		delete(m.groups, id)
+		m.connsCount -= group.GetLimit()
+
+		reapedGroups = append(reapedGroups, reapedGroup{
+			id:    id,
+			group: group,
+			idle:  idle,
+		})
+	}
+	m.groupsMutex.Unlock()
+
+	reaped := make([]int, 0, len(reapedGroups))
+
+	// Groups are stopped outside the manager lock not to block the manager
+	for _, r := range reapedGroups {
+		r.group.Stop()
+
+		atomic.AddUint64(&m.reapedCount, 1)
+		reaped = append(reaped, r.id)
+
+		m.logger.WithFields(logrus.Fields{
+			"group_id": r.id,
+			"name":     r.group.GetMetadata().Name,
+			"idle":     r.idle.String(),
+		}).Info("reaped idle group")
+	}
+
+	return reaped
+}
+
+// reapInterval returns how often groups are checked by the reaper
+func reapInterval(ttl time.Duration) time.Duration {
+	interval := ttl / 4
+	if interval < minReapInterval {
+		return minReapInterval
+	}
+	if interval > maxReapInterval {
+		return maxReapInterval
+	}
+	return interval
+}
+
+// StartReaper runs a goroutine which periodically deletes groups that have
+// been empty longer than the ttl until the channel stop is closed
+func (m *ConnectionGroupManager) StartReaper(stop <-chan struct{}, ttl time.Duration) {
+	go func() {
+		ticker := time.NewTicker(reapInterval(ttl))
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				m.Reap(ttl, now)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
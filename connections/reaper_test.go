@@ -0,0 +1,98 @@
+package connections
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ivan1993spb/snake-server/game"
+)
+
+func Test_ConnectionGroupManager_Reap_DeletesIdleGroups(t *testing.T) {
+	const groupLimit = 10
+	const connsLimit = 100
+	const ttl = time.Minute
+
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	m, err := NewConnectionGroupManager(logger, groupLimit, connsLimit)
+	require.Nil(t, err)
+
+	idleGroup, err := NewConnectionGroup(logger, 5, 20, 20, game.DefaultConfig())
+	require.Nil(t, err)
+	idleID, err := m.Add(idleGroup)
+	require.Nil(t, err)
+
+	persistentGroup, err := NewConnectionGroup(logger, 5, 20, 20, game.DefaultConfig())
+	require.Nil(t, err)
+	persistentGroup.SetPersistent(true)
+	persistentID, err := m.Add(persistentGroup)
+	require.Nil(t, err)
+
+	busyGroup, err := NewConnectionGroup(logger, 5, 20, 20, game.DefaultConfig())
+	require.Nil(t, err)
+	busyGroup.counter = 1
+	busyID, err := m.Add(busyGroup)
+	require.Nil(t, err)
+
+	now := time.Now()
+
+	require.Empty(t, m.Reap(ttl, now))
+	require.Equal(t, 3, m.GroupCount())
+
+	require.Equal(t, []int{idleID}, m.Reap(ttl, now.Add(ttl)))
+	require.Equal(t, 2, m.GroupCount())
+	require.Equal(t, uint64(1), m.reapedCount)
+	require.Equal(t, 10, m.connsCount)
+
+	_, err = m.Get(idleID)
+	require.Equal(t, ErrNotFoundGroup, err)
+	_, err = m.Get(persistentID)
+	require.Nil(t, err)
+	_, err = m.Get(busyID)
+	require.Nil(t, err)
+
+	select {
+	case <-idleGroup.stop:
+	default:
+		t.Fatal("reaped group is not stopped")
+	}
+
+	require.True(t, idleGroup.IsDraining(), "reaped group accepts players")
+	require.False(t, persistentGroup.IsDraining())
+	require.False(t, busyGroup.IsDraining())
+}
+
+func Test_ConnectionGroup_closeIfIdle_RejectsPlayersAfterClosing(t *testing.T) {
+	const ttl = time.Minute
+
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	group, err := NewConnectionGroup(logger, 5, 20, 20, game.DefaultConfig())
+	require.Nil(t, err)
+
+	now := time.Now()
+
+	_, closed := group.closeIfIdle(ttl, now)
+	require.False(t, closed)
+	require.False(t, group.IsDraining())
+
+	idle, closed := group.closeIfIdle(ttl, now.Add(ttl))
+	require.True(t, closed)
+	require.True(t, idle >= ttl)
+
+	err = group.Handle(&ConnectionWorker{})
+	require.Equal(t, &ErrHandleConnection{
+		Err: ErrGroupIsDraining,
+	}, err)
+}
+
+func Test_reapInterval(t *testing.T) {
+	require.Equal(t, minReapInterval, reapInterval(time.Millisecond))
+	require.Equal(t, time.Second*15, reapInterval(time.Minute))
+	require.Equal(t, maxReapInterval, reapInterval(time.Hour))
+}
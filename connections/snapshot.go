@@ -0,0 +1,131 @@
+package connections
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ivan1993spb/snake-server/game"
+)
+
+// GroupSnapshot represents the state of a connection group
+type GroupSnapshot struct {
+	ID    int            `json:"id"`
+	Limit int            `json:"limit"`
+	Game  *game.Snapshot `json:"game"`
+
+	Access     Access   `json:"access"`
+	Metadata   Metadata `json:"metadata"`
+	Persistent bool     `json:"persistent"`
+
+	Backpressure Backpressure `json:"backpressure"`
+}
+
+// Snapshot represents the state of all groups of a group manager
+type Snapshot struct {
+	Groups []*GroupSnapshot `json:"groups"`
+}
+
+type ErrSnapshot struct {
+	Err error
+}
+
+func (e *ErrSnapshot) Error() string {
+	return "snapshot error: " + e.Err.Error()
+}
+
+// Snapshot returns the current state of all the groups
+func (m *ConnectionGroupManager) Snapshot() (*Snapshot, error) {
+	groups := m.Groups()
+
+	ids := make([]int, 0, len(groups))
+	for id := range groups {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	snapshot := &Snapshot{
+		Groups: make([]*GroupSnapshot, 0, len(groups)),
+	}
+
+	for _, id := range ids {
+		group := groups[id]
+
+		gameSnapshot, err := group.Snapshot()
+		if err != nil {
+			return nil, &ErrSnapshot{fmt.Errorf("group %d: %s", id, err)}
+		}
+
+		snapshot.Groups = append(snapshot.Groups, &GroupSnapshot{
+			ID:    id,
+			Limit: group.GetLimit(),
+			Game:  gameSnapshot,
+
+			Access:     group.getAccess(),
+			Metadata:   group.GetMetadata(),
+			Persistent: group.IsPersistent(),
+
+			Backpressure: group.GetBackpressure(),
+		})
+	}
+
+	return snapshot, nil
+}
+
+// Restore creates and starts the groups from the snapshot. Groups which
+// cannot be restored are skipped. Restore returns the number of restored
+// groups. The restored games are configured with the config except the walls
+// and the playground which are taken from the snapshot.
+func (m *ConnectionGroupManager) Restore(snapshot *Snapshot, config game.Config) int {
+	count := 0
+
+	for _, groupSnapshot := range snapshot.Groups {
+		logger := m.logger.WithField("group_id", groupSnapshot.ID)
+
+		group, err := NewConnectionGroupFromSnapshot(m.logger, groupSnapshot.Limit, groupSnapshot.Game, config)
+		if err != nil {
+			logger.WithError(err).Error("cannot restore group")
+			continue
+		}
+
+		group.setAccess(groupSnapshot.Access)
+		group.SetMetadata(groupSnapshot.Metadata)
+		group.SetPersistent(groupSnapshot.Persistent)
+		group.SetBackpressure(groupSnapshot.Backpressure)
+
+		if err := m.AddWithID(groupSnapshot.ID, group); err != nil {
+			logger.WithError(err).Error("cannot add restored group")
+			continue
+		}
+
+		group.Start()
+		count++
+
+		logger.WithFields(logrus.Fields{
+			"limit":   group.GetLimit(),
+			"objects": len(groupSnapshot.Game.Objects),
+		}).Info("restored group")
+	}
+
+	return count
+}
+
+// WriteSnapshot encodes the snapshot to the writer
+func WriteSnapshot(w io.Writer, snapshot *Snapshot) error {
+	if err := json.NewEncoder(w).Encode(snapshot); err != nil {
+		return &ErrSnapshot{err}
+	}
+	return nil
+}
+
+// ReadSnapshot decodes a snapshot from the reader
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	var snapshot Snapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return nil, &ErrSnapshot{err}
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,84 @@
+package connections
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ivan1993spb/snake-server/game"
+	"github.com/ivan1993spb/snake-server/playground"
+)
+
+func Test_ConnectionGroupManager_Restore_RestoresSnapshot(t *testing.T) {
+	const groupLimit = 10
+	const connsLimit = 100
+
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	m, err := NewConnectionGroupManager(logger, groupLimit, connsLimit)
+	require.Nil(t, err)
+
+	gameConfig := game.DefaultConfig()
+	gameConfig.EnableWalls = true
+	gameConfig.Playground = playground.BackendLockFree
+
+	group, err := NewConnectionGroup(logger, 5, 40, 30, gameConfig)
+	require.Nil(t, err)
+
+	group.SetMetadata(Metadata{
+		Name: "Beginner room",
+		Tags: []string{"beginner"},
+	})
+	group.SetBackpressure(Backpressure{
+		Policy:   BackpressurePolicyResync,
+		MaxDrops: 10,
+	})
+
+	id, err := m.Add(group)
+	require.Nil(t, err)
+
+	group.Start()
+	defer group.Stop()
+
+	// Let observers fill the world
+	time.Sleep(time.Millisecond * 100)
+
+	snapshot, err := m.Snapshot()
+	require.Nil(t, err)
+	require.Len(t, snapshot.Groups, 1)
+	require.Equal(t, id, snapshot.Groups[0].ID)
+	require.Equal(t, 5, snapshot.Groups[0].Limit)
+	require.NotEmpty(t, snapshot.Groups[0].Game.Objects)
+
+	buffer := &bytes.Buffer{}
+	require.Nil(t, WriteSnapshot(buffer, snapshot))
+
+	decodedSnapshot, err := ReadSnapshot(buffer)
+	require.Nil(t, err)
+
+	restoredManager, err := NewConnectionGroupManager(logger, groupLimit, connsLimit)
+	require.Nil(t, err)
+	require.Equal(t, 1, restoredManager.Restore(decodedSnapshot, game.DefaultConfig()))
+
+	restoredGroup, err := restoredManager.Get(id)
+	require.Nil(t, err)
+	defer restoredGroup.Stop()
+
+	require.Equal(t, uint8(40), restoredGroup.GetWorldWidth())
+	require.Equal(t, uint8(30), restoredGroup.GetWorldHeight())
+	require.Equal(t, 5, restoredGroup.GetLimit())
+	require.Equal(t, "Beginner room", restoredGroup.GetMetadata().Name)
+	require.Equal(t, []string{"beginner"}, restoredGroup.GetMetadata().Tags)
+	require.True(t, group.GetMetadata().CreatedAt.Equal(restoredGroup.GetMetadata().CreatedAt))
+	require.Equal(t, group.GetBackpressure(), restoredGroup.GetBackpressure())
+	require.Equal(t, playground.BackendLockFree, restoredGroup.GetPlaygroundBackend())
+
+	restoredSnapshot, err := restoredGroup.Snapshot()
+	require.Nil(t, err)
+	require.ElementsMatch(t, snapshot.Groups[0].Game.Objects, restoredSnapshot.Objects)
+	require.Equal(t, snapshot.Groups[0].Game.Registry, restoredSnapshot.Registry)
+}
@@ -0,0 +1,99 @@
+package connections
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	closeMessageWriteTimeout = time.Second
+
+	// wsWriteTimeout is the time allowed to write a message to a client
+	wsWriteTimeout = time.Second * 10
+	// wsPongTimeout is the time allowed to read the next pong or message from
+	// a client. Clients which don't respond in time are disconnected
+	wsPongTimeout = time.Second * 35
+)
+
+// transport delivers messages between a client and a connection worker
+type transport interface {
+	// ReadMessage blocks until a message from the client is received
+	ReadMessage() ([]byte, error)
+	// WriteMessage sends the message to the client
+	WriteMessage(pm *preparedMessage) error
+	// Ping checks the connection to detect dead clients
+	Ping() error
+	// Close notifies the client with the code and the reason and closes
+	// the connection
+	Close(code int, reason string) error
+}
+
+var errUnexpectedMessageType = errors.New("unexpected input message type")
+
+type webSocketTransport struct {
+	conn *websocket.Conn
+}
+
+// newWebSocketTransport creates a web-socket transport. Round-trip times
+// measured with pings are passed to the latency
+func newWebSocketTransport(conn *websocket.Conn, rtt *latency) *webSocketTransport {
+	conn.SetPongHandler(func(appData string) error {
+		// Pings carry the time they have been sent at
+		if sentAt, err := strconv.ParseInt(appData, 10, 64); err == nil {
+			rtt.observe(time.Since(time.Unix(0, sentAt)))
+		}
+		return conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+
+	return &webSocketTransport{
+		conn: conn,
+	}
+}
+
+func (t *webSocketTransport) ReadMessage() ([]byte, error) {
+	if err := t.conn.SetReadDeadline(time.Now().Add(wsPongTimeout)); err != nil {
+		return nil, err
+	}
+
+	messageType, data, err := t.conn.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+
+	if messageType != websocket.TextMessage {
+		return nil, errUnexpectedMessageType
+	}
+
+	return data, nil
+}
+
+func (t *webSocketTransport) WriteMessage(pm *preparedMessage) error {
+	if err := t.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout)); err != nil {
+		return err
+	}
+	return t.conn.WritePreparedMessage(pm.websocket)
+}
+
+func (t *webSocketTransport) Ping() error {
+	now := time.Now()
+	appData := []byte(strconv.FormatInt(now.UnixNano(), 10))
+	return t.conn.WriteControl(websocket.PingMessage, appData, now.Add(wsWriteTimeout))
+}
+
+func (t *webSocketTransport) Close(code int, reason string) error {
+	closeMessage := websocket.FormatCloseMessage(code, reason)
+
+	err := t.conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(closeMessageWriteTimeout))
+	if err == websocket.ErrCloseSent {
+		err = nil
+	}
+
+	if closeErr := t.conn.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
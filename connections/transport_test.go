@@ -0,0 +1,106 @@
+package connections
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_webSocketTransport_Ping_SendsPingToClient(t *testing.T) {
+	transports := make(chan *webSocketTransport, 1)
+
+	upgrader := &websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		transports <- newWebSocketTransport(conn, newLatency())
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.Nil(t, err)
+	defer conn.Close()
+
+	pings := make(chan struct{}, 1)
+	conn.SetPingHandler(func(string) error {
+		pings <- struct{}{}
+		return nil
+	})
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	var transport *webSocketTransport
+	select {
+	case transport = <-transports:
+	case <-time.After(time.Second):
+		t.Fatal("connection is not upgraded")
+	}
+	defer transport.conn.Close()
+
+	require.Nil(t, transport.Ping())
+
+	select {
+	case <-pings:
+	case <-time.After(time.Second):
+		t.Fatal("ping is not received")
+	}
+}
+
+func Test_webSocketTransport_Ping_MeasuresRTT(t *testing.T) {
+	rtt := newLatency()
+	measured := make(chan struct{})
+
+	upgrader := &websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		transport := newWebSocketTransport(conn, rtt)
+		defer transport.conn.Close()
+
+		if err := transport.Ping(); err != nil {
+			return
+		}
+		// Pongs are handled while reading
+		go transport.ReadMessage()
+
+		if eventually(func() bool {
+			return rtt.Last() > 0
+		}, time.Second) {
+			close(measured)
+		}
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.Nil(t, err)
+	defer conn.Close()
+
+	// The client responds to pings while reading
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-measured:
+	case <-time.After(time.Second * 2):
+		t.Fatal("rtt is not measured")
+	}
+}
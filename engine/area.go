@@ -241,6 +241,24 @@ func (a Area) Navigate(dot Dot, dir Direction, dis uint8) (Dot, error) {
 	}
 }
 
+// wrapDelta returns the distance between two coordinates across the border
+func wrapDelta(a, b, size uint8) uint8 {
+	d := a - b
+	if a < b {
+		d = b - a
+	}
+	if size-d < d {
+		return size - d
+	}
+	return d
+}
+
+// WrapDistance returns the Manhattan distance between two dots across the
+// borders of the area as objects move with Navigate
+func (a Area) WrapDistance(from, to Dot) uint16 {
+	return uint16(wrapDelta(from.X, to.X, a.width)) + uint16(wrapDelta(from.Y, to.Y, a.height))
+}
+
 const areaExpectedSerializedSize = 26
 
 // Implementing json.Marshaler interface
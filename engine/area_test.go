@@ -276,3 +276,24 @@ func Test_Area_Height_ReturnsAreaHeight(t *testing.T) {
 		require.Equal(t, test.area.height, test.area.Height(), fmt.Sprintf("number: %d", i))
 	}
 }
+
+func Test_Area_WrapDistance(t *testing.T) {
+	area := MustArea(10, 8)
+
+	tests := []struct {
+		from     Dot
+		to       Dot
+		distance uint16
+	}{
+		{Dot{X: 0, Y: 0}, Dot{X: 0, Y: 0}, 0},
+		{Dot{X: 1, Y: 1}, Dot{X: 3, Y: 2}, 3},
+		{Dot{X: 0, Y: 0}, Dot{X: 9, Y: 7}, 2},
+		{Dot{X: 0, Y: 0}, Dot{X: 5, Y: 4}, 9},
+		{Dot{X: 8, Y: 1}, Dot{X: 1, Y: 6}, 6},
+	}
+
+	for i, test := range tests {
+		require.Equal(t, test.distance, area.WrapDistance(test.from, test.to), "test %d", i)
+		require.Equal(t, test.distance, area.WrapDistance(test.to, test.from), "test %d", i)
+	}
+}
@@ -1,6 +1,9 @@
 package engine
 
-import "math/rand"
+import (
+	"bytes"
+	"math/rand"
+)
 
 type ErrInvalidDirection struct {
 	Direction Direction
@@ -110,6 +113,28 @@ func (dir Direction) MarshalJSON() ([]byte, error) {
 	}
 }
 
+type ErrDirectionUnmarshal struct {
+	Data []byte
+}
+
+func (e *ErrDirectionUnmarshal) Error() string {
+	return "cannot unmarshal direction"
+}
+
+// Implementing json.Unmarshaler interface
+func (dir *Direction) UnmarshalJSON(data []byte) error {
+	for direction, dirJSON := range directionsJSON {
+		if bytes.Equal(dirJSON, data) {
+			*dir = direction
+			return nil
+		}
+	}
+
+	return &ErrDirectionUnmarshal{
+		Data: data,
+	}
+}
+
 type ErrReverseDirection struct {
 	Err error
 }
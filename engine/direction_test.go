@@ -106,3 +106,26 @@ func Test_Direction_MarshalJSON(t *testing.T) {
 		require.Equal(t, test.expectedErr, err, fmt.Sprintf("number %d", i))
 	}
 }
+
+func Test_Direction_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		json              []byte
+		expectedDirection Direction
+		expectedErr       error
+	}{
+		{[]byte(`"north"`), DirectionNorth, nil},
+		{[]byte(`"east"`), DirectionEast, nil},
+		{[]byte(`"south"`), DirectionSouth, nil},
+		{[]byte(`"west"`), DirectionWest, nil},
+		{[]byte(`"-"`), 0, &ErrDirectionUnmarshal{
+			Data: []byte(`"-"`),
+		}},
+	}
+
+	for i, test := range tests {
+		var direction Direction
+		err := direction.UnmarshalJSON(test.json)
+		require.Equal(t, test.expectedDirection, direction, fmt.Sprintf("number %d", i))
+		require.Equal(t, test.expectedErr, err, fmt.Sprintf("number %d", i))
+	}
+}
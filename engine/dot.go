@@ -2,6 +2,8 @@ package engine
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 )
@@ -36,6 +38,25 @@ func (d Dot) MarshalJSON() ([]byte, error) {
 	return buff.Bytes(), nil
 }
 
+var errDotUnmarshalJSON = errors.New("cannot unmarshal dot: expected an array of two coordinates")
+
+// Implementing json.Unmarshaler interface
+func (d *Dot) UnmarshalJSON(data []byte) error {
+	var coordinates []uint8
+	if err := json.Unmarshal(data, &coordinates); err != nil {
+		return err
+	}
+
+	if len(coordinates) != 2 {
+		return errDotUnmarshalJSON
+	}
+
+	d.X = coordinates[0]
+	d.Y = coordinates[1]
+
+	return nil
+}
+
 func (d Dot) Hash() uint16 {
 	return uint16(d.X)<<8 | uint16(d.Y)
 }
@@ -76,6 +76,33 @@ func Test_Dot_MarshalJSON(t *testing.T) {
 	}
 }
 
+func Test_Dot_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		json      []byte
+		dot       Dot
+		expectErr bool
+	}{
+		{[]byte("[10,10]"), Dot{10, 10}, false},
+		{[]byte("[0,255]"), Dot{0, 255}, false},
+		{[]byte("[1, 3]"), Dot{1, 3}, false},
+		{[]byte("[1]"), Dot{}, true},
+		{[]byte("[1,2,3]"), Dot{}, true},
+		{[]byte("[256,1]"), Dot{}, true},
+		{[]byte(`"1,2"`), Dot{}, true},
+	}
+
+	for i, test := range tests {
+		var dot Dot
+		err := dot.UnmarshalJSON(test.json)
+		if test.expectErr {
+			require.NotNil(t, err, "test %d", i)
+		} else {
+			require.Nil(t, err, "test %d", i)
+			require.Equal(t, test.dot, dot, "test %d", i)
+		}
+	}
+}
+
 func Test_Dot_DistanceTo_CalculatesDistance(t *testing.T) {
 	tests := []struct {
 		first            Dot
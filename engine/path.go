@@ -0,0 +1,260 @@
+package engine
+
+import (
+	"container/heap"
+	"errors"
+)
+
+// Passable returns true if a path can go through the dot
+type Passable func(dot Dot) bool
+
+var ErrPathNotFound = errors.New("path not found")
+
+func (a Area) dotIndex(dot Dot) int {
+	return int(dot.Y)*int(a.width) + int(dot.X)
+}
+
+func (a Area) indexDot(i int) Dot {
+	return Dot{
+		X: uint8(i % int(a.width)),
+		Y: uint8(i / int(a.width)),
+	}
+}
+
+// neighbours returns the adjacent dots in the order of directions
+func (a Area) neighbours(dot Dot) [directionCount]Dot {
+	var dots [directionCount]Dot
+	for dir := DirectionNorth; dir < directionCount; dir++ {
+		// The dot is in the area and the direction is valid
+		dots[dir], _ = a.Navigate(dot, dir, 1)
+	}
+	return dots
+}
+
+func (a Area) checkPathDots(from, to Dot) error {
+	if !a.ContainsDot(from) {
+		return &ErrAreaNotContainsDot{
+			Dot: from,
+		}
+	}
+	if !a.ContainsDot(to) {
+		return &ErrAreaNotContainsDot{
+			Dot: to,
+		}
+	}
+	return nil
+}
+
+// buildPath returns dots from the dot after the start to the end by the
+// previous dots indexes
+func (a Area) buildPath(previous []int, start, end int) Location {
+	length := 0
+	for i := end; i != start; i = previous[i] {
+		length++
+	}
+
+	path := make(Location, length)
+	for i := end; i != start; i = previous[i] {
+		length--
+		path[length] = a.indexDot(i)
+	}
+
+	return path
+}
+
+// FindPathBFS returns the shortest path between the dots found with breadth
+// first search. The path contains the dots after from up to to inclusive.
+// The destination does not have to be passable, so that a path to an
+// object could be found
+func (a Area) FindPathBFS(from, to Dot, passable Passable) (Location, error) {
+	if err := a.checkPathDots(from, to); err != nil {
+		return nil, err
+	}
+
+	start, end := a.dotIndex(from), a.dotIndex(to)
+	if start == end {
+		return Location{}, nil
+	}
+
+	previous := make([]int, a.Size())
+	visited := make([]bool, a.Size())
+	visited[start] = true
+	queue := []int{start}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, dot := range a.neighbours(a.indexDot(current)) {
+			next := a.dotIndex(dot)
+			if visited[next] {
+				continue
+			}
+			if next != end && !passable(dot) {
+				continue
+			}
+
+			visited[next] = true
+			previous[next] = current
+
+			if next == end {
+				return a.buildPath(previous, start, end), nil
+			}
+
+			queue = append(queue, next)
+		}
+	}
+
+	return nil, ErrPathNotFound
+}
+
+type pathNode struct {
+	index int
+	cost  uint16
+	// estimate is the cost plus the heuristic distance to the destination
+	estimate uint16
+}
+
+type pathNodeQueue []pathNode
+
+func (q pathNodeQueue) Len() int {
+	return len(q)
+}
+
+func (q pathNodeQueue) Less(i, j int) bool {
+	if q[i].estimate == q[j].estimate {
+		// Prefer nodes closer to the destination
+		return q[i].cost > q[j].cost
+	}
+	return q[i].estimate < q[j].estimate
+}
+
+func (q pathNodeQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *pathNodeQueue) Push(x interface{}) {
+	*q = append(*q, x.(pathNode))
+}
+
+func (q *pathNodeQueue) Pop() interface{} {
+	old := *q
+	node := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return node
+}
+
+// FindPath returns the shortest path between the dots found with A* search.
+// The path contains the dots after from up to to inclusive. The destination
+// does not have to be passable, so that a path to an object could be found
+func (a Area) FindPath(from, to Dot, passable Passable) (Location, error) {
+	if err := a.checkPathDots(from, to); err != nil {
+		return nil, err
+	}
+
+	start, end := a.dotIndex(from), a.dotIndex(to)
+	if start == end {
+		return Location{}, nil
+	}
+
+	previous := make([]int, a.Size())
+	costs := make([]uint16, a.Size())
+	reached := make([]bool, a.Size())
+	closed := make([]bool, a.Size())
+
+	reached[start] = true
+	queue := &pathNodeQueue{{
+		index:    start,
+		estimate: a.WrapDistance(from, to),
+	}}
+
+	for queue.Len() > 0 {
+		current := heap.Pop(queue).(pathNode)
+		if current.index == end {
+			return a.buildPath(previous, start, end), nil
+		}
+		if closed[current.index] {
+			continue
+		}
+		closed[current.index] = true
+
+		for _, dot := range a.neighbours(a.indexDot(current.index)) {
+			next := a.dotIndex(dot)
+			if closed[next] {
+				continue
+			}
+			if next != end && !passable(dot) {
+				continue
+			}
+
+			cost := current.cost + 1
+			if reached[next] && costs[next] <= cost {
+				continue
+			}
+
+			reached[next] = true
+			costs[next] = cost
+			previous[next] = current.index
+
+			heap.Push(queue, pathNode{
+				index:    next,
+				cost:     cost,
+				estimate: cost + a.WrapDistance(dot, to),
+			})
+		}
+	}
+
+	return nil, ErrPathNotFound
+}
+
+// NextDirection returns the direction of the first step of the shortest path
+// from the dot to the destination
+func (a Area) NextDirection(from, to Dot, passable Passable) (Direction, error) {
+	path, err := a.FindPath(from, to, passable)
+	if err != nil {
+		return 0, err
+	}
+	if len(path) == 0 {
+		return 0, ErrPathNotFound
+	}
+
+	for dir, dot := range a.neighbours(from) {
+		if dot.Equals(path[0]) {
+			return Direction(dir), nil
+		}
+	}
+
+	return 0, ErrPathNotFound
+}
+
+// FloodFill returns the dots reachable from the dot through passable dots
+// including the dot itself
+func (a Area) FloodFill(from Dot, passable Passable) Location {
+	if !a.ContainsDot(from) {
+		return Location{}
+	}
+
+	start := a.dotIndex(from)
+	visited := make([]bool, a.Size())
+	visited[start] = true
+	queue := []int{start}
+	location := Location{from}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, dot := range a.neighbours(a.indexDot(current)) {
+			next := a.dotIndex(dot)
+			if visited[next] || !passable(dot) {
+				continue
+			}
+
+			visited[next] = true
+			queue = append(queue, next)
+			location = append(location, dot)
+		}
+	}
+
+	return location
+}
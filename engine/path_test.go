@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func passableExcept(dots ...Dot) Passable {
+	walls := make(map[Dot]bool)
+	for _, dot := range dots {
+		walls[dot] = true
+	}
+	return func(dot Dot) bool {
+		return !walls[dot]
+	}
+}
+
+func passableAll(dot Dot) bool {
+	return true
+}
+
+// requirePath checks that the path is a chain of adjacent passable dots
+func requirePath(t *testing.T, area Area, from, to Dot, path Location, passable Passable) {
+	require.NotEmpty(t, path)
+	require.Equal(t, to, path[len(path)-1])
+
+	prev := from
+	for i, dot := range path {
+		require.Equal(t, uint16(1), area.WrapDistance(prev, dot), "step %d", i)
+		if i < len(path)-1 {
+			require.True(t, passable(dot), "step %d", i)
+		}
+		prev = dot
+	}
+}
+
+func Test_Area_FindPath_WrapsAround(t *testing.T) {
+	area := MustArea(20, 20)
+
+	for _, find := range []func(from, to Dot, passable Passable) (Location, error){area.FindPath, area.FindPathBFS} {
+		path, err := find(Dot{1, 1}, Dot{18, 1}, passableAll)
+		require.Nil(t, err)
+		require.Len(t, path, 3)
+		requirePath(t, area, Dot{1, 1}, Dot{18, 1}, path, passableAll)
+
+		path, err = find(Dot{5, 5}, Dot{5, 5}, passableAll)
+		require.Nil(t, err)
+		require.Empty(t, path)
+	}
+}
+
+func Test_Area_FindPath_AvoidsWalls(t *testing.T) {
+	area := MustArea(10, 10)
+
+	// Two vertical walls with gaps at the bottom. The shortest way goes
+	// through the gap across the top border
+	walls := make([]Dot, 0)
+	for y := uint8(0); y < 9; y++ {
+		walls = append(walls, Dot{5, y}, Dot{9, y})
+	}
+	passable := passableExcept(walls...)
+
+	from, to := Dot{3, 3}, Dot{7, 3}
+
+	pathAStar, err := area.FindPath(from, to, passable)
+	require.Nil(t, err)
+	requirePath(t, area, from, to, pathAStar, passable)
+
+	pathBFS, err := area.FindPathBFS(from, to, passable)
+	require.Nil(t, err)
+	requirePath(t, area, from, to, pathBFS, passable)
+
+	require.Equal(t, len(pathBFS), len(pathAStar))
+	require.Len(t, pathAStar, 4+4+4)
+}
+
+func Test_Area_FindPath_NotFound(t *testing.T) {
+	area := MustArea(10, 10)
+
+	// The destination is enclosed, but it is reachable itself if it is not passable
+	passable := passableExcept(Dot{4, 5}, Dot{6, 5}, Dot{5, 4}, Dot{5, 6}, Dot{5, 5})
+
+	_, err := area.FindPath(Dot{1, 1}, Dot{5, 5}, passable)
+	require.Equal(t, ErrPathNotFound, err)
+	_, err = area.FindPathBFS(Dot{1, 1}, Dot{5, 5}, passable)
+	require.Equal(t, ErrPathNotFound, err)
+
+	path, err := area.FindPath(Dot{1, 1}, Dot{5, 4}, passable)
+	require.Nil(t, err)
+	requirePath(t, area, Dot{1, 1}, Dot{5, 4}, path, passable)
+
+	_, err = area.FindPath(Dot{1, 1}, Dot{10, 1}, passable)
+	require.NotNil(t, err)
+}
+
+func Test_Area_NextDirection(t *testing.T) {
+	area := MustArea(10, 10)
+
+	dir, err := area.NextDirection(Dot{1, 5}, Dot{8, 5}, passableAll)
+	require.Nil(t, err)
+	require.Equal(t, DirectionWest, dir)
+
+	dir, err = area.NextDirection(Dot{1, 5}, Dot{1, 7}, passableAll)
+	require.Nil(t, err)
+	require.Equal(t, DirectionSouth, dir)
+
+	_, err = area.NextDirection(Dot{1, 5}, Dot{1, 5}, passableAll)
+	require.Equal(t, ErrPathNotFound, err)
+}
+
+func Test_Area_FloodFill(t *testing.T) {
+	area := MustArea(10, 10)
+
+	require.Len(t, area.FloodFill(Dot{3, 3}, passableAll), 100)
+
+	// A closed ring of walls around the dot 5, 5
+	passable := passableExcept(Dot{4, 5}, Dot{6, 5}, Dot{5, 4}, Dot{5, 6})
+	require.Equal(t, Location{{5, 5}}, area.FloodFill(Dot{5, 5}, passable))
+	require.Len(t, area.FloodFill(Dot{0, 0}, passable), 100-5)
+
+	require.Empty(t, area.FloodFill(Dot{10, 10}, passableAll))
+}
+
+func Benchmark_Area_FindPath(b *testing.B) {
+	area := MustArea(255, 255)
+
+	for i := 0; i < b.N; i++ {
+		if _, err := area.FindPath(Dot{0, 0}, Dot{100, 120}, passableAll); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,5 +1,33 @@
 package game
 
+import (
+	"github.com/ivan1993spb/snake-server/highscores"
+	"github.com/ivan1993spb/snake-server/objects/corpse"
+	"github.com/ivan1993spb/snake-server/objects/snake"
+	"github.com/ivan1993spb/snake-server/playground"
+)
+
 type Config struct {
 	EnableWalls bool
+
+	// Playground is the implementation of the game's playground
+	Playground playground.Backend
+
+	Corpse corpse.Config
+
+	// Snake defines how snakes of players are spawned
+	Snake snake.Config
+
+	// HighScores stores players' records if it is set
+	HighScores highscores.Recorder
+}
+
+// DefaultConfig returns a configuration of a game without walls and high
+// scores on the default playground
+func DefaultConfig() Config {
+	return Config{
+		Playground: playground.BackendCMap,
+		Corpse:     corpse.DefaultConfig(),
+		Snake:      snake.DefaultConfig(),
+	}
 }
@@ -1,7 +1,11 @@
 package game
 
 //go:generate ffjson $GOFILE
-import "github.com/ivan1993spb/snake-server/world"
+import (
+	"time"
+
+	"github.com/ivan1993spb/snake-server/world"
+)
 
 type EventType uint8
 
@@ -47,6 +51,20 @@ func (event EventType) MarshalJSON() ([]byte, error) {
 type Event struct {
 	Type    EventType   `json:"type"`
 	Payload interface{} `json:"payload"`
+	// Seq is the sequence number of the event in the game world
+	Seq uint64 `json:"seq"`
+	// Time is a monotonic time of the event in milliseconds since the game
+	// has started
+	Time int64 `json:"time"`
+}
+
+func newEvent(worldEvent world.Event) Event {
+	return Event{
+		Type:    worldEventTypeToGameEventType(worldEvent.Type),
+		Payload: worldEvent.Payload,
+		Seq:     worldEvent.Seq,
+		Time:    int64(worldEvent.Time / time.Millisecond),
+	}
 }
 
 var eventTypesCasting = map[world.EventType]EventType{
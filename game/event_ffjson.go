@@ -48,6 +48,10 @@ func (j *Event) MarshalJSONBuf(buf fflib.EncodingBuffer) error {
 	if err != nil {
 		return err
 	}
+	buf.WriteString(`,"seq":`)
+	fflib.FormatBits2(buf, uint64(j.Seq), 10, false)
+	buf.WriteString(`,"time":`)
+	fflib.FormatBits2(buf, uint64(j.Time), 10, j.Time < 0)
 	buf.WriteByte('}')
 	return nil
 }
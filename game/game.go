@@ -5,12 +5,14 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/ivan1993spb/snake-server/objects/snake"
 	"github.com/ivan1993spb/snake-server/observers/apple"
 	"github.com/ivan1993spb/snake-server/observers/logger"
 	"github.com/ivan1993spb/snake-server/observers/mouse"
 	"github.com/ivan1993spb/snake-server/observers/snake"
 	"github.com/ivan1993spb/snake-server/observers/wall"
 	"github.com/ivan1993spb/snake-server/observers/watermelon"
+	"github.com/ivan1993spb/snake-server/playground"
 	"github.com/ivan1993spb/snake-server/world"
 )
 
@@ -18,6 +20,9 @@ type Game struct {
 	world  world.Interface
 	logger logrus.FieldLogger
 	config Config
+
+	// snapshot is restored on start if it's set
+	snapshot *Snapshot
 }
 
 type ErrCreateGame struct {
@@ -29,7 +34,7 @@ func (e *ErrCreateGame) Error() string {
 }
 
 func NewGame(logger logrus.FieldLogger, width, height uint8, config Config) (*Game, error) {
-	w, err := world.NewWorld(width, height)
+	w, err := world.NewWorldWithBackend(config.Playground, width, height)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create game: %s", err)
 	}
@@ -44,29 +49,43 @@ func NewGame(logger logrus.FieldLogger, width, height uint8, config Config) (*Ga
 func (g *Game) Start(stop <-chan struct{}) {
 	g.world.Start(stop)
 
+	if g.snapshot != nil {
+		g.restore(stop, g.snapshot)
+		g.snapshot = nil
+	}
+
 	logger_observer.NewLoggerObserver(g.world, g.logger).Observe(stop)
 	if g.config.EnableWalls {
 		wall_observer.NewWallObserver(g.world, g.logger).Observe(stop)
 	}
 	apple_observer.NewAppleObserver(g.world, g.logger).Observe(stop)
-	snake_observer.NewSnakeObserver(g.world, g.logger).Observe(stop)
+	snake_observer.NewSnakeObserver(g.world, g.logger, g.config.Corpse, g.config.HighScores).Observe(stop)
 	watermelon_observer.NewWatermelonObserver(g.world, g.logger).Observe(stop)
 	mouse_observer.NewMouseObserver(g.world, g.logger).Observe(stop)
 }
 
+// PlaygroundBackend returns the implementation of the game's playground
+func (g *Game) PlaygroundBackend() playground.Backend {
+	return g.config.Playground
+}
+
+// SnakeConfig returns the configuration of players' snakes
+func (g *Game) SnakeConfig() snake.Config {
+	return g.config.Snake
+}
+
 func (g *Game) World() world.Interface {
 	return g.world
 }
 
+// ListenEvents returns a channel of all events in the game. No events are
+// dropped, so the listener must keep up with the game
 func (g *Game) ListenEvents(stop <-chan struct{}, buffer uint) <-chan Event {
 	chout := make(chan Event, buffer)
 	go func() {
 		defer close(chout)
-		for worldEvent := range g.world.Events(stop, buffer) {
-			chout <- Event{
-				Type:    worldEventTypeToGameEventType(worldEvent.Type),
-				Payload: worldEvent.Payload,
-			}
+		for worldEvent := range g.world.LosslessEvents(stop, buffer) {
+			chout <- newEvent(worldEvent)
 		}
 	}()
 	return chout
@@ -0,0 +1,189 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ivan1993spb/snake-server/engine"
+	"github.com/ivan1993spb/snake-server/objects/apple"
+	"github.com/ivan1993spb/snake-server/objects/corpse"
+	"github.com/ivan1993spb/snake-server/objects/mouse"
+	"github.com/ivan1993spb/snake-server/objects/wall"
+	"github.com/ivan1993spb/snake-server/objects/watermelon"
+	"github.com/ivan1993spb/snake-server/playground"
+	"github.com/ivan1993spb/snake-server/world"
+)
+
+// Type labels of the objects in the snapshots
+const (
+	snapshotObjectTypeApple      = "apple"
+	snapshotObjectTypeCorpse     = "corpse"
+	snapshotObjectTypeMouse      = "mouse"
+	snapshotObjectTypeSnake      = "snake"
+	snapshotObjectTypeWall       = "wall"
+	snapshotObjectTypeWatermelon = "watermelon"
+)
+
+// Snapshot represents the state of a game which could be saved and restored
+// later. Objects are stored in the same JSON format which is used to send
+// them to the clients.
+type Snapshot struct {
+	Width       uint8                            `json:"width"`
+	Height      uint8                            `json:"height"`
+	EnableWalls bool                             `json:"enable_walls"`
+	Playground  playground.Backend               `json:"playground"`
+	Registry    world.IdentifierRegistrySnapshot `json:"registry"`
+	Objects     []json.RawMessage                `json:"objects"`
+
+	// Invulnerability is absent in snapshots of older versions, such
+	// games get the invulnerability window of the server config
+	Invulnerability *time.Duration `json:"invulnerability,omitempty"`
+}
+
+// snapshotObject contains all fields of the objects in the snapshots
+type snapshotObject struct {
+	Type      string           `json:"type"`
+	ID        world.Identifier `json:"id"`
+	Dot       engine.Dot       `json:"dot"`
+	Dots      engine.Location  `json:"dots"`
+	Direction engine.Direction `json:"direction"`
+}
+
+type ErrSnapshotGame struct {
+	Err error
+}
+
+func (e *ErrSnapshotGame) Error() string {
+	return "cannot snapshot game: " + e.Err.Error()
+}
+
+// Snapshot returns the current state of the game
+func (g *Game) Snapshot() (*Snapshot, error) {
+	objects := g.world.GetObjects()
+	rawObjects := make([]json.RawMessage, 0, len(objects))
+
+	for _, object := range objects {
+		data, err := json.Marshal(object)
+		if err != nil {
+			return nil, &ErrSnapshotGame{err}
+		}
+		rawObjects = append(rawObjects, data)
+	}
+
+	area := g.world.Area()
+	invulnerability := g.config.Snake.Invulnerability
+
+	return &Snapshot{
+		Width:       area.Width(),
+		Height:      area.Height(),
+		EnableWalls: g.config.EnableWalls,
+		Playground:  g.config.Playground,
+		Registry:    g.world.IdentifierRegistry().Snapshot(),
+		Objects:     rawObjects,
+
+		Invulnerability: &invulnerability,
+	}, nil
+}
+
+// NewGameFromSnapshot creates a game which restores the snapshot on start
+func NewGameFromSnapshot(logger logrus.FieldLogger, snapshot *Snapshot, config Config) (*Game, error) {
+	if snapshot == nil {
+		return nil, &ErrCreateGame{
+			Err: fmt.Errorf("snapshot is nil"),
+		}
+	}
+
+	config.EnableWalls = snapshot.EnableWalls
+	config.Playground = snapshot.Playground
+	if snapshot.Invulnerability != nil {
+		config.Snake.Invulnerability = *snapshot.Invulnerability
+	}
+
+	g, err := NewGame(logger, snapshot.Width, snapshot.Height, config)
+	if err != nil {
+		return nil, err
+	}
+
+	g.snapshot = snapshot
+
+	return g, nil
+}
+
+// restore puts the objects of the snapshot in the world
+func (g *Game) restore(stop <-chan struct{}, snapshot *Snapshot) {
+	g.world.IdentifierRegistry().Restore(snapshot.Registry)
+
+	for _, rawObject := range snapshot.Objects {
+		var object snapshotObject
+
+		if err := json.Unmarshal(rawObject, &object); err != nil {
+			g.logger.WithError(err).Error("cannot decode snapshot object")
+			continue
+		}
+
+		if err := g.restoreObject(stop, object); err != nil {
+			g.logger.WithError(err).WithFields(logrus.Fields{
+				"type": object.Type,
+				"id":   object.ID,
+			}).Error("cannot restore snapshot object")
+
+			// The identifier has been obtained with the registry snapshot.
+			// Identifiers of snakes are released before corpses are created
+			if object.Type != snapshotObjectTypeSnake {
+				g.world.IdentifierRegistry().Release(object.ID)
+			}
+		}
+	}
+
+	// The walls could enclose pockets of free space if they have been saved
+	// by an older version or if some of them have not been restored
+	broken, err := wall.ConnectWalls(g.world)
+	if err != nil {
+		g.logger.WithError(err).Error("cannot connect restored walls")
+	}
+	if broken > 0 {
+		g.logger.WithField("broken", broken).Warn("restored walls have been broken to connect free space")
+	}
+}
+
+func (g *Game) restoreObject(stop <-chan struct{}, object snapshotObject) error {
+	switch object.Type {
+	case snapshotObjectTypeApple:
+		_, err := apple.RestoreApple(g.world, object.ID, object.Dot)
+		return err
+	case snapshotObjectTypeCorpse:
+		c, err := corpse.RestoreCorpse(g.world, object.ID, object.Dots, g.config.Corpse)
+		if err != nil {
+			return err
+		}
+		c.Run(stop, g.logger)
+	case snapshotObjectTypeMouse:
+		m, err := mouse.RestoreMouse(g.world, object.ID, object.Dot, object.Direction)
+		if err != nil {
+			return err
+		}
+		m.Run(stop)
+	case snapshotObjectTypeSnake:
+		// Players cannot come back to their snakes after a restart,
+		// so the snakes turn into corpses.
+		g.world.IdentifierRegistry().Release(object.ID)
+		c, err := corpse.NewCorpse(g.world, object.Dots, g.config.Corpse)
+		if err != nil {
+			return err
+		}
+		c.Run(stop, g.logger)
+	case snapshotObjectTypeWall:
+		_, err := wall.RestoreWall(g.world, object.ID, object.Dots)
+		return err
+	case snapshotObjectTypeWatermelon:
+		_, err := watermelon.RestoreWatermelon(g.world, object.ID, object.Dots)
+		return err
+	default:
+		return fmt.Errorf("unknown object type: %q", object.Type)
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ivan1993spb/snake-server/engine"
+	"github.com/ivan1993spb/snake-server/objects/apple"
+	"github.com/ivan1993spb/snake-server/objects/corpse"
+	"github.com/ivan1993spb/snake-server/objects/wall"
+	"github.com/ivan1993spb/snake-server/world"
+)
+
+func Test_Game_restore_TurnsSnakesIntoCorpses(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	snakeDots := engine.Location{
+		{X: 5, Y: 5},
+		{X: 6, Y: 5},
+		{X: 7, Y: 5},
+	}
+
+	snapshot := &Snapshot{
+		Width:      20,
+		Height:     20,
+		Playground: DefaultConfig().Playground,
+		Registry: world.IdentifierRegistrySnapshot{
+			Index:    12,
+			Obtained: []world.Identifier{10, 11, 12},
+		},
+		Objects: []json.RawMessage{
+			json.RawMessage(`{"type":"snake","id":10,"dots":[[5,5],[6,5],[7,5]]}`),
+			json.RawMessage(`{"type":"apple","id":11,"dot":[1,1]}`),
+			// The dot is occupied by the apple, so the object is not restored
+			json.RawMessage(`{"type":"apple","id":12,"dot":[1,1]}`),
+		},
+	}
+
+	g, err := NewGameFromSnapshot(logger, snapshot, DefaultConfig())
+	require.Nil(t, err)
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	g.world.Start(stop)
+	g.restore(stop, snapshot)
+
+	objects := g.world.GetObjects()
+	require.Len(t, objects, 2)
+
+	for _, dot := range snakeDots {
+		require.IsType(t, &corpse.Corpse{}, g.world.GetObjectByDot(dot))
+	}
+
+	restoredApple := g.world.GetObjectByDot(engine.Dot{X: 1, Y: 1})
+	require.IsType(t, &apple.Apple{}, restoredApple)
+	data, err := json.Marshal(restoredApple)
+	require.Nil(t, err)
+	require.Contains(t, string(data), `"id":11`)
+
+	require.Equal(t, len(objects), g.world.IdentifierRegistry().Count())
+}
+
+func Test_NewGameFromSnapshot_RestoresInvulnerability(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	config := DefaultConfig()
+	config.Snake.Invulnerability = time.Second * 3
+
+	g, err := NewGame(logger, 20, 20, config)
+	require.Nil(t, err)
+
+	snapshot, err := g.Snapshot()
+	require.Nil(t, err)
+
+	data, err := json.Marshal(snapshot)
+	require.Nil(t, err)
+
+	var restoredSnapshot *Snapshot
+	require.Nil(t, json.Unmarshal(data, &restoredSnapshot))
+
+	restored, err := NewGameFromSnapshot(logger, restoredSnapshot, DefaultConfig())
+	require.Nil(t, err)
+	require.Equal(t, time.Second*3, restored.SnakeConfig().Invulnerability)
+
+	// Snapshots of older versions keep the window of the server config
+	restoredSnapshot.Invulnerability = nil
+	restored, err = NewGameFromSnapshot(logger, restoredSnapshot, DefaultConfig())
+	require.Nil(t, err)
+	require.Equal(t, DefaultConfig().Snake.Invulnerability, restored.SnakeConfig().Invulnerability)
+}
+
+func Test_Game_restore_ConnectsEnclosedPockets(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	// The ring of walls encloses the dot 6,6
+	ring := engine.NewRect(5, 5, 3, 3).Location().Delete(engine.Dot{X: 6, Y: 6})
+	data, err := json.Marshal(map[string]interface{}{
+		"type": "wall",
+		"id":   1,
+		"dots": ring,
+	})
+	require.Nil(t, err)
+
+	snapshot := &Snapshot{
+		Width:      20,
+		Height:     20,
+		Playground: DefaultConfig().Playground,
+		Registry: world.IdentifierRegistrySnapshot{
+			Index:    1,
+			Obtained: []world.Identifier{1},
+		},
+		Objects: []json.RawMessage{data},
+	}
+
+	g, err := NewGameFromSnapshot(logger, snapshot, DefaultConfig())
+	require.Nil(t, err)
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	g.world.Start(stop)
+	g.restore(stop, snapshot)
+
+	walls := make(engine.Location, 0)
+	for _, dot := range g.world.Area().Dots() {
+		if object := g.world.GetObjectByDot(dot); object != nil {
+			require.IsType(t, &wall.Wall{}, object)
+			walls = append(walls, dot)
+		}
+	}
+	require.Len(t, walls, len(ring)-1)
+	require.Nil(t, wall.ValidateConnectivity(g.world.Area(), walls))
+}
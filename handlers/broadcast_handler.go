@@ -16,6 +16,8 @@ const URLRouteBroadcast = "/games/{id}/broadcast"
 
 const MethodBroadcast = http.MethodPost
 
+const ScopeBroadcast = "games:broadcast"
+
 const broadcastTimeout = time.Millisecond
 
 const broadcastMaxBodySize = 128
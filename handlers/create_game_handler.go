@@ -5,23 +5,46 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
 	"github.com/ivan1993spb/snake-server/connections"
+	"github.com/ivan1993spb/snake-server/game"
+	"github.com/ivan1993spb/snake-server/playground"
 )
 
 const URLRouteCreateGame = "/games"
 
 const MethodCreateGame = http.MethodPost
 
+const ScopeCreateGame = "games:create"
+
+// ScopePersistentGame is required to create games which are never reaped
+const ScopePersistentGame = "games:persistent"
+
 const (
 	postFieldConnectionLimit = "limit"
 	postFieldMapWidth        = "width"
 	postFieldMapHeight       = "height"
 	postFieldEnableWalls     = "enable_walls"
+	postFieldPrivate         = "private"
+	postFieldPassword        = "password"
+	postFieldPersistent      = "persistent"
+
+	postFieldBackpressure         = "backpressure"
+	postFieldBackpressureMaxDrops = "backpressure_max_drops"
+
+	postFieldPlayground = "playground"
+
+	postFieldInvulnerability = "invulnerability"
 )
 
+const maxGamePasswordLength = 64
+
+// maxInvulnerability limits the invulnerability window of new snakes
+const maxInvulnerability = time.Minute
+
 const (
 	minMapWidth  = 8
 	minMapHeight = 8
@@ -41,6 +64,26 @@ type responseCreateGameHandler struct {
 	Width  uint8  `json:"width"`
 	Height uint8  `json:"height"`
 	Rate   uint32 `json:"rate"`
+
+	Private bool `json:"private"`
+	// Invite is a token to join the private game
+	Invite string `json:"invite,omitempty"`
+
+	Persistent bool `json:"persistent"`
+
+	Backpressure connections.Backpressure `json:"backpressure"`
+
+	Playground playground.Backend `json:"playground"`
+
+	// Invulnerability is the invulnerability window of new snakes in milliseconds
+	Invulnerability int64 `json:"invulnerability"`
+
+	responseGameMetadata
+}
+
+// milliseconds converts the duration to milliseconds for the responses
+func milliseconds(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
 }
 
 type responseCreateGameHandlerError struct {
@@ -51,6 +94,15 @@ type responseCreateGameHandlerError struct {
 type createGameHandler struct {
 	logger       logrus.FieldLogger
 	groupManager *connections.ConnectionGroupManager
+	gameConfig   game.Config
+	backpressure connections.Backpressure
+	authorizer   Authorizer
+}
+
+// Authorizer checks scopes granted to requests
+type Authorizer interface {
+	// Granted returns true if the request is granted the scope
+	Granted(r *http.Request, scope string) bool
 }
 
 type ErrCreateGameHandler string
@@ -59,10 +111,19 @@ func (e ErrCreateGameHandler) Error() string {
 	return "create game handler error: " + string(e)
 }
 
-func NewCreateGameHandler(logger logrus.FieldLogger, groupManager *connections.ConnectionGroupManager) http.Handler {
+// NewCreateGameHandler returns a handler which creates games. The game config
+// is the default configuration of new games and the backpressure is the
+// default policy for slow connections. The playground and the backpressure
+// can be overridden by clients for every game. If the authorizer is not nil
+// persistent games require the scope ScopePersistentGame
+func NewCreateGameHandler(logger logrus.FieldLogger, groupManager *connections.ConnectionGroupManager,
+	gameConfig game.Config, backpressure connections.Backpressure, authorizer Authorizer) http.Handler {
 	return &createGameHandler{
 		logger:       logger,
 		groupManager: groupManager,
+		gameConfig:   gameConfig,
+		backpressure: backpressure,
+		authorizer:   authorizer,
 	}
 }
 
@@ -126,14 +187,138 @@ func (h *createGameHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		enableWalls = defaultParamValueEnableWalls
 	}
 
+	private := false
+	if privateLabel := r.PostFormValue(postFieldPrivate); len(privateLabel) > 0 {
+		private, err = strconv.ParseBool(privateLabel)
+		if err != nil {
+			h.logger.Error(ErrCreateGameHandler(err.Error()))
+			h.writeResponseJSON(w, http.StatusBadRequest, &responseCreateGameHandlerError{
+				Code: http.StatusBadRequest,
+				Text: "invalid private flag",
+			})
+			return
+		}
+	}
+
+	persistent := false
+	if persistentLabel := r.PostFormValue(postFieldPersistent); len(persistentLabel) > 0 {
+		persistent, err = strconv.ParseBool(persistentLabel)
+		if err != nil {
+			h.logger.Error(ErrCreateGameHandler(err.Error()))
+			h.writeResponseJSON(w, http.StatusBadRequest, &responseCreateGameHandlerError{
+				Code: http.StatusBadRequest,
+				Text: "invalid persistent flag",
+			})
+			return
+		}
+	}
+	if persistent && h.authorizer != nil && !h.authorizer.Granted(r, ScopePersistentGame) {
+		h.logger.Warn(ErrCreateGameHandler("persistent game without scope"))
+		h.writeResponseJSON(w, http.StatusForbidden, &responseCreateGameHandlerError{
+			Code: http.StatusForbidden,
+			Text: "persistent games require scope " + ScopePersistentGame,
+		})
+		return
+	}
+
+	password := r.PostFormValue(postFieldPassword)
+	if len(password) > 0 && !private {
+		h.logger.Warn(ErrCreateGameHandler("password for public game"))
+		h.writeResponseJSON(w, http.StatusBadRequest, &responseCreateGameHandlerError{
+			Code: http.StatusBadRequest,
+			Text: "password is allowed only for private games",
+		})
+		return
+	}
+	if len(password) > maxGamePasswordLength {
+		h.logger.Warn(ErrCreateGameHandler("password is too long"))
+		h.writeResponseJSON(w, http.StatusBadRequest, &responseCreateGameHandlerError{
+			Code: http.StatusBadRequest,
+			Text: "password is too long",
+		})
+		return
+	}
+
+	backpressure := h.backpressure
+	if policyLabel := r.PostFormValue(postFieldBackpressure); len(policyLabel) > 0 {
+		backpressure.Policy, err = connections.ParseBackpressurePolicy(policyLabel)
+		if err != nil {
+			h.logger.Error(ErrCreateGameHandler(err.Error()))
+			h.writeResponseJSON(w, http.StatusBadRequest, &responseCreateGameHandlerError{
+				Code: http.StatusBadRequest,
+				Text: "invalid backpressure policy",
+			})
+			return
+		}
+	}
+	if maxDropsLabel := r.PostFormValue(postFieldBackpressureMaxDrops); len(maxDropsLabel) > 0 {
+		backpressure.MaxDrops, err = strconv.Atoi(maxDropsLabel)
+		if err != nil || backpressure.MaxDrops <= 0 {
+			h.logger.Warnln(ErrCreateGameHandler("invalid backpressure max drops"), maxDropsLabel)
+			h.writeResponseJSON(w, http.StatusBadRequest, &responseCreateGameHandlerError{
+				Code: http.StatusBadRequest,
+				Text: "invalid backpressure max drops",
+			})
+			return
+		}
+	}
+
+	backend := h.gameConfig.Playground
+	if backendLabel := r.PostFormValue(postFieldPlayground); len(backendLabel) > 0 {
+		backend, err = playground.ParseBackend(backendLabel)
+		if err != nil {
+			h.logger.Error(ErrCreateGameHandler(err.Error()))
+			h.writeResponseJSON(w, http.StatusBadRequest, &responseCreateGameHandlerError{
+				Code: http.StatusBadRequest,
+				Text: "invalid playground",
+			})
+			return
+		}
+	}
+
+	snakeConfig := h.gameConfig.Snake
+	if invulnerabilityLabel := r.PostFormValue(postFieldInvulnerability); len(invulnerabilityLabel) > 0 {
+		value, err := strconv.ParseUint(invulnerabilityLabel, 10, 32)
+		if err != nil || time.Duration(value)*time.Millisecond > maxInvulnerability {
+			h.logger.Warnln(ErrCreateGameHandler("invalid invulnerability"), invulnerabilityLabel)
+			h.writeResponseJSON(w, http.StatusBadRequest, &responseCreateGameHandlerError{
+				Code: http.StatusBadRequest,
+				Text: "invalid invulnerability",
+			})
+			return
+		}
+		snakeConfig.Invulnerability = time.Duration(value) * time.Millisecond
+	}
+
+	metadata, err := parseGameMetadata(r)
+	if err != nil {
+		h.logger.Warn(ErrCreateGameHandler(err.Error()))
+		h.writeResponseJSON(w, http.StatusBadRequest, &responseCreateGameHandlerError{
+			Code: http.StatusBadRequest,
+			Text: err.Error(),
+		})
+		return
+	}
+
 	h.logger.WithFields(logrus.Fields{
+		"name":             metadata.Name,
 		"width":            mapWidth,
 		"height":           mapHeight,
 		"connection_limit": connectionLimit,
 		"enable_walls":     enableWalls,
+		"private":          private,
+		"persistent":       persistent,
+		"backpressure":     backpressure.Policy,
+		"playground":       backend,
+		"invulnerability":  snakeConfig.Invulnerability,
 	}).Debug("create game group")
 
-	group, err := connections.NewConnectionGroup(h.logger, connectionLimit, uint8(mapWidth), uint8(mapHeight), enableWalls)
+	gameConfig := h.gameConfig
+	gameConfig.EnableWalls = enableWalls
+	gameConfig.Playground = backend
+	gameConfig.Snake = snakeConfig
+
+	group, err := connections.NewConnectionGroup(h.logger, connectionLimit, uint8(mapWidth), uint8(mapHeight), gameConfig)
 	if err != nil {
 		h.logger.Error(ErrCreateGameHandler(err.Error()))
 		h.writeResponseJSON(w, http.StatusInternalServerError, &responseCreateGameHandlerError{
@@ -143,6 +328,23 @@ func (h *createGameHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	group.SetMetadata(metadata)
+	group.SetPersistent(persistent)
+	group.SetBackpressure(backpressure)
+
+	var invite string
+	if private {
+		invite, err = group.MakePrivate(password)
+		if err != nil {
+			h.logger.Error(ErrCreateGameHandler(err.Error()))
+			h.writeResponseJSON(w, http.StatusInternalServerError, &responseCreateGameHandlerError{
+				Code: http.StatusInternalServerError,
+				Text: "cannot create game",
+			})
+			return
+		}
+	}
+
 	id, err := h.groupManager.Add(group)
 	if err != nil {
 		h.logger.Error(ErrCreateGameHandler(err.Error()))
@@ -158,6 +360,11 @@ func (h *createGameHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				Code: http.StatusServiceUnavailable,
 				Text: "connections limit reached",
 			})
+		case connections.ErrServerDraining:
+			h.writeResponseJSON(w, http.StatusServiceUnavailable, &responseCreateGameHandlerError{
+				Code: http.StatusServiceUnavailable,
+				Text: "server is shutting down",
+			})
 		default:
 			h.writeResponseJSON(w, http.StatusInternalServerError, &responseCreateGameHandlerError{
 				Code: http.StatusInternalServerError,
@@ -179,6 +386,19 @@ func (h *createGameHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		Width:  uint8(mapWidth),
 		Height: uint8(mapHeight),
 		Rate:   0,
+
+		Private: private,
+		Invite:  invite,
+
+		Persistent: persistent,
+
+		Backpressure: backpressure,
+
+		Playground: backend,
+
+		Invulnerability: milliseconds(snakeConfig.Invulnerability),
+
+		responseGameMetadata: newResponseGameMetadata(group.GetMetadata()),
 	})
 }
 
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -13,7 +14,11 @@ import (
 	"github.com/urfave/negroni"
 
 	"github.com/ivan1993spb/snake-server/connections"
+	"github.com/ivan1993spb/snake-server/game"
 	"github.com/ivan1993spb/snake-server/middlewares"
+	"github.com/ivan1993spb/snake-server/playground"
+
+	"time"
 )
 
 func Test_CreateGameHandler_ServeHTTP_CreatesGroup(t *testing.T) {
@@ -25,7 +30,7 @@ func Test_CreateGameHandler_ServeHTTP_CreatesGroup(t *testing.T) {
 	require.Nil(t, err)
 	require.NotNil(t, groupManager)
 
-	handler := NewCreateGameHandler(logger, groupManager)
+	handler := NewCreateGameHandler(logger, groupManager, game.DefaultConfig(), connections.DefaultBackpressure(), nil)
 
 	r := mux.NewRouter()
 	r.Path(URLRouteCreateGame).Methods(MethodCreateGame).Handler(handler)
@@ -55,3 +60,292 @@ func Test_CreateGameHandler_ServeHTTP_CreatesGroup(t *testing.T) {
 
 	hook.Reset()
 }
+
+func Test_CreateGameHandler_ServeHTTP_CreatesPrivateGroup(t *testing.T) {
+	const groupsLimit = 5
+	const connsLimit = 10
+
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	groupManager, err := connections.NewConnectionGroupManager(logger, groupsLimit, connsLimit)
+	require.Nil(t, err)
+
+	r := mux.NewRouter()
+	r.Path(URLRouteCreateGame).Methods(MethodCreateGame).Handler(NewCreateGameHandler(logger, groupManager, game.DefaultConfig(), connections.DefaultBackpressure(), nil))
+
+	data := &url.Values{}
+	data.Add(postFieldConnectionLimit, "10")
+	data.Add(postFieldMapWidth, "100")
+	data.Add(postFieldMapHeight, "100")
+	data.Add(postFieldPrivate, "true")
+	data.Add(postFieldPassword, "secret")
+
+	request := httptest.NewRequest(MethodCreateGame, URLRouteCreateGame, strings.NewReader(data.Encode()))
+	request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	recorder := httptest.NewRecorder()
+
+	r.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	var response responseCreateGameHandler
+	require.Nil(t, json.NewDecoder(recorder.Body).Decode(&response))
+	require.True(t, response.Private)
+	require.NotEmpty(t, response.Invite)
+
+	group, err := groupManager.Get(response.ID)
+	require.Nil(t, err)
+	defer groupManager.Delete(group)
+
+	require.True(t, group.IsPrivate())
+	require.True(t, group.Allowed(response.Invite, ""))
+	require.True(t, group.Allowed("", "secret"))
+	require.False(t, group.Allowed("", ""))
+	require.False(t, group.Allowed("invalid", "invalid"))
+}
+
+func Test_CreateGameHandler_ServeHTTP_RejectsPasswordForPublicGroup(t *testing.T) {
+	const groupsLimit = 5
+	const connsLimit = 10
+
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	groupManager, err := connections.NewConnectionGroupManager(logger, groupsLimit, connsLimit)
+	require.Nil(t, err)
+
+	r := mux.NewRouter()
+	r.Path(URLRouteCreateGame).Methods(MethodCreateGame).Handler(NewCreateGameHandler(logger, groupManager, game.DefaultConfig(), connections.DefaultBackpressure(), nil))
+
+	data := &url.Values{}
+	data.Add(postFieldConnectionLimit, "10")
+	data.Add(postFieldMapWidth, "100")
+	data.Add(postFieldMapHeight, "100")
+	data.Add(postFieldPassword, "secret")
+
+	request := httptest.NewRequest(MethodCreateGame, URLRouteCreateGame, strings.NewReader(data.Encode()))
+	request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	recorder := httptest.NewRecorder()
+
+	r.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	require.Zero(t, groupManager.GroupCount())
+}
+
+func Test_CreateGameHandler_ServeHTTP_SetsBackpressure(t *testing.T) {
+	const groupsLimit = 5
+	const connsLimit = 10
+
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	groupManager, err := connections.NewConnectionGroupManager(logger, groupsLimit, connsLimit)
+	require.Nil(t, err)
+
+	r := mux.NewRouter()
+	r.Path(URLRouteCreateGame).Methods(MethodCreateGame).Handler(NewCreateGameHandler(logger, groupManager, game.DefaultConfig(), connections.DefaultBackpressure(), nil))
+
+	create := func(policy, maxDrops string) *httptest.ResponseRecorder {
+		data := &url.Values{}
+		data.Add(postFieldConnectionLimit, "2")
+		data.Add(postFieldMapWidth, "100")
+		data.Add(postFieldMapHeight, "100")
+		data.Add(postFieldBackpressure, policy)
+		data.Add(postFieldBackpressureMaxDrops, maxDrops)
+
+		request := httptest.NewRequest(MethodCreateGame, URLRouteCreateGame, strings.NewReader(data.Encode()))
+		request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	require.Equal(t, http.StatusBadRequest, create("invalid", "").Code)
+	require.Equal(t, http.StatusBadRequest, create("disconnect", "0").Code)
+	require.Zero(t, groupManager.GroupCount())
+
+	recorder := create("disconnect", "20")
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	var response responseCreateGameHandler
+	require.Nil(t, json.NewDecoder(recorder.Body).Decode(&response))
+
+	expected := connections.Backpressure{
+		Policy:   connections.BackpressurePolicyDisconnect,
+		MaxDrops: 20,
+	}
+	require.Equal(t, expected, response.Backpressure)
+
+	group, err := groupManager.Get(response.ID)
+	require.Nil(t, err)
+	defer groupManager.Delete(group)
+
+	require.Equal(t, expected, group.GetBackpressure())
+}
+
+func Test_CreateGameHandler_ServeHTTP_SetsPlayground(t *testing.T) {
+	const groupsLimit = 5
+	const connsLimit = 10
+
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	groupManager, err := connections.NewConnectionGroupManager(logger, groupsLimit, connsLimit)
+	require.Nil(t, err)
+
+	r := mux.NewRouter()
+	r.Path(URLRouteCreateGame).Methods(MethodCreateGame).Handler(NewCreateGameHandler(logger, groupManager, game.DefaultConfig(), connections.DefaultBackpressure(), nil))
+
+	create := func(backend string) *httptest.ResponseRecorder {
+		data := &url.Values{}
+		data.Add(postFieldConnectionLimit, "2")
+		data.Add(postFieldMapWidth, "100")
+		data.Add(postFieldMapHeight, "100")
+		if len(backend) > 0 {
+			data.Add(postFieldPlayground, backend)
+		}
+
+		request := httptest.NewRequest(MethodCreateGame, URLRouteCreateGame, strings.NewReader(data.Encode()))
+		request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	require.Equal(t, http.StatusBadRequest, create("invalid").Code)
+	require.Zero(t, groupManager.GroupCount())
+
+	for backend, expected := range map[string]playground.Backend{
+		"":         playground.BackendCMap,
+		"lockfree": playground.BackendLockFree,
+	} {
+		recorder := create(backend)
+		require.Equal(t, http.StatusCreated, recorder.Code)
+
+		var response responseCreateGameHandler
+		require.Nil(t, json.NewDecoder(recorder.Body).Decode(&response))
+		require.Equal(t, expected, response.Playground)
+
+		group, err := groupManager.Get(response.ID)
+		require.Nil(t, err)
+		require.Equal(t, expected, group.GetPlaygroundBackend())
+		groupManager.Delete(group)
+	}
+}
+
+func Test_CreateGameHandler_ServeHTTP_SetsInvulnerability(t *testing.T) {
+	const groupsLimit = 5
+	const connsLimit = 10
+
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	groupManager, err := connections.NewConnectionGroupManager(logger, groupsLimit, connsLimit)
+	require.Nil(t, err)
+
+	r := mux.NewRouter()
+	r.Path(URLRouteCreateGame).Methods(MethodCreateGame).Handler(NewCreateGameHandler(logger, groupManager, game.DefaultConfig(), connections.DefaultBackpressure(), nil))
+
+	create := func(invulnerability string) *httptest.ResponseRecorder {
+		data := &url.Values{}
+		data.Add(postFieldConnectionLimit, "2")
+		data.Add(postFieldMapWidth, "100")
+		data.Add(postFieldMapHeight, "100")
+		if len(invulnerability) > 0 {
+			data.Add(postFieldInvulnerability, invulnerability)
+		}
+
+		request := httptest.NewRequest(MethodCreateGame, URLRouteCreateGame, strings.NewReader(data.Encode()))
+		request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	for _, invulnerability := range []string{"invalid", "-1", "60001"} {
+		require.Equal(t, http.StatusBadRequest, create(invulnerability).Code, invulnerability)
+	}
+	require.Zero(t, groupManager.GroupCount())
+
+	for invulnerability, expected := range map[string]time.Duration{
+		"":      game.DefaultConfig().Snake.Invulnerability,
+		"0":     0,
+		"500":   time.Millisecond * 500,
+		"60000": time.Minute,
+	} {
+		recorder := create(invulnerability)
+		require.Equal(t, http.StatusCreated, recorder.Code)
+
+		var response responseCreateGameHandler
+		require.Nil(t, json.NewDecoder(recorder.Body).Decode(&response))
+		require.Equal(t, int64(expected/time.Millisecond), response.Invulnerability)
+
+		group, err := groupManager.Get(response.ID)
+		require.Nil(t, err)
+		require.Equal(t, expected, group.GetInvulnerability())
+		groupManager.Delete(group)
+	}
+}
+
+type testAuthorizer map[string]bool
+
+func (a testAuthorizer) Granted(r *http.Request, scope string) bool {
+	return a[scope]
+}
+
+func Test_CreateGameHandler_ServeHTTP_RequiresScopeForPersistentGroup(t *testing.T) {
+	const groupsLimit = 5
+	const connsLimit = 10
+
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	groupManager, err := connections.NewConnectionGroupManager(logger, groupsLimit, connsLimit)
+	require.Nil(t, err)
+
+	create := func(authorizer Authorizer, persistent string) *httptest.ResponseRecorder {
+		data := &url.Values{}
+		data.Add(postFieldConnectionLimit, "2")
+		data.Add(postFieldMapWidth, "100")
+		data.Add(postFieldMapHeight, "100")
+		data.Add(postFieldPersistent, persistent)
+
+		request := httptest.NewRequest(MethodCreateGame, URLRouteCreateGame, strings.NewReader(data.Encode()))
+		request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+		recorder := httptest.NewRecorder()
+		NewCreateGameHandler(logger, groupManager, game.DefaultConfig(), connections.DefaultBackpressure(),
+			authorizer).ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	require.Equal(t, http.StatusForbidden, create(testAuthorizer{}, "true").Code)
+	require.Zero(t, groupManager.GroupCount())
+
+	tests := []struct {
+		authorizer Authorizer
+		persistent string
+	}{
+		{testAuthorizer{}, "false"},
+		{testAuthorizer{ScopePersistentGame: true}, "true"},
+		{nil, "true"},
+	}
+
+	for _, test := range tests {
+		recorder := create(test.authorizer, test.persistent)
+		require.Equal(t, http.StatusCreated, recorder.Code)
+
+		var response responseCreateGameHandler
+		require.Nil(t, json.NewDecoder(recorder.Body).Decode(&response))
+		require.Equal(t, test.persistent == "true", response.Persistent)
+
+		group, err := groupManager.Get(response.ID)
+		require.Nil(t, err)
+		require.Nil(t, groupManager.Delete(group))
+	}
+}
@@ -15,6 +15,8 @@ const URLRouteDeleteGameByID = "/games/{id}"
 
 const MethodDeleteGame = http.MethodDelete
 
+const ScopeDeleteGame = "games:delete"
+
 type responseDeleteGameHandler struct {
 	ID int `json:"id"`
 }
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ivan1993spb/snake-server/connections"
+)
+
+const URLRouteEventStreamCommandBySession = "/sessions/{session}"
+
+const MethodEventStreamCommand = http.MethodPost
+
+type responseEventStreamCommandHandlerError struct {
+	Code int    `json:"code"`
+	Text string `json:"text"`
+}
+
+type eventStreamCommandHandler struct {
+	logger   logrus.FieldLogger
+	registry *connections.EventStreamRegistry
+}
+
+type ErrEventStreamCommandHandler string
+
+func (e ErrEventStreamCommandHandler) Error() string {
+	return "event stream command handler error: " + string(e)
+}
+
+// NewEventStreamCommandHandler returns a handler which passes input messages
+// of event stream clients to their sessions. The request body is an input
+// message in the same format as web-socket input messages
+func NewEventStreamCommandHandler(logger logrus.FieldLogger, registry *connections.EventStreamRegistry) http.Handler {
+	return &eventStreamCommandHandler{
+		logger:   logger,
+		registry: registry,
+	}
+}
+
+func (h *eventStreamCommandHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	stream, err := h.registry.Get(mux.Vars(r)["session"])
+	if err != nil {
+		h.logger.Warn(ErrEventStreamCommandHandler(err.Error()))
+		h.writeResponseJSON(w, http.StatusNotFound, &responseEventStreamCommandHandlerError{
+			Code: http.StatusNotFound,
+			Text: "session not found",
+		})
+		return
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, wsReadMessageLimit+1))
+	if err != nil {
+		h.logger.Error(ErrEventStreamCommandHandler(err.Error()))
+		h.writeResponseJSON(w, http.StatusBadRequest, &responseEventStreamCommandHandlerError{
+			Code: http.StatusBadRequest,
+			Text: "cannot read message",
+		})
+		return
+	}
+	if len(data) == 0 || len(data) > wsReadMessageLimit {
+		h.logger.Warn(ErrEventStreamCommandHandler("invalid message size"))
+		h.writeResponseJSON(w, http.StatusBadRequest, &responseEventStreamCommandHandlerError{
+			Code: http.StatusBadRequest,
+			Text: "invalid message size",
+		})
+		return
+	}
+
+	if err := stream.Push(data); err != nil {
+		h.logger.Warn(ErrEventStreamCommandHandler(err.Error()))
+
+		switch err {
+		case connections.ErrEventStreamClosed:
+			h.writeResponseJSON(w, http.StatusNotFound, &responseEventStreamCommandHandlerError{
+				Code: http.StatusNotFound,
+				Text: "session not found",
+			})
+		case connections.ErrEventStreamInputOverflow:
+			h.writeResponseJSON(w, http.StatusServiceUnavailable, &responseEventStreamCommandHandlerError{
+				Code: http.StatusServiceUnavailable,
+				Text: "too many messages",
+			})
+		default:
+			h.writeResponseJSON(w, http.StatusInternalServerError, &responseEventStreamCommandHandlerError{
+				Code: http.StatusInternalServerError,
+				Text: "unknown error",
+			})
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *eventStreamCommandHandler) writeResponseJSON(w http.ResponseWriter, statusCode int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Error(ErrEventStreamCommandHandler(err.Error()))
+	}
+}
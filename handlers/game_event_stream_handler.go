@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ivan1993spb/snake-server/connections"
+)
+
+const URLRouteGameEventStreamByID = "/games/{id}"
+
+const MethodGameEventStream = http.MethodGet
+
+type responseGameEventStreamHandlerError struct {
+	Code int    `json:"code"`
+	Text string `json:"text"`
+}
+
+type gameEventStreamHandler struct {
+	logger       logrus.FieldLogger
+	groupManager *connections.ConnectionGroupManager
+	registry     *connections.EventStreamRegistry
+}
+
+type ErrGameEventStreamHandler string
+
+func (e ErrGameEventStreamHandler) Error() string {
+	return "game event stream handler error: " + string(e)
+}
+
+// NewGameEventStreamHandler returns a handler which streams a game with
+// server-sent events. It is an alternative to the web-socket handler for
+// clients which cannot use web-sockets
+func NewGameEventStreamHandler(logger logrus.FieldLogger, groupManager *connections.ConnectionGroupManager,
+	registry *connections.EventStreamRegistry) http.Handler {
+	return &gameEventStreamHandler{
+		logger:       logger,
+		groupManager: groupManager,
+		registry:     registry,
+	}
+}
+
+func (h *gameEventStreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("game event stream handler start")
+	defer h.logger.Info("game event stream handler end")
+
+	vars := mux.Vars(r)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.logger.Error(ErrGameEventStreamHandler(err.Error()))
+		h.writeResponseJSON(w, http.StatusBadRequest, &responseGameEventStreamHandlerError{
+			Code: http.StatusBadRequest,
+			Text: "invalid game id",
+		})
+		return
+	}
+
+	playerName := r.URL.Query().Get(getFieldPlayerName)
+	if !validPlayerName(playerName) {
+		h.logger.Warn(ErrGameEventStreamHandler("invalid player name"))
+		h.writeResponseJSON(w, http.StatusBadRequest, &responseGameEventStreamHandlerError{
+			Code: http.StatusBadRequest,
+			Text: "invalid player name",
+		})
+		return
+	}
+
+	group, err := h.groupManager.Get(id)
+	if err != nil {
+		h.logger.Error(ErrGameEventStreamHandler(err.Error()))
+
+		switch err {
+		case connections.ErrNotFoundGroup:
+			h.writeResponseJSON(w, http.StatusNotFound, &responseGameEventStreamHandlerError{
+				Code: http.StatusNotFound,
+				Text: "game not found",
+			})
+		default:
+			h.writeResponseJSON(w, http.StatusInternalServerError, &responseGameEventStreamHandlerError{
+				Code: http.StatusInternalServerError,
+				Text: "unknown error",
+			})
+		}
+		return
+	}
+
+	if !group.Allowed(r.URL.Query().Get(getFieldGameInvite), r.URL.Query().Get(getFieldGamePassword)) {
+		h.logger.WithField("game", id).Warn(ErrGameEventStreamHandler("access denied to private game"))
+		h.writeResponseJSON(w, http.StatusForbidden, &responseGameEventStreamHandlerError{
+			Code: http.StatusForbidden,
+			Text: "invalid invite token or password",
+		})
+		return
+	}
+
+	if group.IsDraining() {
+		h.logger.Warn(ErrGameEventStreamHandler("group is draining"))
+		h.writeResponseJSON(w, http.StatusServiceUnavailable, &responseGameEventStreamHandlerError{
+			Code: http.StatusServiceUnavailable,
+			Text: "server is shutting down",
+		})
+		return
+	}
+
+	if group.IsFull() {
+		h.logger.Warn(ErrGameEventStreamHandler("group is full"))
+		h.writeResponseJSON(w, http.StatusServiceUnavailable, &responseGameEventStreamHandlerError{
+			Code: http.StatusServiceUnavailable,
+			Text: "group is full",
+		})
+		return
+	}
+
+	stream, err := connections.NewEventStream(w, r)
+	if err != nil {
+		h.logger.Error(ErrGameEventStreamHandler(err.Error()))
+		h.writeResponseJSON(w, http.StatusInternalServerError, &responseGameEventStreamHandlerError{
+			Code: http.StatusInternalServerError,
+			Text: "streaming is not supported",
+		})
+		return
+	}
+
+	h.registry.Add(stream)
+	defer h.registry.Delete(stream)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Disable response buffering in nginx
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err := stream.WriteSession(); err != nil {
+		h.logger.Error(ErrGameEventStreamHandler(err.Error()))
+		return
+	}
+
+	go func() {
+		select {
+		case <-r.Context().Done():
+			stream.Stop()
+		case <-stream.Done():
+		}
+	}()
+
+	// The session is a secret of the player, so it is not logged
+	h.logger.Info("start connection worker")
+
+	if err := group.Handle(connections.NewEventStreamConnectionWorker(stream, h.logger, playerName)); err != nil {
+		h.logger.Error(ErrGameEventStreamHandler(err.Error()))
+		// The worker has not been started, so notify the client here
+		if err := stream.Close(websocket.CloseTryAgainLater, "cannot join the game"); err != nil {
+			h.logger.Error(ErrGameEventStreamHandler(err.Error()))
+		}
+		return
+	}
+}
+
+func (h *gameEventStreamHandler) writeResponseJSON(w http.ResponseWriter, statusCode int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Error(ErrGameEventStreamHandler(err.Error()))
+	}
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/negroni"
+
+	"github.com/ivan1993spb/snake-server/connections"
+	"github.com/ivan1993spb/snake-server/game"
+	"github.com/ivan1993spb/snake-server/middlewares"
+)
+
+// readEvent reads the next server-sent event from the reader
+func readEvent(reader *bufio.Reader) (event string, data string, err error) {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return "", "", err
+		}
+
+		line = strings.TrimRight(line, "\n")
+
+		switch {
+		case line == "" && data != "":
+			return event, data, nil
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func Test_GameEventStreamHandler_ServeHTTP_StreamsGame(t *testing.T) {
+	const groupsLimit = 5
+	const connsLimit = 10
+
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	groupManager, err := connections.NewConnectionGroupManager(logger, groupsLimit, connsLimit)
+	require.Nil(t, err)
+
+	group, err := connections.NewConnectionGroup(logger, 2, 20, 20, game.DefaultConfig())
+	require.Nil(t, err)
+	id, err := groupManager.Add(group)
+	require.Nil(t, err)
+
+	group.Start()
+	defer group.Stop()
+
+	registry := connections.NewEventStreamRegistry()
+
+	r := mux.NewRouter()
+	events := r.PathPrefix("/events").Subrouter()
+	events.Path(URLRouteGameEventStreamByID).Methods(MethodGameEventStream).Handler(NewGameEventStreamHandler(logger, groupManager, registry))
+	events.Path(URLRouteEventStreamCommandBySession).Methods(MethodEventStreamCommand).Handler(NewEventStreamCommandHandler(logger, registry))
+
+	n := negroni.New(middlewares.NewRecovery(logger), middlewares.NewLogger(logger, "events"))
+	n.UseHandler(r)
+
+	server := httptest.NewServer(n)
+	defer server.Close()
+
+	client := &http.Client{
+		Timeout: time.Second * 5,
+	}
+
+	response, err := client.Get(server.URL + "/events/games/" + strconv.Itoa(id))
+	require.Nil(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(response.Body)
+
+	event, data, err := readEvent(reader)
+	require.Nil(t, err)
+	require.Equal(t, "session", event)
+
+	var session struct {
+		Session string `json:"session"`
+	}
+	require.Nil(t, json.Unmarshal([]byte(data), &session))
+	require.NotEmpty(t, session.Session)
+
+	// The player receives the map size as the first output message
+	event, data, err = readEvent(reader)
+	require.Nil(t, err)
+	require.Empty(t, event)
+
+	var message struct {
+		Type string `json:"type"`
+	}
+	require.Nil(t, json.Unmarshal([]byte(data), &message))
+	require.NotEmpty(t, message.Type)
+
+	commandResponse, err := client.Post(server.URL+"/events/sessions/"+session.Session, "application/json",
+		strings.NewReader(`{"type":"snake","payload":"north"}`))
+	require.Nil(t, err)
+	commandResponse.Body.Close()
+	require.Equal(t, http.StatusNoContent, commandResponse.StatusCode)
+
+	commandResponse, err = client.Post(server.URL+"/events/sessions/invalid", "application/json",
+		strings.NewReader(`{"type":"snake","payload":"north"}`))
+	require.Nil(t, err)
+	commandResponse.Body.Close()
+	require.Equal(t, http.StatusNotFound, commandResponse.StatusCode)
+
+	group.Disconnect(1001, "server is shutting down")
+
+	for {
+		event, data, err = readEvent(reader)
+		require.Nil(t, err)
+		if event == "close" {
+			break
+		}
+	}
+	require.JSONEq(t, `{"code":1001,"reason":"server is shutting down"}`, data)
+}
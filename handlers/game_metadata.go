@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ivan1993spb/snake-server/connections"
+)
+
+const (
+	postFieldGameName        = "name"
+	postFieldGameDescription = "description"
+	postFieldGameTags        = "tags"
+	postFieldGameCreator     = "creator"
+	postFieldGameRegion      = "region"
+)
+
+const (
+	maxGameNameLength        = 64
+	maxGameDescriptionLength = 256
+	maxGameCreatorLength     = 32
+	maxGameRegionLength      = 32
+	maxGameTagLength         = 32
+	maxGameTagsCount         = 10
+)
+
+// tagsSeparator separates tags passed in a single form value
+const tagsSeparator = ","
+
+var gameTagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+type responseGameMetadata struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Tags        []string  `json:"tags"`
+	Creator     string    `json:"creator"`
+	CreatedAt   time.Time `json:"created_at"`
+	Region      string    `json:"region"`
+}
+
+func newResponseGameMetadata(metadata connections.Metadata) responseGameMetadata {
+	tags := metadata.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return responseGameMetadata{
+		Name:        metadata.Name,
+		Description: metadata.Description,
+		Tags:        tags,
+		Creator:     metadata.Creator,
+		CreatedAt:   metadata.CreatedAt,
+		Region:      metadata.Region,
+	}
+}
+
+type errGameMetadata string
+
+func (e errGameMetadata) Error() string {
+	return string(e)
+}
+
+// parseGameMetadata reads and validates game metadata from the request's post
+// form. Tags can be passed as several values or separated by commas
+func parseGameMetadata(r *http.Request) (connections.Metadata, error) {
+	if err := r.ParseForm(); err != nil {
+		return connections.Metadata{}, errGameMetadata("invalid form")
+	}
+
+	metadata := connections.Metadata{
+		Name:        strings.TrimSpace(r.PostFormValue(postFieldGameName)),
+		Description: strings.TrimSpace(r.PostFormValue(postFieldGameDescription)),
+		Creator:     strings.TrimSpace(r.PostFormValue(postFieldGameCreator)),
+		Region:      strings.TrimSpace(r.PostFormValue(postFieldGameRegion)),
+	}
+
+	if err := validateMetadataText(postFieldGameName, metadata.Name, maxGameNameLength); err != nil {
+		return connections.Metadata{}, err
+	}
+	if err := validateMetadataText(postFieldGameDescription, metadata.Description, maxGameDescriptionLength); err != nil {
+		return connections.Metadata{}, err
+	}
+	if err := validateMetadataText(postFieldGameCreator, metadata.Creator, maxGameCreatorLength); err != nil {
+		return connections.Metadata{}, err
+	}
+	if err := validateMetadataText(postFieldGameRegion, metadata.Region, maxGameRegionLength); err != nil {
+		return connections.Metadata{}, err
+	}
+
+	tags, err := parseGameTags(r.PostForm[postFieldGameTags])
+	if err != nil {
+		return connections.Metadata{}, err
+	}
+	metadata.Tags = tags
+
+	return metadata, nil
+}
+
+func validateMetadataText(field, value string, maxLength int) error {
+	if !utf8.ValidString(value) {
+		return errGameMetadata(fmt.Sprintf("invalid %s", field))
+	}
+	if utf8.RuneCountInString(value) > maxLength {
+		return errGameMetadata(fmt.Sprintf("%s is longer than %d characters", field, maxLength))
+	}
+	for _, r := range value {
+		if !unicode.IsPrint(r) {
+			return errGameMetadata(fmt.Sprintf("invalid %s", field))
+		}
+	}
+	return nil
+}
+
+func parseGameTags(values []string) ([]string, error) {
+	tags := make([]string, 0)
+	seen := make(map[string]struct{})
+
+	for _, value := range values {
+		for _, tag := range strings.Split(value, tagsSeparator) {
+			tag = strings.ToLower(strings.TrimSpace(tag))
+			if len(tag) == 0 {
+				continue
+			}
+			if len(tag) > maxGameTagLength || !gameTagPattern.MatchString(tag) {
+				return nil, errGameMetadata("invalid tag: " + tag)
+			}
+			if _, ok := seen[tag]; ok {
+				continue
+			}
+			seen[tag] = struct{}{}
+			tags = append(tags, tag)
+		}
+	}
+
+	if len(tags) > maxGameTagsCount {
+		return nil, errGameMetadata(fmt.Sprintf("too many tags, the limit is %d", maxGameTagsCount))
+	}
+
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newGameMetadataRequest(data url.Values) *http.Request {
+	request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(data.Encode()))
+	request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	return request
+}
+
+func Test_parseGameMetadata(t *testing.T) {
+	metadata, err := parseGameMetadata(newGameMetadataRequest(url.Values{
+		postFieldGameName:        {" Beginner room 30x30 "},
+		postFieldGameDescription: {"Slow and safe"},
+		postFieldGameTags:        {"Beginner, small", "small", "eu-west"},
+		postFieldGameCreator:     {"alice"},
+		postFieldGameRegion:      {"eu"},
+	}))
+	require.Nil(t, err)
+	require.Equal(t, "Beginner room 30x30", metadata.Name)
+	require.Equal(t, "Slow and safe", metadata.Description)
+	require.Equal(t, []string{"beginner", "small", "eu-west"}, metadata.Tags)
+	require.Equal(t, "alice", metadata.Creator)
+	require.Equal(t, "eu", metadata.Region)
+
+	metadata, err = parseGameMetadata(newGameMetadataRequest(url.Values{}))
+	require.Nil(t, err)
+	require.Empty(t, metadata.Name)
+	require.Nil(t, metadata.Tags)
+}
+
+func Test_parseGameMetadata_ReturnsErrorWithInvalidValues(t *testing.T) {
+	tests := []url.Values{
+		{postFieldGameName: {strings.Repeat("a", maxGameNameLength+1)}},
+		{postFieldGameName: {"line\nbreak"}},
+		{postFieldGameDescription: {strings.Repeat("a", maxGameDescriptionLength+1)}},
+		{postFieldGameCreator: {strings.Repeat("a", maxGameCreatorLength+1)}},
+		{postFieldGameRegion: {strings.Repeat("a", maxGameRegionLength+1)}},
+		{postFieldGameTags: {"invalid tag"}},
+		{postFieldGameTags: {"-tag"}},
+		{postFieldGameTags: {strings.Repeat("a", maxGameTagLength+1)}},
+		{postFieldGameTags: {"a,b,c,d,e,f,g,h,i,j,k"}},
+	}
+
+	for i, data := range tests {
+		_, err := parseGameMetadata(newGameMetadataRequest(data))
+		require.NotNil(t, err, "case number %d", i)
+	}
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"compress/flate"
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
@@ -16,12 +19,26 @@ const URLRouteGameWebSocketByID = "/games/{id}"
 
 const MethodGame = http.MethodGet
 
+const getFieldPlayerName = "name"
+
+const (
+	getFieldGameInvite   = "invite"
+	getFieldGamePassword = "password"
+)
+
+const maxPlayerNameLength = 32
+
 const wsReadMessageLimit = 128
 
 const wsReadBufferSize = 2048
 
 const wsWriteBufferSize = 20480
 
+const (
+	minCompressionLevel = flate.HuffmanOnly
+	maxCompressionLevel = flate.BestCompression
+)
+
 const messageUpgradeConnectionError = "web-socket upgrade connection error"
 
 type responseGameWebSocketHandlerError struct {
@@ -33,6 +50,9 @@ type gameWebSocketHandler struct {
 	logger       logrus.FieldLogger
 	groupManager *connections.ConnectionGroupManager
 	upgrader     *websocket.Upgrader
+
+	enableCompression bool
+	compressionLevel  int
 }
 
 type ErrGameWebSocketHandler string
@@ -41,11 +61,21 @@ func (e ErrGameWebSocketHandler) Error() string {
 	return "game web-socket handler error: " + string(e)
 }
 
-func NewGameWebSocketHandler(logger logrus.FieldLogger, groupManager *connections.ConnectionGroupManager) http.Handler {
+// ValidCompressionLevel returns true if the web-socket compression level is
+// supported: from -2 (huffman only) to 9 (best compression)
+func ValidCompressionLevel(level int) bool {
+	return level >= minCompressionLevel && level <= maxCompressionLevel
+}
+
+// NewGameWebSocketHandler returns a web-socket game handler. If compression is
+// enabled, permessage-deflate is negotiated with clients which support it and
+// messages are compressed with the given level
+func NewGameWebSocketHandler(logger logrus.FieldLogger, groupManager *connections.ConnectionGroupManager,
+	enableCompression bool, compressionLevel int) http.Handler {
 	upgrader := &websocket.Upgrader{
 		ReadBufferSize:    wsReadBufferSize,
 		WriteBufferSize:   wsWriteBufferSize,
-		EnableCompression: false,
+		EnableCompression: enableCompression,
 		CheckOrigin: func(r *http.Request) bool {
 			return true
 		},
@@ -55,6 +85,9 @@ func NewGameWebSocketHandler(logger logrus.FieldLogger, groupManager *connection
 		logger:       logger,
 		groupManager: groupManager,
 		upgrader:     upgrader,
+
+		enableCompression: enableCompression,
+		compressionLevel:  compressionLevel,
 	}
 
 	upgrader.Error = handler.errorUpgradeConnection
@@ -62,6 +95,22 @@ func NewGameWebSocketHandler(logger logrus.FieldLogger, groupManager *connection
 	return handler
 }
 
+// validPlayerName returns true if the name is empty or it contains only
+// printable characters and its length doesn't exceed the limit
+func validPlayerName(name string) bool {
+	if !utf8.ValidString(name) || utf8.RuneCountInString(name) > maxPlayerNameLength {
+		return false
+	}
+
+	for _, r := range name {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (h *gameWebSocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.logger.Info("game handler start")
 	defer h.logger.Info("game handler end")
@@ -78,6 +127,16 @@ func (h *gameWebSocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	playerName := r.URL.Query().Get(getFieldPlayerName)
+	if !validPlayerName(playerName) {
+		h.logger.Warn(ErrGameWebSocketHandler("invalid player name"))
+		h.writeResponseJSON(w, http.StatusBadRequest, &responseGameWebSocketHandlerError{
+			Code: http.StatusBadRequest,
+			Text: "invalid player name",
+		})
+		return
+	}
+
 	h.logger.WithField("game", id).Info("try to connect to game group")
 
 	group, err := h.groupManager.Get(id)
@@ -99,6 +158,24 @@ func (h *gameWebSocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if !group.Allowed(r.URL.Query().Get(getFieldGameInvite), r.URL.Query().Get(getFieldGamePassword)) {
+		h.logger.WithField("game", id).Warn(ErrGameWebSocketHandler("access denied to private game"))
+		h.writeResponseJSON(w, http.StatusForbidden, &responseGameWebSocketHandlerError{
+			Code: http.StatusForbidden,
+			Text: "invalid invite token or password",
+		})
+		return
+	}
+
+	if group.IsDraining() {
+		h.logger.Warn(ErrGameWebSocketHandler("group is draining"))
+		h.writeResponseJSON(w, http.StatusServiceUnavailable, &responseGameWebSocketHandlerError{
+			Code: http.StatusServiceUnavailable,
+			Text: "server is shutting down",
+		})
+		return
+	}
+
 	if group.IsFull() {
 		h.logger.Warn(ErrGameWebSocketHandler("group is full"))
 		h.writeResponseJSON(w, http.StatusServiceUnavailable, &responseGameWebSocketHandlerError{
@@ -119,9 +196,16 @@ func (h *gameWebSocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 
 	conn.SetReadLimit(wsReadMessageLimit)
 
+	if h.enableCompression {
+		// Compression is used only if it has been negotiated with the client
+		if err := conn.SetCompressionLevel(h.compressionLevel); err != nil {
+			h.logger.Error(ErrGameWebSocketHandler(err.Error()))
+		}
+	}
+
 	h.logger.Info("start connection worker")
 
-	if err := group.Handle(connections.NewConnectionWorker(conn, h.logger)); err != nil {
+	if err := group.Handle(connections.NewConnectionWorker(conn, h.logger, playerName)); err != nil {
 		h.logger.Error(ErrGameWebSocketHandler(err.Error()))
 		return
 	}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ivan1993spb/snake-server/connections"
+	"github.com/ivan1993spb/snake-server/game"
+)
+
+func Test_ValidCompressionLevel(t *testing.T) {
+	require.True(t, ValidCompressionLevel(-2))
+	require.True(t, ValidCompressionLevel(1))
+	require.True(t, ValidCompressionLevel(9))
+	require.False(t, ValidCompressionLevel(-3))
+	require.False(t, ValidCompressionLevel(10))
+}
+
+func Test_GameWebSocketHandler_ServeHTTP_NegotiatesCompression(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	groupManager, err := connections.NewConnectionGroupManager(logger, 10, 100)
+	require.Nil(t, err)
+
+	group, err := connections.NewConnectionGroup(logger, 5, 20, 20, game.DefaultConfig())
+	require.Nil(t, err)
+	id, err := groupManager.Add(group)
+	require.Nil(t, err)
+
+	group.Start()
+	defer group.Stop()
+
+	router := mux.NewRouter()
+	router.Path(URLRouteGameWebSocketByID).Handler(NewGameWebSocketHandler(logger, groupManager, true, 1))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	dialer := &websocket.Dialer{
+		EnableCompression: true,
+	}
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/games/" + strconv.Itoa(id)
+
+	conn, response, err := dialer.Dial(url, nil)
+	require.Nil(t, err)
+	defer conn.Close()
+
+	require.Contains(t, response.Header.Get("Sec-Websocket-Extensions"), "permessage-deflate")
+
+	_, _, err = conn.ReadMessage()
+	require.Nil(t, err)
+
+	group.Disconnect(websocket.CloseGoingAway, "server is shutting down")
+
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			require.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), err.Error())
+			break
+		}
+	}
+}
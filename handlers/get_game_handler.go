@@ -9,6 +9,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/ivan1993spb/snake-server/connections"
+	"github.com/ivan1993spb/snake-server/playground"
 )
 
 const URLRouteGetGameByID = "/games/{id}"
@@ -22,6 +23,18 @@ type responseGetGameHandler struct {
 	Width  int    `json:"width"`
 	Height int    `json:"height"`
 	Rate   uint32 `json:"rate"`
+
+	Private    bool `json:"private"`
+	Persistent bool `json:"persistent"`
+
+	Backpressure connections.Backpressure `json:"backpressure"`
+
+	Playground playground.Backend `json:"playground"`
+
+	// Invulnerability is the invulnerability window of new snakes in milliseconds
+	Invulnerability int64 `json:"invulnerability"`
+
+	responseGameMetadata
 }
 
 type responseGetGameHandlerError struct {
@@ -85,6 +98,18 @@ func (h *getGameHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Private games are indistinguishable from missing ones without the
+	// invite token or the password
+	if !group.Allowed(r.URL.Query().Get(getFieldGameInvite), r.URL.Query().Get(getFieldGamePassword)) {
+		h.logger.Warn(ErrGetGameHandler("access denied to private game"))
+		h.writeResponseJSON(w, http.StatusNotFound, &responseGetGameHandlerError{
+			Code: http.StatusNotFound,
+			Text: "game not found",
+			ID:   id,
+		})
+		return
+	}
+
 	h.writeResponseJSON(w, http.StatusOK, &responseGetGameHandler{
 		ID:     id,
 		Limit:  group.GetLimit(),
@@ -92,6 +117,17 @@ func (h *getGameHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		Width:  int(group.GetWorldWidth()),
 		Height: int(group.GetWorldHeight()),
 		Rate:   group.GetRate(),
+
+		Private:    group.IsPrivate(),
+		Persistent: group.IsPersistent(),
+
+		Backpressure: group.GetBackpressure(),
+
+		Playground: group.GetPlaygroundBackend(),
+
+		Invulnerability: milliseconds(group.GetInvulnerability()),
+
+		responseGameMetadata: newResponseGameMetadata(group.GetMetadata()),
 	})
 }
 
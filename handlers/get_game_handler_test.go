@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ivan1993spb/snake-server/connections"
+	"github.com/ivan1993spb/snake-server/game"
+)
+
+func Test_GetGameHandler_ServeHTTP_HidesPrivateGameWithoutAccess(t *testing.T) {
+	const groupsLimit = 5
+	const connsLimit = 10
+	const password = "secret"
+
+	logger, hook := test.NewNullLogger()
+	groupManager, err := connections.NewConnectionGroupManager(logger, groupsLimit, connsLimit)
+	require.Nil(t, err)
+	require.NotNil(t, groupManager)
+
+	group, err := connections.NewConnectionGroup(logger, 2, 30, 30, game.DefaultConfig())
+	require.Nil(t, err)
+	invite, err := group.MakePrivate(password)
+	require.Nil(t, err)
+	id, err := groupManager.Add(group)
+	require.Nil(t, err)
+
+	r := mux.NewRouter()
+	r.Path(URLRouteGetGameByID).Methods(MethodGetGame).Handler(NewGetGameHandler(logger, groupManager))
+
+	tests := []struct {
+		invite     string
+		password   string
+		statusCode int
+	}{
+		{
+			statusCode: http.StatusNotFound,
+		},
+		{
+			invite:     "invalid",
+			password:   "invalid",
+			statusCode: http.StatusNotFound,
+		},
+		{
+			invite:     invite,
+			statusCode: http.StatusOK,
+		},
+		{
+			password:   password,
+			statusCode: http.StatusOK,
+		},
+	}
+
+	for i, test := range tests {
+		request := httptest.NewRequest(MethodGetGame, strings.Replace(URLRouteGetGameByID, "{id}", strconv.Itoa(id), 1), nil)
+		q := request.URL.Query()
+		q.Add(getFieldGameInvite, test.invite)
+		q.Add(getFieldGamePassword, test.password)
+		request.URL.RawQuery = q.Encode()
+
+		recorder := httptest.NewRecorder()
+
+		r.ServeHTTP(recorder, request)
+		require.Equal(t, test.statusCode, recorder.Code, "case number "+strconv.Itoa(i))
+	}
+
+	hook.Reset()
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 
@@ -19,6 +20,8 @@ const MethodGetGames = http.MethodGet
 const (
 	getFieldGamesLimit   = "limit"
 	getFieldGamesSorting = "sorting"
+	getFieldGamesTag     = "tag"
+	getFieldGamesName    = "name"
 )
 
 const (
@@ -61,6 +64,10 @@ type responseGetGamesEntity struct {
 	Width  int    `json:"width"`
 	Height int    `json:"height"`
 	Rate   uint32 `json:"rate"`
+
+	Persistent bool `json:"persistent"`
+
+	responseGameMetadata
 }
 
 type responseGetGamesHandler struct {
@@ -113,6 +120,9 @@ func (h *getGamesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tags := parseGamesTagsQuery(r.URL.Query()[getFieldGamesTag])
+	name := strings.TrimSpace(r.URL.Query().Get(getFieldGamesName))
+
 	groupCount := h.groupManager.GroupCount()
 
 	if groupCount == 0 {
@@ -124,18 +134,19 @@ func (h *getGamesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if flagUseLimit && limit == 0 {
-		h.writeResponseJSON(w, http.StatusOK, &responseGetGamesHandler{
-			Games: []*responseGetGamesEntity{},
-			Limit: h.groupManager.GroupLimit(),
-			Count: groupCount,
-		})
-		return
-	}
-
 	entities := make([]*responseGetGamesEntity, 0, groupCount)
 
 	for id, group := range h.groupManager.Groups() {
+		// Private games are visible only to invited players
+		if group.IsPrivate() {
+			continue
+		}
+
+		metadata := group.GetMetadata()
+		if !metadata.HasTags(tags) || !metadata.NameContains(name) {
+			continue
+		}
+
 		entities = append(entities, &responseGetGamesEntity{
 			ID:     id,
 			Limit:  group.GetLimit(),
@@ -143,9 +154,17 @@ func (h *getGamesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			Width:  int(group.GetWorldWidth()),
 			Height: int(group.GetWorldHeight()),
 			Rate:   group.GetRate(),
+
+			Persistent: group.IsPersistent(),
+
+			responseGameMetadata: newResponseGameMetadata(metadata),
 		})
 	}
 
+	// The count includes only the games visible to the caller, so that
+	// private games are not revealed
+	visibleCount := len(entities)
+
 	entities = sortGameEntities(sorting, entities)
 
 	if flagUseLimit && limit < len(entities) {
@@ -155,7 +174,7 @@ func (h *getGamesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.writeResponseJSON(w, http.StatusOK, &responseGetGamesHandler{
 		Games: entities,
 		Limit: h.groupManager.GroupLimit(),
-		Count: groupCount,
+		Count: visibleCount,
 	})
 }
 
@@ -204,6 +223,20 @@ func sortGameEntities(sorting gamesSorting, entities []*responseGetGamesEntity)
 	return entities
 }
 
+// parseGamesTagsQuery returns the tags to filter games by. Tags can be passed
+// as several values or separated by commas
+func parseGamesTagsQuery(values []string) []string {
+	tags := make([]string, 0, len(values))
+	for _, value := range values {
+		for _, tag := range strings.Split(value, tagsSeparator) {
+			if tag = strings.TrimSpace(tag); len(tag) > 0 {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
 type gamesEntityFilter func(entity *responseGetGamesEntity) bool
 
 func filterGameEntities(entities []*responseGetGamesEntity, filter gamesEntityFilter) []*responseGetGamesEntity {
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -12,6 +13,7 @@ import (
 	"github.com/urfave/negroni"
 
 	"github.com/ivan1993spb/snake-server/connections"
+	"github.com/ivan1993spb/snake-server/game"
 	"github.com/ivan1993spb/snake-server/middlewares"
 )
 
@@ -84,3 +86,104 @@ func Test_GetGamesHandler_ServeHTTP_ReturnsBadRequestErrorWithInvalidSorting(t *
 
 	hook.Reset()
 }
+
+func Test_GetGamesHandler_ServeHTTP_FiltersGamesByTagAndName(t *testing.T) {
+	const groupsLimit = 5
+	const connsLimit = 10
+
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	groupManager, err := connections.NewConnectionGroupManager(logger, groupsLimit, connsLimit)
+	require.Nil(t, err)
+
+	metadatas := []connections.Metadata{
+		{
+			Name: "Beginner room 30x30",
+			Tags: []string{"beginner", "small"},
+		},
+		{
+			Name: "Advanced room",
+			Tags: []string{"advanced", "small"},
+		},
+		{
+			Name: "Beginner arena",
+			Tags: []string{"beginner"},
+		},
+	}
+
+	for _, metadata := range metadatas {
+		group, err := connections.NewConnectionGroup(logger, 2, 30, 30, game.DefaultConfig())
+		require.Nil(t, err)
+		group.SetMetadata(metadata)
+		_, err = groupManager.Add(group)
+		require.Nil(t, err)
+	}
+
+	// The private game matches the queries below, but it must stay hidden
+	private, err := connections.NewConnectionGroup(logger, 2, 30, 30, game.DefaultConfig())
+	require.Nil(t, err)
+	private.SetMetadata(connections.Metadata{
+		Name: "Beginner room private",
+		Tags: []string{"beginner", "small", "advanced"},
+	})
+	_, err = private.MakePrivate("")
+	require.Nil(t, err)
+	_, err = groupManager.Add(private)
+	require.Nil(t, err)
+
+	r := mux.NewRouter()
+	r.Path(URLRouteGetGames).Methods(MethodGetGames).Handler(NewGetGamesHandler(logger, groupManager))
+
+	tests := []struct {
+		query string
+		names []string
+	}{
+		{
+			query: "tag=beginner",
+			names: []string{"Beginner arena", "Beginner room 30x30"},
+		},
+		{
+			query: "tag=beginner&tag=small",
+			names: []string{"Beginner room 30x30"},
+		},
+		{
+			query: "tag=beginner,small",
+			names: []string{"Beginner room 30x30"},
+		},
+		{
+			query: "name=room",
+			names: []string{"Advanced room", "Beginner room 30x30"},
+		},
+		{
+			query: "name=ROOM&tag=small&tag=advanced",
+			names: []string{"Advanced room"},
+		},
+		{
+			query: "tag=expert",
+			names: []string{},
+		},
+		{
+			query: "",
+			names: []string{"Advanced room", "Beginner arena", "Beginner room 30x30"},
+		},
+	}
+
+	for i, test := range tests {
+		request := httptest.NewRequest(MethodGetGames, URLRouteGetGames+"?"+test.query, nil)
+		recorder := httptest.NewRecorder()
+
+		r.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusOK, recorder.Code, "case number "+strconv.Itoa(i))
+
+		var response responseGetGamesHandler
+		require.Nil(t, json.NewDecoder(recorder.Body).Decode(&response), "case number "+strconv.Itoa(i))
+		require.Equal(t, len(test.names), response.Count, "case number "+strconv.Itoa(i))
+
+		names := make([]string, 0, len(response.Games))
+		for _, game := range response.Games {
+			names = append(names, game.Name)
+		}
+		require.ElementsMatch(t, test.names, names, "case number "+strconv.Itoa(i))
+	}
+}
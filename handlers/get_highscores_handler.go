@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ivan1993spb/snake-server/highscores"
+)
+
+const URLRouteGetHighScores = "/highscores"
+
+const MethodGetHighScores = http.MethodGet
+
+const (
+	getFieldHighScoresWidth   = "width"
+	getFieldHighScoresHeight  = "height"
+	getFieldHighScoresPeriod  = "period"
+	getFieldHighScoresSorting = "sorting"
+	getFieldHighScoresLimit   = "limit"
+)
+
+const defaultHighScoresLimit = 10
+
+var highScoresPeriods = map[string]time.Duration{
+	"day":   time.Hour * 24,
+	"week":  time.Hour * 24 * 7,
+	"month": time.Hour * 24 * 30,
+	"year":  time.Hour * 24 * 365,
+	"all":   0,
+}
+
+const highScoresPeriodDefault = "all"
+
+var highScoresSortings = map[string]highscores.Sorting{
+	"length":   highscores.SortingLength,
+	"kills":    highscores.SortingKills,
+	"survived": highscores.SortingSurvived,
+}
+
+const highScoresSortingDefault = "length"
+
+type responseGetHighScoresEntity struct {
+	Player string `json:"player"`
+	Length uint16 `json:"length"`
+	Kills  uint16 `json:"kills"`
+	// Survived is a number of seconds
+	Survived int64 `json:"survived"`
+}
+
+type responseGetHighScoresHandler struct {
+	HighScores []*responseGetHighScoresEntity `json:"highscores"`
+	Count      int                            `json:"count"`
+}
+
+type responseGetHighScoresHandlerError struct {
+	Code int    `json:"code"`
+	Text string `json:"text"`
+}
+
+type getHighScoresHandler struct {
+	logger logrus.FieldLogger
+	store  *highscores.Store
+}
+
+type ErrGetHighScoresHandler string
+
+func (e ErrGetHighScoresHandler) Error() string {
+	return "get high scores handler error: " + string(e)
+}
+
+func NewGetHighScoresHandler(logger logrus.FieldLogger, store *highscores.Store) http.Handler {
+	return &getHighScoresHandler{
+		logger: logger,
+		store:  store,
+	}
+}
+
+func (h *getHighScoresHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := highscores.Filter{}
+
+	if widthLabel := query.Get(getFieldHighScoresWidth); len(widthLabel) > 0 {
+		width, err := strconv.ParseUint(widthLabel, 10, 8)
+		if err != nil {
+			h.logger.Warn(ErrGetHighScoresHandler(err.Error()))
+			h.writeResponseJSON(w, http.StatusBadRequest, &responseGetHighScoresHandlerError{
+				Code: http.StatusBadRequest,
+				Text: "invalid width",
+			})
+			return
+		}
+		filter.Width = uint8(width)
+	}
+
+	if heightLabel := query.Get(getFieldHighScoresHeight); len(heightLabel) > 0 {
+		height, err := strconv.ParseUint(heightLabel, 10, 8)
+		if err != nil {
+			h.logger.Warn(ErrGetHighScoresHandler(err.Error()))
+			h.writeResponseJSON(w, http.StatusBadRequest, &responseGetHighScoresHandlerError{
+				Code: http.StatusBadRequest,
+				Text: "invalid height",
+			})
+			return
+		}
+		filter.Height = uint8(height)
+	}
+
+	periodLabel := query.Get(getFieldHighScoresPeriod)
+	if len(periodLabel) == 0 {
+		periodLabel = highScoresPeriodDefault
+	}
+	period, ok := highScoresPeriods[periodLabel]
+	if !ok {
+		h.writeResponseJSON(w, http.StatusBadRequest, &responseGetHighScoresHandlerError{
+			Code: http.StatusBadRequest,
+			Text: "invalid period",
+		})
+		return
+	}
+	if period > 0 {
+		filter.Since = time.Now().Add(-period)
+	}
+
+	sortingLabel := query.Get(getFieldHighScoresSorting)
+	if len(sortingLabel) == 0 {
+		sortingLabel = highScoresSortingDefault
+	}
+	sorting, ok := highScoresSortings[sortingLabel]
+	if !ok {
+		h.writeResponseJSON(w, http.StatusBadRequest, &responseGetHighScoresHandlerError{
+			Code: http.StatusBadRequest,
+			Text: "invalid sorting",
+		})
+		return
+	}
+
+	limit := defaultHighScoresLimit
+	if limitLabel := query.Get(getFieldHighScoresLimit); len(limitLabel) > 0 {
+		var err error
+		limit, err = strconv.Atoi(limitLabel)
+		if err != nil || limit <= 0 {
+			h.writeResponseJSON(w, http.StatusBadRequest, &responseGetHighScoresHandlerError{
+				Code: http.StatusBadRequest,
+				Text: "invalid limit value",
+			})
+			return
+		}
+	}
+
+	top := h.store.Top(filter, sorting, limit)
+	entities := make([]*responseGetHighScoresEntity, 0, len(top))
+
+	for _, best := range top {
+		entities = append(entities, &responseGetHighScoresEntity{
+			Player:   best.Player,
+			Length:   best.Length,
+			Kills:    best.Kills,
+			Survived: int64(best.Survived / time.Second),
+		})
+	}
+
+	h.writeResponseJSON(w, http.StatusOK, &responseGetHighScoresHandler{
+		HighScores: entities,
+		Count:      len(entities),
+	})
+}
+
+func (h *getHighScoresHandler) writeResponseJSON(w http.ResponseWriter, statusCode int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Error(ErrGetHighScoresHandler(err.Error()))
+	}
+}
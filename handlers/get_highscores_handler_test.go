@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/negroni"
+
+	"github.com/ivan1993spb/snake-server/highscores"
+	"github.com/ivan1993spb/snake-server/middlewares"
+)
+
+func Test_GetHighScoresHandler_ServeHTTP_ReturnsBadRequestErrorWithInvalidParameters(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	store, err := highscores.NewStore(afero.NewMemMapFs(), "/highscores.jsonl")
+	require.Nil(t, err)
+	defer store.Close()
+
+	r := mux.NewRouter()
+	r.Path(URLRouteGetHighScores).Methods(MethodGetHighScores).Handler(NewGetHighScoresHandler(logger, store))
+
+	n := negroni.New(middlewares.NewRecovery(logger), middlewares.NewLogger(logger, "api"))
+	n.UseHandler(r)
+
+	invalidParameters := []map[string]string{
+		{getFieldHighScoresWidth: "-1"},
+		{getFieldHighScoresHeight: "256"},
+		{getFieldHighScoresPeriod: "century"},
+		{getFieldHighScoresSorting: "invalid"},
+		{getFieldHighScoresLimit: "0"},
+		{getFieldHighScoresLimit: "test"},
+	}
+
+	for i, parameters := range invalidParameters {
+		request := httptest.NewRequest(MethodGetHighScores, URLRouteGetHighScores, nil)
+		q := request.URL.Query()
+		for key, value := range parameters {
+			q.Add(key, value)
+		}
+		request.URL.RawQuery = q.Encode()
+
+		recorder := httptest.NewRecorder()
+
+		n.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusBadRequest, recorder.Code, "case number "+strconv.Itoa(i))
+	}
+}
+
+func Test_GetHighScoresHandler_ServeHTTP_ReturnsHighScores(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	store, err := highscores.NewStore(afero.NewMemMapFs(), "/highscores.jsonl")
+	require.Nil(t, err)
+	defer store.Close()
+
+	require.Nil(t, store.Record(highscores.Record{
+		Player:   "alice",
+		Length:   12,
+		Kills:    2,
+		Survived: time.Second * 90,
+		Width:    30,
+		Height:   30,
+		Time:     time.Now(),
+	}))
+	require.Nil(t, store.Record(highscores.Record{
+		Player:   "bob",
+		Length:   30,
+		Kills:    1,
+		Survived: time.Second * 30,
+		Width:    100,
+		Height:   100,
+		Time:     time.Now(),
+	}))
+
+	r := mux.NewRouter()
+	r.Path(URLRouteGetHighScores).Methods(MethodGetHighScores).Handler(NewGetHighScoresHandler(logger, store))
+
+	request := httptest.NewRequest(MethodGetHighScores, URLRouteGetHighScores+"?width=30&height=30&period=day", nil)
+	recorder := httptest.NewRecorder()
+
+	r.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var response responseGetHighScoresHandler
+	require.Nil(t, json.NewDecoder(recorder.Body).Decode(&response))
+	require.Equal(t, responseGetHighScoresHandler{
+		HighScores: []*responseGetHighScoresEntity{
+			{
+				Player:   "alice",
+				Length:   12,
+				Kills:    2,
+				Survived: 90,
+			},
+		},
+		Count: 1,
+	}, response)
+}
@@ -123,6 +123,15 @@ func (h *getObjectsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !group.Allowed(r.URL.Query().Get(getFieldGameInvite), r.URL.Query().Get(getFieldGamePassword)) {
+		h.logger.WithField("game", id).Warn(ErrGetObjectsHandler("access denied to private game"))
+		h.writeResponseJSON(w, http.StatusForbidden, &responseGetObjectsHandlerError{
+			Code: http.StatusForbidden,
+			Text: "invalid invite token or password",
+		})
+		return
+	}
+
 	if retryAfterSeconds := h.getRetryAfterHeaderSeconds(id); retryAfterSeconds > 0 {
 		seconds := strconv.Itoa(retryAfterSeconds)
 		w.Header().Set("Retry-After", seconds)
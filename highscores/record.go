@@ -0,0 +1,139 @@
+package highscores
+
+import "time"
+
+// Record is a result of a single snake's life
+type Record struct {
+	Player string `json:"player"`
+
+	Length   uint16        `json:"length"`
+	Kills    uint16        `json:"kills"`
+	Survived time.Duration `json:"survived"`
+
+	// Width and Height are the map size of the game where the record was set
+	Width  uint8 `json:"width"`
+	Height uint8 `json:"height"`
+
+	// Time is the moment when the snake died
+	Time time.Time `json:"time"`
+}
+
+// merge merges the best results of the records keeping the latest time
+func (r *Record) merge(record Record) {
+	if record.Length > r.Length {
+		r.Length = record.Length
+	}
+	if record.Kills > r.Kills {
+		r.Kills = record.Kills
+	}
+	if record.Survived > r.Survived {
+		r.Survived = record.Survived
+	}
+	if record.Time.After(r.Time) {
+		r.Time = record.Time
+	}
+}
+
+// Recorder interface describes a storage for records
+type Recorder interface {
+	// Record saves a record or returns an error if one occurred
+	Record(record Record) error
+}
+
+// Best contains the best results of a player achieved in the records
+type Best struct {
+	Player   string
+	Length   uint16
+	Kills    uint16
+	Survived time.Duration
+}
+
+func newBest(record Record) *Best {
+	return &Best{
+		Player:   record.Player,
+		Length:   record.Length,
+		Kills:    record.Kills,
+		Survived: record.Survived,
+	}
+}
+
+// record returns a record of the best results on the map size of the key
+func (b *Best) record(key bestKey) Record {
+	return Record{
+		Player:   b.Player,
+		Length:   b.Length,
+		Kills:    b.Kills,
+		Survived: b.Survived,
+		Width:    key.width,
+		Height:   key.height,
+	}
+}
+
+func (b *Best) merge(record Record) {
+	if record.Length > b.Length {
+		b.Length = record.Length
+	}
+	if record.Kills > b.Kills {
+		b.Kills = record.Kills
+	}
+	if record.Survived > b.Survived {
+		b.Survived = record.Survived
+	}
+}
+
+// Filter selects records
+type Filter struct {
+	// Width and Height filter records by the map size if they are not zero
+	Width  uint8
+	Height uint8
+
+	// Since filters records set after the time if it is not zero
+	Since time.Time
+}
+
+func (f Filter) matchSize(width, height uint8) bool {
+	if f.Width > 0 && f.Width != width {
+		return false
+	}
+	if f.Height > 0 && f.Height != height {
+		return false
+	}
+	return true
+}
+
+func (f Filter) match(record Record) bool {
+	if !f.matchSize(record.Width, record.Height) {
+		return false
+	}
+	if !f.Since.IsZero() && record.Time.Before(f.Since) {
+		return false
+	}
+	return true
+}
+
+// Sorting defines a field to order the best results by
+type Sorting uint8
+
+const (
+	SortingLength Sorting = iota
+	SortingKills
+	SortingSurvived
+)
+
+func (s Sorting) less(a, b *Best) bool {
+	switch s {
+	case SortingKills:
+		if a.Kills != b.Kills {
+			return a.Kills > b.Kills
+		}
+	case SortingSurvived:
+		if a.Survived != b.Survived {
+			return a.Survived > b.Survived
+		}
+	default:
+		if a.Length != b.Length {
+			return a.Length > b.Length
+		}
+	}
+	return a.Player < b.Player
+}
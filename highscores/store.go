@@ -0,0 +1,308 @@
+package highscores
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+const storeFileMode = 0644
+
+// recordsRetention is the period before the latest record during which the
+// records are kept as is. It is longer than the longest period of the high
+// scores API. Older records are compacted to the best results of players
+const recordsRetention = time.Hour * 24 * 366
+
+// bestKey identifies the best results of a player on a map size
+type bestKey struct {
+	player string
+	width  uint8
+	height uint8
+}
+
+func newBestKey(record Record) bestKey {
+	return bestKey{
+		player: record.Player,
+		width:  record.Width,
+		height: record.Height,
+	}
+}
+
+// Store keeps records in memory and appends them to a file in the JSON lines
+// format, one record per line
+type Store struct {
+	file afero.File
+
+	// bests contains the best results of all records
+	bests map[bestKey]*Best
+	// records contains the records of the retention period, they are used
+	// to select the best results of a period
+	records []Record
+	// count is the number of records in the file
+	count int
+
+	mux *sync.RWMutex
+}
+
+type ErrStore struct {
+	Err error
+}
+
+func (e *ErrStore) Error() string {
+	return "high scores store error: " + e.Err.Error()
+}
+
+// NewStore loads all records from a file at the path and opens the file to
+// append new records. If the file does not exist, it is created
+func NewStore(fs afero.Fs, path string) (*Store, error) {
+	records, err := loadRecords(fs, path)
+	if err != nil {
+		return nil, &ErrStore{err}
+	}
+
+	compacted, recent := compactRecords(records)
+	if len(compacted)+len(recent) < len(records) {
+		records = append(compacted, recent...)
+		if err := writeRecords(fs, path, records); err != nil {
+			return nil, &ErrStore{err}
+		}
+	}
+
+	file, err := fs.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, storeFileMode)
+	if err != nil {
+		return nil, &ErrStore{err}
+	}
+
+	store := &Store{
+		file:    file,
+		bests:   make(map[bestKey]*Best),
+		records: recent,
+		count:   len(records),
+		mux:     &sync.RWMutex{},
+	}
+
+	for _, record := range records {
+		store.unsafeIndex(record)
+	}
+
+	return store, nil
+}
+
+// compactRecords merges the records which are older than the retention
+// period before the latest record to a record per player and map size
+func compactRecords(records []Record) (compacted, recent []Record) {
+	compacted = make([]Record, 0)
+	recent = make([]Record, 0, len(records))
+
+	var latest time.Time
+	for _, record := range records {
+		if record.Time.After(latest) {
+			latest = record.Time
+		}
+	}
+	cutoff := latest.Add(-recordsRetention)
+
+	indexes := make(map[bestKey]int)
+
+	for _, record := range records {
+		if !record.Time.Before(cutoff) {
+			recent = append(recent, record)
+			continue
+		}
+
+		key := newBestKey(record)
+		if i, ok := indexes[key]; ok {
+			compacted[i].merge(record)
+		} else {
+			indexes[key] = len(compacted)
+			compacted = append(compacted, record)
+		}
+	}
+
+	return compacted, recent
+}
+
+func loadRecords(fs afero.Fs, path string) ([]Record, error) {
+	file, err := fs.Open(path)
+	if os.IsNotExist(err) {
+		return make([]Record, 0), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	records, broken, err := readRecords(file)
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if broken {
+		// Rewrite the file without the broken line in order to append new
+		// records on a new line
+		if err := writeRecords(fs, path, records); err != nil {
+			return nil, err
+		}
+	}
+
+	return records, nil
+}
+
+func readRecords(file afero.File) (records []Record, broken bool, err error) {
+	records = make([]Record, 0)
+	scanner := bufio.NewScanner(file)
+
+	var errBroken error
+
+	for line := 1; scanner.Scan(); line++ {
+		if errBroken != nil {
+			// Only the last line is allowed to be broken by an interrupted write
+			return nil, false, errBroken
+		}
+
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			errBroken = fmt.Errorf("cannot decode record on line %d: %s", line, err)
+			continue
+		}
+
+		records = append(records, record)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, false, err
+	}
+
+	return records, errBroken != nil, nil
+}
+
+func writeRecords(fs afero.Fs, path string, records []Record) error {
+	file, err := fs.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, storeFileMode)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(file)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			file.Close()
+			return err
+		}
+	}
+
+	return file.Close()
+}
+
+// Record saves the record to the file and adds it to the memory
+func (s *Store) Record(record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return &ErrStore{err}
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return &ErrStore{err}
+	}
+
+	s.count++
+	s.unsafeIndex(record)
+
+	// Records come in the order of time, so the oldest ones are in the front
+	cutoff := record.Time.Add(-recordsRetention)
+	i := 0
+	for i < len(s.records) && s.records[i].Time.Before(cutoff) {
+		i++
+	}
+	s.records = append(s.records[i:], record)
+
+	return nil
+}
+
+// unsafeIndex updates the best results of the player with the record
+func (s *Store) unsafeIndex(record Record) {
+	key := newBestKey(record)
+	if best, ok := s.bests[key]; ok {
+		best.merge(record)
+	} else {
+		s.bests[key] = newBest(record)
+	}
+}
+
+// Top returns the best results of players in the records selected by the
+// filter. The results are ordered by the sorting field. If limit is greater
+// than zero, no more than limit results are returned. The best results of all
+// time are taken from the index, whereas a period is selected from the records
+// of the retention period
+func (s *Store) Top(filter Filter, sorting Sorting, limit int) []*Best {
+	bests := make(map[string]*Best)
+	merge := func(record Record) {
+		if best, ok := bests[record.Player]; ok {
+			best.merge(record)
+		} else {
+			bests[record.Player] = newBest(record)
+		}
+	}
+
+	s.mux.RLock()
+	if filter.Since.IsZero() {
+		for key, best := range s.bests {
+			if filter.matchSize(key.width, key.height) {
+				merge(best.record(key))
+			}
+		}
+	} else {
+		for _, record := range s.records {
+			if filter.match(record) {
+				merge(record)
+			}
+		}
+	}
+	s.mux.RUnlock()
+
+	top := make([]*Best, 0, len(bests))
+	for _, best := range bests {
+		top = append(top, best)
+	}
+
+	sort.Slice(top, func(i, j int) bool {
+		return sorting.less(top[i], top[j])
+	})
+
+	if limit > 0 && limit < len(top) {
+		top = top[:limit]
+	}
+
+	return top
+}
+
+// Count returns the number of records in the file of the store
+func (s *Store) Count() int {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.count
+}
+
+// Close closes the records file
+func (s *Store) Close() error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if err := s.file.Close(); err != nil {
+		return &ErrStore{err}
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package highscores
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+const testStorePath = "/highscores.jsonl"
+
+func Test_Store_Record_PersistsRecords(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	store, err := NewStore(fs, testStorePath)
+	require.Nil(t, err)
+
+	now := time.Date(2020, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+	require.Nil(t, store.Record(Record{
+		Player:   "alice",
+		Length:   10,
+		Kills:    1,
+		Survived: time.Minute,
+		Width:    30,
+		Height:   30,
+		Time:     now,
+	}))
+	require.Nil(t, store.Record(Record{
+		Player:   "bob",
+		Length:   7,
+		Kills:    3,
+		Survived: time.Second * 30,
+		Width:    30,
+		Height:   30,
+		Time:     now,
+	}))
+	require.Nil(t, store.Close())
+
+	store, err = NewStore(fs, testStorePath)
+	require.Nil(t, err)
+	defer store.Close()
+
+	require.Equal(t, 2, store.Count())
+	require.Equal(t, []*Best{
+		{
+			Player:   "alice",
+			Length:   10,
+			Kills:    1,
+			Survived: time.Minute,
+		},
+		{
+			Player:   "bob",
+			Length:   7,
+			Kills:    3,
+			Survived: time.Second * 30,
+		},
+	}, store.Top(Filter{}, SortingLength, 0))
+}
+
+func Test_NewStore_IgnoresBrokenLastLine(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	err := afero.WriteFile(fs, testStorePath, []byte(
+		`{"player":"alice","length":5,"kills":0,"survived":1000000000,"width":20,"height":20,"time":"2020-05-01T12:00:00Z"}`+"\n"+
+			`{"player":"bob","len`,
+	), storeFileMode)
+	require.Nil(t, err)
+
+	store, err := NewStore(fs, testStorePath)
+	require.Nil(t, err)
+	require.Equal(t, 1, store.Count())
+
+	require.Nil(t, store.Record(Record{Player: "bob", Length: 3}))
+	require.Nil(t, store.Close())
+
+	store, err = NewStore(fs, testStorePath)
+	require.Nil(t, err)
+	defer store.Close()
+
+	require.Equal(t, 2, store.Count())
+}
+
+func Test_NewStore_ReturnsErrorOnBrokenRecord(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	err := afero.WriteFile(fs, testStorePath, []byte(
+		`{"player":"bob","len`+"\n"+
+			`{"player":"alice","length":5,"kills":0,"survived":1000000000,"width":20,"height":20,"time":"2020-05-01T12:00:00Z"}`+"\n",
+	), storeFileMode)
+	require.Nil(t, err)
+
+	store, err := NewStore(fs, testStorePath)
+	require.NotNil(t, err)
+	require.Nil(t, store)
+}
+
+func Test_Store_Top_MergesAndFiltersRecords(t *testing.T) {
+	store, err := NewStore(afero.NewMemMapFs(), testStorePath)
+	require.Nil(t, err)
+	defer store.Close()
+
+	now := time.Date(2020, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+	records := []Record{
+		{Player: "alice", Length: 10, Kills: 0, Survived: time.Minute, Width: 30, Height: 30, Time: now},
+		{Player: "alice", Length: 4, Kills: 5, Survived: time.Second, Width: 30, Height: 30, Time: now.Add(-time.Hour * 48)},
+		{Player: "bob", Length: 20, Kills: 2, Survived: time.Minute * 2, Width: 100, Height: 50, Time: now},
+		{Player: "carol", Length: 8, Kills: 1, Survived: time.Minute * 3, Width: 30, Height: 30, Time: now},
+	}
+
+	for _, record := range records {
+		require.Nil(t, store.Record(record))
+	}
+
+	// All time best results
+	require.Equal(t, []*Best{
+		{Player: "alice", Length: 10, Kills: 5, Survived: time.Minute},
+		{Player: "bob", Length: 20, Kills: 2, Survived: time.Minute * 2},
+		{Player: "carol", Length: 8, Kills: 1, Survived: time.Minute * 3},
+	}, store.Top(Filter{}, SortingKills, 0))
+
+	// Filter by the map size
+	require.Equal(t, []*Best{
+		{Player: "carol", Length: 8, Kills: 1, Survived: time.Minute * 3},
+		{Player: "alice", Length: 10, Kills: 5, Survived: time.Minute},
+	}, store.Top(Filter{Width: 30, Height: 30}, SortingSurvived, 0))
+
+	// Filter by the period
+	require.Equal(t, []*Best{
+		{Player: "bob", Length: 20, Kills: 2, Survived: time.Minute * 2},
+		{Player: "alice", Length: 10, Kills: 0, Survived: time.Minute},
+	}, store.Top(Filter{Since: now.Add(-time.Hour)}, SortingLength, 2))
+}
+
+func Test_NewStore_CompactsOldRecords(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	store, err := NewStore(fs, testStorePath)
+	require.Nil(t, err)
+
+	now := time.Date(2020, time.May, 1, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-recordsRetention - time.Hour)
+
+	records := []Record{
+		{Player: "alice", Length: 10, Kills: 0, Survived: time.Minute, Width: 30, Height: 30, Time: old.Add(-time.Hour)},
+		{Player: "alice", Length: 4, Kills: 5, Survived: time.Second, Width: 30, Height: 30, Time: old},
+		{Player: "alice", Length: 3, Kills: 1, Survived: time.Hour, Width: 100, Height: 50, Time: old},
+		{Player: "bob", Length: 20, Kills: 2, Survived: time.Minute * 2, Width: 30, Height: 30, Time: now},
+	}
+
+	for _, record := range records {
+		require.Nil(t, store.Record(record))
+	}
+	require.Equal(t, 4, store.Count())
+	require.Nil(t, store.Close())
+
+	store, err = NewStore(fs, testStorePath)
+	require.Nil(t, err)
+	defer store.Close()
+
+	require.Equal(t, 3, store.Count())
+
+	loaded, err := loadRecords(fs, testStorePath)
+	require.Nil(t, err)
+	require.Equal(t, []Record{
+		{Player: "alice", Length: 10, Kills: 5, Survived: time.Minute, Width: 30, Height: 30, Time: old},
+		{Player: "alice", Length: 3, Kills: 1, Survived: time.Hour, Width: 100, Height: 50, Time: old},
+		{Player: "bob", Length: 20, Kills: 2, Survived: time.Minute * 2, Width: 30, Height: 30, Time: now},
+	}, loaded)
+
+	// The compacted records keep the best results of all time
+	require.Equal(t, []*Best{
+		{Player: "alice", Length: 10, Kills: 5, Survived: time.Hour},
+		{Player: "bob", Length: 20, Kills: 2, Survived: time.Minute * 2},
+	}, store.Top(Filter{}, SortingKills, 0))
+
+	require.Equal(t, []*Best{
+		{Player: "bob", Length: 20, Kills: 2, Survived: time.Minute * 2},
+	}, store.Top(Filter{Since: now.Add(-time.Hour * 24 * 365)}, SortingKills, 0))
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"math"
 	"math/rand"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"runtime"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/evalphobia/logrus_sentry"
@@ -24,8 +27,13 @@ import (
 	"github.com/ivan1993spb/snake-server/client"
 	"github.com/ivan1993spb/snake-server/config"
 	"github.com/ivan1993spb/snake-server/connections"
+	"github.com/ivan1993spb/snake-server/game"
 	"github.com/ivan1993spb/snake-server/handlers"
+	"github.com/ivan1993spb/snake-server/highscores"
 	"github.com/ivan1993spb/snake-server/middlewares"
+	"github.com/ivan1993spb/snake-server/objects/corpse"
+	"github.com/ivan1993spb/snake-server/objects/snake"
+	"github.com/ivan1993spb/snake-server/playground"
 )
 
 const ServerName = "Snake-Server"
@@ -77,36 +85,314 @@ func logger(configLog config.Log) *logrus.Logger {
 
 const serverShutdownTimeout = time.Second
 
-func serve(ctx context.Context, logger logrus.FieldLogger,
-	handler http.Handler, address string, configTLS config.TLS) error {
-	server := &http.Server{
-		Addr:    address,
-		Handler: handler,
-		BaseContext: func(net.Listener) context.Context {
-			return ctx
-		},
+// authScopes are the scopes which can be granted to tokens
+var authScopes = []string{
+	middlewares.ScopeAll,
+	handlers.ScopeCreateGame,
+	handlers.ScopePersistentGame,
+	handlers.ScopeDeleteGame,
+	handlers.ScopeBroadcast,
+}
+
+// secure wraps the handler to require a token with the scope if auth is set
+func secure(auth *middlewares.Auth, scope string, handler http.Handler) http.Handler {
+	if auth == nil {
+		return handler
+	}
+	return negroni.New(auth.Require(scope), negroni.Wrap(handler))
+}
+
+// with wraps the handler with the middlewares if there are any
+func with(handler http.Handler, middlewares ...negroni.Handler) http.Handler {
+	if len(middlewares) == 0 {
+		return handler
+	}
+	n := negroni.New(middlewares...)
+	n.UseHandler(handler)
+	return n
+}
+
+func restoreGames(logger logrus.FieldLogger, groupManager *connections.ConnectionGroupManager, path string,
+	gameConfig game.Config) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		logger.WithField("path", path).Info("no games snapshot found")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	snapshot, err := connections.ReadSnapshot(f)
+	if err != nil {
+		return err
+	}
+
+	count := groupManager.Restore(snapshot, gameConfig)
+
+	logger.WithFields(logrus.Fields{
+		"path":     path,
+		"restored": count,
+		"total":    len(snapshot.Groups),
+	}).Info("games restored from snapshot")
+
+	return nil
+}
+
+func saveGames(logger logrus.FieldLogger, groupManager *connections.ConnectionGroupManager, path string) error {
+	snapshot, err := groupManager.Snapshot()
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first not to corrupt a previous snapshot
+	tmpPath := path + ".tmp"
+
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	if err := connections.WriteSnapshot(f, snapshot); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"path":  path,
+		"games": len(snapshot.Groups),
+	}).Info("games saved to snapshot")
+
+	return nil
+}
+
+// Route sets which are served by listeners
+const (
+	// routeSetGame contains the web client, game connections and public API
+	// methods
+	routeSetGame = "game"
+	// routeSetAdmin contains admin API methods
+	routeSetAdmin = "admin"
+	// routeSetMetrics contains prometheus metrics
+	routeSetMetrics = "metrics"
+	// routeSetDebug contains profiling routes enabled with the debug flag
+	routeSetDebug = "debug"
+)
+
+var routeSets = []string{
+	routeSetGame,
+	routeSetAdmin,
+	routeSetMetrics,
+	routeSetDebug,
+}
+
+// listenerRouteSets returns the route sets of the listener. The implicit
+// listener made of the server's address serves all route sets, configured
+// listeners have to name their route sets
+func listenerRouteSets(configListener config.Listener, implicit bool) (map[string]bool, error) {
+	known := make(map[string]bool, len(routeSets))
+	for _, name := range routeSets {
+		known[name] = true
+	}
+
+	if implicit {
+		return known, nil
+	}
+
+	if len(configListener.Routes) == 0 {
+		return nil, fmt.Errorf("no route sets")
+	}
+
+	sets := make(map[string]bool, len(configListener.Routes))
+	for _, name := range configListener.Routes {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown route set: %s", name)
+		}
+		sets[name] = true
+	}
+	return sets, nil
+}
+
+// listener is a configured listener with its handler
+type listener struct {
+	config  config.Listener
+	handler http.Handler
+}
+
+const unixAddressPrefix = "unix:"
+
+// listen announces on the address. Addresses with the unix: prefix are paths
+// to Unix domain sockets
+func listen(address string) (net.Listener, error) {
+	path := strings.TrimPrefix(address, unixAddressPrefix)
+	if path == address {
+		return net.Listen("tcp", address)
+	}
+
+	// The socket file is left if the server has been killed
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
 	}
 
-	go func() {
-		<-ctx.Done()
+	return net.Listen("unix", path)
+}
+
+// serve serves the listeners until the context is done or one of them fails.
+// When the context is done it calls drain before shutting the servers down.
+// Requests are not bound to the context: long-lived streams are notified and
+// closed by drain and the rest of requests are canceled after the shutdown
+func serve(ctx context.Context, logger logrus.FieldLogger, listeners []listener, drain func()) error {
+	baseCtx, cancelBase := context.WithCancel(context.Background())
+	defer cancelBase()
+
+	servers := make([]*http.Server, 0, len(listeners))
+	netListeners := make([]net.Listener, 0, len(listeners))
+
+	for _, l := range listeners {
+		netListener, err := listen(l.config.Address)
+		if err != nil {
+			for _, netListener := range netListeners {
+				netListener.Close()
+			}
+			return fmt.Errorf("listener %s: %s", l.config.Name, err)
+		}
+		netListeners = append(netListeners, netListener)
+
+		servers = append(servers, &http.Server{
+			Handler: l.handler,
+			BaseContext: func(net.Listener) context.Context {
+				return baseCtx
+			},
+			ConnContext: connections.ConnContext,
+		})
+	}
+
+	errs := make(chan error, len(servers))
+	for i, server := range servers {
+		go func(server *http.Server, netListener net.Listener, configTLS config.TLS) {
+			var err error
+			if configTLS.Enable {
+				err = server.ServeTLS(netListener, configTLS.Cert, configTLS.Key)
+			} else {
+				err = server.Serve(netListener)
+			}
+			if err == http.ErrServerClosed {
+				err = nil
+			}
+			errs <- err
+		}(server, netListeners[i], listeners[i].config.TLS)
+	}
+
+	var err error
+	running := len(servers)
+
+	select {
+	case <-ctx.Done():
 		logger.Info("shutting down")
-		ctx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
-		defer cancel()
-		if err := server.Shutdown(ctx); err != nil && err != context.Canceled {
+		drain()
+	case err = <-errs:
+		running--
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+	defer cancel()
+	for _, server := range servers {
+		if err := server.Shutdown(shutdownCtx); err != nil && err != context.Canceled {
 			logger.Errorln("server shutdown error:", err)
 		}
-	}()
+	}
+	cancelBase()
 
-	var err error
-	if configTLS.Enable {
-		err = server.ListenAndServeTLS(configTLS.Cert, configTLS.Key)
+	for ; running > 0; running-- {
+		if serveErr := <-errs; err == nil {
+			err = serveErr
+		}
+	}
+
+	return err
+}
+
+// reloadable holds the parts of the server which are changed when the
+// configuration is reloaded
+type reloadable struct {
+	logger       *logrus.Logger
+	groupManager *connections.ConnectionGroupManager
+	cors         *middlewares.Switch
+	// broadcastOff hides the broadcast route while it is enabled
+	broadcastOff *middlewares.Switch
+}
+
+// reload applies the reloadable fields of the next config and returns the
+// config in effect. Fields which cannot be changed at runtime are rejected
+func reload(r reloadable, cfg, next config.Config) config.Config {
+	next, rejected := cfg.Reload(next)
+	for _, label := range rejected {
+		r.logger.WithField("field", label).Warning("config field cannot be changed at runtime: restart the server to apply it")
+	}
+
+	level, err := logrus.ParseLevel(next.Server.Log.Level)
+	if err != nil {
+		r.logger.Errorln("cannot apply log level:", err)
+		next.Server.Log.Level = cfg.Server.Log.Level
 	} else {
-		err = server.ListenAndServe()
+		r.logger.SetLevel(level)
 	}
-	if err == http.ErrServerClosed {
-		return nil
+
+	if err := r.groupManager.SetLimits(next.Server.Limits.Groups, next.Server.Limits.Conns); err != nil {
+		r.logger.Errorln("cannot apply limits:", err)
+		next.Server.Limits = cfg.Server.Limits
+	}
+
+	r.cors.Set(!next.Server.Flags.ForbidCORS)
+	r.broadcastOff.Set(!next.Server.Flags.EnableBroadcast)
+
+	r.logger.WithFields(logrus.Fields{
+		"conns_limit":  next.Server.Limits.Conns,
+		"groups_limit": next.Server.Limits.Groups,
+		"log_level":    next.Server.Log.Level,
+		"broadcast":    next.Server.Flags.EnableBroadcast,
+		"cors":         !next.Server.Flags.ForbidCORS,
+	}).Info("config reloaded")
+
+	if next.Server.Flags.EnableBroadcast && !cfg.Server.Flags.EnableBroadcast {
+		r.logger.Warning("broadcasting API method is enabled!")
+	}
+
+	return next
+}
+
+// reloadOnSignal reloads the config every time the server receives SIGHUP
+// until the context is done
+func reloadOnSignal(ctx context.Context, r reloadable, cfg config.Config) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sighup:
+			r.logger.Info("reloading config")
+			next, err := configurate()
+			if err != nil {
+				r.logger.Errorln("cannot reload config:", err)
+				continue
+			}
+			cfg = reload(r, cfg, next)
+		}
 	}
-	return err
 }
 
 func main() {
@@ -152,6 +438,11 @@ func main() {
 		"broadcast":    cfg.Server.Flags.EnableBroadcast,
 		"web":          cfg.Server.Flags.EnableWeb,
 		"cors":         !cfg.Server.Flags.ForbidCORS,
+		"snapshot":     cfg.Server.Snapshot.Enable,
+		"highscores":   cfg.Server.HighScores.Enable,
+		"auth":         cfg.Server.Auth.Enable,
+		"per_ip":       cfg.Server.Limits.PerIP,
+		"reaper":       cfg.Server.Reaper.Enable,
 	}).Info("preparing to start server")
 
 	if cfg.Server.Flags.EnableBroadcast {
@@ -168,65 +459,245 @@ func main() {
 		logger.Fatalln("cannot register connection group manager as a metric collector:", err)
 	}
 
-	rootRouter := mux.NewRouter().StrictSlash(true)
-	rootRouter.Path("/metrics").Handler(promhttp.Handler())
-	if cfg.Server.Flags.Debug {
-		rootRouter.PathPrefix(handlers.URLRouteDebug).Handler(handlers.NewDebugHandler())
+	var (
+		highScoresStore *highscores.Store
+		recorder        highscores.Recorder
+	)
+	if cfg.Server.HighScores.Enable {
+		highScoresStore, err = highscores.NewStore(afero.NewOsFs(), cfg.Server.HighScores.Path)
+		if err != nil {
+			logger.Fatalln("cannot open high scores store:", err)
+		}
+		recorder = highScoresStore
+
+		logger.WithFields(logrus.Fields{
+			"path":    cfg.Server.HighScores.Path,
+			"records": highScoresStore.Count(),
+		}).Info("high scores store opened")
 	}
-	rootRouter.Path(handlers.URLRouteOpenAPI).Handler(handlers.NewOpenAPIHandler())
-	if cfg.Server.Flags.EnableWeb {
-		rootRouter.Path(client.URLRouteServerEndpoint).Handler(http.RedirectHandler(client.URLRouteClient, http.StatusFound))
-		rootRouter.PathPrefix(client.URLRouteClient).Handler(negroni.New(gzip.Gzip(gzip.DefaultCompression), negroni.Wrap(client.NewHandler())))
-	} else {
-		rootRouter.Path(handlers.URLRouteWelcome).Methods(handlers.MethodWelcome).Handler(handlers.NewWelcomeHandler(logger))
-	}
-	rootRouter.NotFoundHandler = handlers.NewNotFoundHandler(logger)
-
-	// Web-Socket routes
-	wsRouter := rootRouter.PathPrefix("/ws").Subrouter()
-	wsRouter.Path(handlers.URLRouteGameWebSocketByID).Methods(handlers.MethodGame).Handler(handlers.NewGameWebSocketHandler(logger, groupManager))
-
-	// API routes
-	apiRouter := rootRouter.PathPrefix("/api").Subrouter()
-	apiRouter.Path(handlers.URLRouteGetInfo).Methods(handlers.MethodGetInfo).Handler(handlers.NewGetInfoHandler(logger, Author, License, Version, Build))
-	apiRouter.Path(handlers.URLRouteGetCapacity).Methods(handlers.MethodGetCapacity).Handler(handlers.NewGetCapacityHandler(logger, groupManager))
-	apiRouter.Path(handlers.URLRouteCreateGame).Methods(handlers.MethodCreateGame).Handler(handlers.NewCreateGameHandler(logger, groupManager))
-	apiRouter.Path(handlers.URLRouteGetGameByID).Methods(handlers.MethodGetGame).Handler(handlers.NewGetGameHandler(logger, groupManager))
-	apiRouter.Path(handlers.URLRouteDeleteGameByID).Methods(handlers.MethodDeleteGame).Handler(handlers.NewDeleteGameHandler(logger, groupManager))
-	apiRouter.Path(handlers.URLRouteGetGames).Methods(handlers.MethodGetGames).Handler(handlers.NewGetGamesHandler(logger, groupManager))
-	if cfg.Server.Flags.EnableBroadcast {
-		apiRouter.Path(handlers.URLRouteBroadcast).Methods(handlers.MethodBroadcast).Handler(handlers.NewBroadcastHandler(logger, groupManager))
+
+	if cfg.Server.Reaper.Enable {
+		if cfg.Server.Reaper.TTL <= 0 {
+			logger.Fatalln("invalid reaper ttl:", cfg.Server.Reaper.TTL)
+		}
+		groupManager.StartReaper(ctx.Done(), cfg.Server.Reaper.TTL)
+		logger.WithField("ttl", cfg.Server.Reaper.TTL.String()).Info("idle games reaper started")
+	}
+
+	if cfg.Server.WebSocket.Compression.Enable && !handlers.ValidCompressionLevel(cfg.Server.WebSocket.Compression.Level) {
+		logger.Fatalln("invalid web-socket compression level:", cfg.Server.WebSocket.Compression.Level)
+	}
+
+	backpressurePolicy, err := connections.ParseBackpressurePolicy(cfg.Server.Backpressure.Policy)
+	if err != nil {
+		logger.Fatalln("invalid backpressure policy:", cfg.Server.Backpressure.Policy)
+	}
+	if cfg.Server.Backpressure.MaxDrops <= 0 {
+		logger.Fatalln("invalid backpressure max drops:", cfg.Server.Backpressure.MaxDrops)
+	}
+	backpressure := connections.Backpressure{
+		Policy:   backpressurePolicy,
+		MaxDrops: cfg.Server.Backpressure.MaxDrops,
+	}
+
+	playgroundBackend, err := playground.ParseBackend(cfg.Server.Playground)
+	if err != nil {
+		logger.Fatalln("invalid playground:", cfg.Server.Playground)
+	}
+
+	if cfg.Server.Corpse.DecayInterval <= 0 {
+		logger.Fatalln("invalid corpse decay interval:", cfg.Server.Corpse.DecayInterval)
+	}
+	if cfg.Server.Corpse.FreshnessInterval <= 0 {
+		logger.Fatalln("invalid corpse freshness interval:", cfg.Server.Corpse.FreshnessInterval)
+	}
+	if cfg.Server.Corpse.NutritionalValue <= 0 || cfg.Server.Corpse.NutritionalValue > math.MaxUint16 {
+		logger.Fatalln("invalid corpse nutritional value:", cfg.Server.Corpse.NutritionalValue)
+	}
+	if cfg.Server.Snake.Invulnerability < 0 {
+		logger.Fatalln("invalid snake invulnerability:", cfg.Server.Snake.Invulnerability)
+	}
+
+	// gameConfig is the default configuration of new and restored games
+	gameConfig := game.Config{
+		Playground: playgroundBackend,
+		Corpse: corpse.Config{
+			DecayInterval:     cfg.Server.Corpse.DecayInterval,
+			FreshnessInterval: cfg.Server.Corpse.FreshnessInterval,
+			NutritionalValue:  uint16(cfg.Server.Corpse.NutritionalValue),
+		},
+		Snake: snake.Config{
+			Invulnerability: cfg.Server.Snake.Invulnerability,
+		},
+		HighScores: recorder,
 	}
-	apiRouter.Path(handlers.URLRouteGetObjects).Methods(handlers.MethodGetObjects).Handler(handlers.NewGetObjectsHandler(logger, groupManager))
-	apiRouter.Path(handlers.URLRoutePing).Methods(handlers.MethodPing).Handler(handlers.NewPingHandler(logger))
 
-	n := negroni.New(
-		middlewares.NewRecovery(logger),
-		middlewares.NewServerInfo(ServerName, Version, Build),
-		middlewares.NewLogger(logger, logName),
+	if cfg.Server.Snapshot.Enable {
+		if err := restoreGames(logger, groupManager, cfg.Server.Snapshot.Path, gameConfig); err != nil {
+			logger.Errorln("cannot restore games from snapshot:", err)
+		}
+	}
+
+	var (
+		auth *middlewares.Auth
+		// authorizer is nil if auth is disabled not to check scopes
+		authorizer handlers.Authorizer
 	)
+	if cfg.Server.Auth.Enable {
+		if len(cfg.Server.Auth.Tokens) == 0 {
+			logger.Warning("auth is enabled but no tokens are set: admin API methods are unavailable")
+		}
+		if err := cfg.Server.Auth.Validate(authScopes); err != nil {
+			logger.Fatalln("cannot load auth tokens:", err)
+		}
+		auth = middlewares.NewAuth(logger, cfg.Server.Auth.TokenScopes())
+		authorizer = auth
+	}
+
+	clientIPResolver, err := middlewares.NewClientIPResolver(cfg.Server.TrustedProxies)
+	if err != nil {
+		logger.Fatalln("cannot parse trusted proxies:", err)
+	}
 
-	if !cfg.Server.Flags.ForbidCORS {
-		n.Use(middlewares.NewCORS())
+	var (
+		wsMiddlewares         []negroni.Handler
+		apiMiddlewares        []negroni.Handler
+		createGameMiddlewares []negroni.Handler
+	)
+	if limit := cfg.Server.Limits.PerIP.Conns; limit > 0 {
+		wsMiddlewares = append(wsMiddlewares, middlewares.NewConnsLimit(logger, clientIPResolver, limit))
+	}
+	if limit := cfg.Server.Limits.PerIP.Requests; limit > 0 {
+		apiMiddlewares = append(apiMiddlewares, middlewares.NewRateLimit(logger, clientIPResolver, limit, time.Second))
+	}
+	if limit := cfg.Server.Limits.PerIP.Games; limit > 0 {
+		createGameMiddlewares = append(createGameMiddlewares, middlewares.NewSuccessRateLimit(logger, clientIPResolver, limit, time.Hour))
 	}
 
-	n.UseHandler(rootRouter)
+	notFoundHandler := handlers.NewNotFoundHandler(logger)
+	eventStreamRegistry := connections.NewEventStreamRegistry()
+
+	// The broadcast route is always registered so that it could be enabled
+	// on reload. The switch responds with not found while it is disabled
+	notFound := negroni.HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		notFoundHandler.ServeHTTP(rw, r)
+	})
+	broadcastOff := middlewares.NewSwitch(notFound, !cfg.Server.Flags.EnableBroadcast)
+
+	cors := middlewares.NewSwitch(middlewares.NewCORS(), !cfg.Server.Flags.ForbidCORS)
 
-	// Dynamically assign port from env var if set
-	address := cfg.Server.Address
-	envPort, envPortExists := os.LookupEnv("PORT")
-	if envPortExists {
-		address = ":" + envPort
+	// newHandler returns a handler of the route sets. Listeners share the
+	// handlers of routes, so limits and event streams are common to them
+	newHandler := func(routeSets map[string]bool) http.Handler {
+		rootRouter := mux.NewRouter().StrictSlash(true)
+		if routeSets[routeSetMetrics] {
+			rootRouter.Path("/metrics").Handler(promhttp.Handler())
+		}
+		if routeSets[routeSetDebug] && cfg.Server.Flags.Debug {
+			rootRouter.PathPrefix(handlers.URLRouteDebug).Handler(handlers.NewDebugHandler())
+		}
+		if routeSets[routeSetGame] {
+			rootRouter.Path(handlers.URLRouteOpenAPI).Handler(handlers.NewOpenAPIHandler())
+			if cfg.Server.Flags.EnableWeb {
+				rootRouter.Path(client.URLRouteServerEndpoint).Handler(http.RedirectHandler(client.URLRouteClient, http.StatusFound))
+				rootRouter.PathPrefix(client.URLRouteClient).Handler(negroni.New(gzip.Gzip(gzip.DefaultCompression), negroni.Wrap(client.NewHandler())))
+			} else {
+				rootRouter.Path(handlers.URLRouteWelcome).Methods(handlers.MethodWelcome).Handler(handlers.NewWelcomeHandler(logger))
+			}
+
+			// Web-Socket routes
+			wsRouter := rootRouter.PathPrefix("/ws").Subrouter()
+			wsRouter.Path(handlers.URLRouteGameWebSocketByID).Methods(handlers.MethodGame).Handler(with(handlers.NewGameWebSocketHandler(logger, groupManager,
+				cfg.Server.WebSocket.Compression.Enable, cfg.Server.WebSocket.Compression.Level), wsMiddlewares...))
+
+			// Server-sent events routes
+			eventsRouter := rootRouter.PathPrefix("/events").Subrouter()
+			eventsRouter.Path(handlers.URLRouteGameEventStreamByID).Methods(handlers.MethodGameEventStream).Handler(with(handlers.NewGameEventStreamHandler(logger, groupManager, eventStreamRegistry), wsMiddlewares...))
+			eventsRouter.Path(handlers.URLRouteEventStreamCommandBySession).Methods(handlers.MethodEventStreamCommand).Handler(with(handlers.NewEventStreamCommandHandler(logger, eventStreamRegistry), apiMiddlewares...))
+		}
+		rootRouter.NotFoundHandler = notFoundHandler
+
+		// API routes
+		if routeSets[routeSetGame] || routeSets[routeSetAdmin] {
+			apiRootRouter := mux.NewRouter().StrictSlash(true)
+			apiRootRouter.NotFoundHandler = notFoundHandler
+			rootRouter.PathPrefix("/api").Handler(with(apiRootRouter, apiMiddlewares...))
+			apiRouter := apiRootRouter.PathPrefix("/api").Subrouter()
+			if routeSets[routeSetGame] {
+				apiRouter.Path(handlers.URLRouteGetInfo).Methods(handlers.MethodGetInfo).Handler(handlers.NewGetInfoHandler(logger, Author, License, Version, Build))
+				apiRouter.Path(handlers.URLRouteGetCapacity).Methods(handlers.MethodGetCapacity).Handler(handlers.NewGetCapacityHandler(logger, groupManager))
+				apiRouter.Path(handlers.URLRouteCreateGame).Methods(handlers.MethodCreateGame).Handler(secure(auth, handlers.ScopeCreateGame, with(handlers.NewCreateGameHandler(logger, groupManager, gameConfig, backpressure, authorizer), createGameMiddlewares...)))
+				apiRouter.Path(handlers.URLRouteGetGameByID).Methods(handlers.MethodGetGame).Handler(handlers.NewGetGameHandler(logger, groupManager))
+				apiRouter.Path(handlers.URLRouteGetGames).Methods(handlers.MethodGetGames).Handler(handlers.NewGetGamesHandler(logger, groupManager))
+				apiRouter.Path(handlers.URLRouteGetObjects).Methods(handlers.MethodGetObjects).Handler(handlers.NewGetObjectsHandler(logger, groupManager))
+				if cfg.Server.HighScores.Enable {
+					apiRouter.Path(handlers.URLRouteGetHighScores).Methods(handlers.MethodGetHighScores).Handler(handlers.NewGetHighScoresHandler(logger, highScoresStore))
+				}
+				apiRouter.Path(handlers.URLRoutePing).Methods(handlers.MethodPing).Handler(handlers.NewPingHandler(logger))
+			}
+			if routeSets[routeSetAdmin] {
+				apiRouter.Path(handlers.URLRouteDeleteGameByID).Methods(handlers.MethodDeleteGame).Handler(secure(auth, handlers.ScopeDeleteGame, handlers.NewDeleteGameHandler(logger, groupManager)))
+				apiRouter.Path(handlers.URLRouteBroadcast).Methods(handlers.MethodBroadcast).Handler(with(secure(auth, handlers.ScopeBroadcast, handlers.NewBroadcastHandler(logger, groupManager)), broadcastOff))
+			}
+		}
+
+		n := negroni.New(
+			middlewares.NewRecovery(logger),
+			middlewares.NewServerInfo(ServerName, Version, Build),
+			middlewares.NewLogger(logger, logName),
+			cors,
+		)
+		n.UseHandler(rootRouter)
+		return n
 	}
 
-	logger.WithFields(logrus.Fields{
-		"address": address,
-		"tls":     cfg.Server.TLS.Enable,
-	}).Info("starting server")
+	go reloadOnSignal(ctx, reloadable{
+		logger:       logger,
+		groupManager: groupManager,
+		cors:         cors,
+		broadcastOff: broadcastOff,
+	}, cfg)
+
+	configListeners := cfg.Server.EffectiveListeners()
+	listeners := make([]listener, 0, len(configListeners))
+	for _, configListener := range configListeners {
+		routeSets, err := listenerRouteSets(configListener, len(cfg.Server.Listeners) == 0)
+		if err != nil {
+			logger.Fatalf("invalid routes of listener %s: %s", configListener.Name, err)
+		}
 
-	if err := serve(ctx, logger, n, address, cfg.Server.TLS); err != nil {
+		listeners = append(listeners, listener{
+			config:  configListener,
+			handler: newHandler(routeSets),
+		})
+
+		logger.WithFields(logrus.Fields{
+			"name":    configListener.Name,
+			"address": configListener.Address,
+			"tls":     configListener.TLS.Enable,
+			"routes":  configListener.Routes,
+		}).Info("starting listener")
+	}
+
+	drain := func() {
+		groupManager.Drain(cfg.Server.Shutdown.DrainTimeout)
+	}
+
+	if err := serve(ctx, logger, listeners, drain); err != nil {
 		logger.Fatalf("server error: %s", err)
 	}
 
+	if cfg.Server.Snapshot.Enable {
+		if err := saveGames(logger, groupManager, cfg.Server.Snapshot.Path); err != nil {
+			logger.Errorln("cannot save games to snapshot:", err)
+		}
+	}
+
+	if highScoresStore != nil {
+		if err := highScoresStore.Close(); err != nil {
+			logger.Errorln("cannot close high scores store:", err)
+		}
+	}
+
 	logger.Info("buh bye!")
 }
@@ -0,0 +1,157 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/negroni"
+)
+
+// ScopeAll grants access to all secured routes
+const ScopeAll = "*"
+
+const (
+	headerAuthorization   = "Authorization"
+	headerWWWAuthenticate = "WWW-Authenticate"
+
+	authorizationSchemeBearer = "Bearer"
+)
+
+type responseAuthError struct {
+	Code int    `json:"code"`
+	Text string `json:"text"`
+}
+
+type authToken struct {
+	token  []byte
+	scopes map[string]struct{}
+}
+
+// Auth checks API tokens passed in the Authorization header with the Bearer
+// scheme
+type Auth struct {
+	logger logrus.FieldLogger
+	tokens []authToken
+}
+
+// NewAuth creates an authenticator with the tokens. The map tokens contains
+// granted scopes by tokens
+func NewAuth(logger logrus.FieldLogger, tokens map[string][]string) *Auth {
+	auth := &Auth{
+		logger: logger,
+		tokens: make([]authToken, 0, len(tokens)),
+	}
+
+	for token, scopes := range tokens {
+		t := authToken{
+			token:  []byte(token),
+			scopes: make(map[string]struct{}, len(scopes)),
+		}
+		for _, scope := range scopes {
+			t.scopes[scope] = struct{}{}
+		}
+		auth.tokens = append(auth.tokens, t)
+	}
+
+	return auth
+}
+
+func (a *Auth) lookup(token string) (authToken, bool) {
+	var (
+		found authToken
+		ok    bool
+	)
+
+	// Compare all tokens in constant time not to leak them by timing
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare(t.token, []byte(token)) == 1 {
+			found = t
+			ok = true
+		}
+	}
+
+	return found, ok
+}
+
+func (t authToken) granted(scope string) bool {
+	if _, ok := t.scopes[ScopeAll]; ok {
+		return true
+	}
+	_, ok := t.scopes[scope]
+	return ok
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get(headerAuthorization)
+	prefix := authorizationSchemeBearer + " "
+
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", false
+	}
+
+	token := strings.TrimSpace(header[len(prefix):])
+	return token, len(token) > 0
+}
+
+// Granted returns true if the request carries a token which grants the scope
+func (a *Auth) Granted(r *http.Request, scope string) bool {
+	token, ok := bearerToken(r)
+	if !ok {
+		return false
+	}
+
+	t, ok := a.lookup(token)
+	return ok && t.granted(scope)
+}
+
+// Require returns a middleware which passes only requests with a token that
+// grants the scope
+func (a *Auth) Require(scope string) negroni.Handler {
+	return negroni.HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		token, ok := bearerToken(r)
+		if !ok {
+			a.logger.WithField("scope", scope).Warn("auth: no token provided")
+			rw.Header().Set(headerWWWAuthenticate, authorizationSchemeBearer)
+			a.writeResponseJSON(rw, http.StatusUnauthorized, &responseAuthError{
+				Code: http.StatusUnauthorized,
+				Text: "authorization required",
+			})
+			return
+		}
+
+		t, ok := a.lookup(token)
+		if !ok {
+			a.logger.WithField("scope", scope).Warn("auth: invalid token")
+			rw.Header().Set(headerWWWAuthenticate, authorizationSchemeBearer+` error="invalid_token"`)
+			a.writeResponseJSON(rw, http.StatusUnauthorized, &responseAuthError{
+				Code: http.StatusUnauthorized,
+				Text: "invalid token",
+			})
+			return
+		}
+
+		if !t.granted(scope) {
+			a.logger.WithField("scope", scope).Warn("auth: insufficient scope")
+			rw.Header().Set(headerWWWAuthenticate, authorizationSchemeBearer+` error="insufficient_scope"`)
+			a.writeResponseJSON(rw, http.StatusForbidden, &responseAuthError{
+				Code: http.StatusForbidden,
+				Text: "insufficient scope",
+			})
+			return
+		}
+
+		next(rw, r)
+	})
+}
+
+func (a *Auth) writeResponseJSON(w http.ResponseWriter, statusCode int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		a.logger.WithError(err).Error("cannot send auth error response")
+	}
+}
@@ -0,0 +1,107 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/negroni"
+)
+
+func Test_Auth_Require_ChecksTokenAndScope(t *testing.T) {
+	const scope = "games:delete"
+
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	auth := NewAuth(logger, map[string][]string{
+		"admin":     {ScopeAll},
+		"moderator": {scope},
+		"creator":   {"games:create"},
+	})
+
+	n := negroni.New(auth.Require(scope), negroni.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	tests := []struct {
+		authorization string
+		expectCode    int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer", http.StatusUnauthorized},
+		{"Basic YWRtaW46YWRtaW4=", http.StatusUnauthorized},
+		{"Bearer unknown", http.StatusUnauthorized},
+		{"Bearer creator", http.StatusForbidden},
+		{"Bearer moderator", http.StatusOK},
+		{"bearer moderator", http.StatusOK},
+		{"Bearer admin", http.StatusOK},
+	}
+
+	for i, test := range tests {
+		request := httptest.NewRequest(http.MethodDelete, "/games/1", nil)
+		if test.authorization != "" {
+			request.Header.Set(headerAuthorization, test.authorization)
+		}
+		recorder := httptest.NewRecorder()
+
+		n.ServeHTTP(recorder, request)
+		require.Equal(t, test.expectCode, recorder.Code, "case number "+strconv.Itoa(i))
+	}
+}
+
+func Test_Auth_Require_RejectsEmptyToken(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	auth := NewAuth(logger, map[string][]string{
+		"": {ScopeAll},
+	})
+
+	n := negroni.New(auth.Require(ScopeAll), negroni.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	request := httptest.NewRequest(http.MethodDelete, "/games/1", nil)
+	request.Header.Set(headerAuthorization, "Bearer   ")
+	recorder := httptest.NewRecorder()
+
+	n.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func Test_Auth_Granted_ChecksTokenAndScope(t *testing.T) {
+	const scope = "games:persistent"
+
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	auth := NewAuth(logger, map[string][]string{
+		"admin":   {ScopeAll},
+		"creator": {"games:create"},
+		"owner":   {"games:create", scope},
+	})
+
+	tests := []struct {
+		authorization string
+		expected      bool
+	}{
+		{"", false},
+		{"Bearer unknown", false},
+		{"Bearer creator", false},
+		{"Bearer owner", true},
+		{"Bearer admin", true},
+	}
+
+	for i, test := range tests {
+		request := httptest.NewRequest(http.MethodPost, "/games", nil)
+		if test.authorization != "" {
+			request.Header.Set(headerAuthorization, test.authorization)
+		}
+
+		require.Equal(t, test.expected, auth.Granted(request, scope), "case number "+strconv.Itoa(i))
+	}
+}
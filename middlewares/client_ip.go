@@ -0,0 +1,103 @@
+package middlewares
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+const headerXForwardedFor = "X-Forwarded-For"
+
+// ClientIPResolver determines the IP address of a client. X-Forwarded-For
+// header is taken into account only if a request comes from a trusted proxy
+type ClientIPResolver struct {
+	trustedProxies []*net.IPNet
+}
+
+type ErrClientIPResolver string
+
+func (e ErrClientIPResolver) Error() string {
+	return "client ip resolver error: " + string(e)
+}
+
+// NewClientIPResolver creates a resolver. Trusted proxies are IP addresses
+// or networks in CIDR notation
+func NewClientIPResolver(trustedProxies []string) (*ClientIPResolver, error) {
+	nets := make([]*net.IPNet, 0, len(trustedProxies))
+
+	for _, proxy := range trustedProxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, ErrClientIPResolver("invalid trusted proxy: " + proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{
+				IP:   ip,
+				Mask: net.CIDRMask(bits, bits),
+			})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, ErrClientIPResolver(err.Error())
+		}
+		nets = append(nets, ipNet)
+	}
+
+	return &ClientIPResolver{
+		trustedProxies: nets,
+	}, nil
+}
+
+func (c *ClientIPResolver) trusted(ip net.IP) bool {
+	for _, ipNet := range c.trustedProxies {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the IP address of the client which has sent the request.
+// Requests from Unix domain sockets have no IP address, they come from local
+// proxies which are trusted to set X-Forwarded-For header. ClientIP returns
+// an empty string if the address of such a client is not forwarded
+func (c *ClientIPResolver) ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip != nil && !c.trusted(ip) {
+		return ip.String()
+	}
+
+	// Walk through the proxy chain from the nearest proxy and take the first
+	// address which is not a trusted proxy
+	forwarded := strings.Split(strings.Join(r.Header.Values(headerXForwardedFor), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		forwardedIP := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if forwardedIP == nil {
+			break
+		}
+
+		ip = forwardedIP
+
+		if !c.trusted(ip) {
+			break
+		}
+	}
+
+	if ip == nil {
+		return ""
+	}
+
+	return ip.String()
+}
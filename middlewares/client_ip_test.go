@@ -0,0 +1,52 @@
+package middlewares
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewClientIPResolver_ReturnsErrorOnInvalidProxy(t *testing.T) {
+	resolver, err := NewClientIPResolver([]string{"10.0.0.0/8", "invalid"})
+	require.NotNil(t, err)
+	require.Nil(t, resolver)
+}
+
+func Test_ClientIPResolver_ClientIP(t *testing.T) {
+	resolver, err := NewClientIPResolver([]string{"10.0.0.0/8", "192.168.1.1"})
+	require.Nil(t, err)
+
+	tests := []struct {
+		remoteAddr    string
+		forwardedFor  string
+		expectAddress string
+	}{
+		// Untrusted clients cannot spoof their addresses
+		{"203.0.113.5:1234", "198.51.100.1", "203.0.113.5"},
+		{"192.168.1.2:1234", "198.51.100.1", "192.168.1.2"},
+		// Trusted proxies
+		{"192.168.1.1:1234", "198.51.100.1", "198.51.100.1"},
+		{"10.1.2.3:1234", "198.51.100.1, 10.0.0.5", "198.51.100.1"},
+		{"10.1.2.3:1234", "203.0.113.9, 198.51.100.1, 10.0.0.5", "198.51.100.1"},
+		{"10.1.2.3:1234", "", "10.1.2.3"},
+		{"10.1.2.3:1234", "garbage", "10.1.2.3"},
+		{"[2001:db8::1]:1234", "", "2001:db8::1"},
+		// Unix domain sockets
+		{"@", "198.51.100.1", "198.51.100.1"},
+		{"@", "198.51.100.1, 10.0.0.5", "198.51.100.1"},
+		{"@", "", ""},
+		{"@", "garbage", ""},
+	}
+
+	for i, test := range tests {
+		request := httptest.NewRequest("GET", "/api/games", nil)
+		request.RemoteAddr = test.remoteAddr
+		if test.forwardedFor != "" {
+			request.Header.Set(headerXForwardedFor, test.forwardedFor)
+		}
+
+		require.Equal(t, test.expectAddress, resolver.ClientIP(request), "case number "+strconv.Itoa(i))
+	}
+}
@@ -2,6 +2,8 @@ package middlewares
 
 import (
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/meatballhat/negroni-logrus"
 	"github.com/sirupsen/logrus"
@@ -10,6 +12,21 @@ import (
 
 const headerSnakeClient = "X-Snake-Client"
 
+const redactedValue = "REDACTED"
+
+// redactedParams are query string params which carry credentials of private
+// games and must not reach the logs
+var redactedParams = map[string]struct{}{
+	"invite":   {},
+	"password": {},
+}
+
+// redactedPathPrefixes are prefixes of paths which are followed by a segment
+// carrying a secret. Session IDs of event streams control players' snakes
+var redactedPathPrefixes = []string{
+	"/events/sessions/",
+}
+
 func NewLogger(logger *logrus.Logger, name string) negroni.Handler {
 	m := negronilogrus.NewMiddlewareFromLogger(logger, name)
 	m.Before = before
@@ -25,8 +42,54 @@ func before(entry *logrus.Entry, req *http.Request, remoteAddr string) *logrus.E
 
 	return entry.WithFields(logrus.Fields{
 		"client":  client,
-		"request": req.RequestURI,
+		"request": redactRequestURI(req.RequestURI),
 		"method":  req.Method,
 		"remote":  remoteAddr,
 	})
 }
+
+// redactRequestURI replaces secret path segments and values of the credential
+// params in the request URI keeping the rest of the URI as is
+func redactRequestURI(requestURI string) string {
+	i := strings.IndexByte(requestURI, '?')
+	if i < 0 {
+		return redactPath(requestURI)
+	}
+
+	pairs := strings.Split(requestURI[i+1:], "&")
+
+	for j, pair := range pairs {
+		rawKey := pair
+		if k := strings.IndexByte(pair, '='); k >= 0 {
+			rawKey = pair[:k]
+		}
+
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+
+		if _, ok := redactedParams[key]; ok {
+			pairs[j] = rawKey + "=" + redactedValue
+		}
+	}
+
+	return redactPath(requestURI[:i]) + "?" + strings.Join(pairs, "&")
+}
+
+// redactPath replaces the segment which follows a redacted prefix in the path
+func redactPath(path string) string {
+	for _, prefix := range redactedPathPrefixes {
+		if !strings.HasPrefix(path, prefix) || len(path) == len(prefix) {
+			continue
+		}
+
+		rest := path[len(prefix):]
+		if j := strings.IndexByte(rest, '/'); j >= 0 {
+			return prefix + redactedValue + rest[j:]
+		}
+		return prefix + redactedValue
+	}
+
+	return path
+}
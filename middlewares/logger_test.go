@@ -0,0 +1,55 @@
+package middlewares
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_redactRequestURI(t *testing.T) {
+	tests := []struct {
+		requestURI string
+		expected   string
+	}{
+		{
+			requestURI: "/api/games",
+			expected:   "/api/games",
+		},
+		{
+			requestURI: "/api/games?limit=10&sorting=smart",
+			expected:   "/api/games?limit=10&sorting=smart",
+		},
+		{
+			requestURI: "/ws/games/2?invite=5f0c4b3e2a1d9c8b7a6f5e4d3c2b1a09",
+			expected:   "/ws/games/2?invite=REDACTED",
+		},
+		{
+			requestURI: "/api/games/2/objects?password=secret&invite=token&x=1",
+			expected:   "/api/games/2/objects?password=REDACTED&invite=REDACTED&x=1",
+		},
+		{
+			requestURI: "/api/games/2?pass%77ord=secret&invite",
+			expected:   "/api/games/2?pass%77ord=REDACTED&invite=REDACTED",
+		},
+		{
+			requestURI: "/api/games/2?",
+			expected:   "/api/games/2?",
+		},
+		{
+			requestURI: "/events/sessions/4b3e2a1d9c8b7a6f",
+			expected:   "/events/sessions/REDACTED",
+		},
+		{
+			requestURI: "/events/sessions/4b3e2a1d9c8b7a6f?invite=token",
+			expected:   "/events/sessions/REDACTED?invite=REDACTED",
+		},
+		{
+			requestURI: "/events/games/2",
+			expected:   "/events/games/2",
+		},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.expected, redactRequestURI(test.requestURI), test.requestURI)
+	}
+}
@@ -0,0 +1,272 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/negroni"
+)
+
+const headerRetryAfter = "Retry-After"
+
+// connsLimitRetryAfter is sent to clients which have too many opened
+// connections: there is no way to know when a connection will be closed
+const connsLimitRetryAfter = time.Second * 10
+
+type responseRateLimitError struct {
+	Code int    `json:"code"`
+	Text string `json:"text"`
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter is a token bucket limiter by keys
+type rateLimiter struct {
+	limit    float64
+	rate     float64
+	interval time.Duration
+
+	buckets     map[string]*bucket
+	lastCleanup time.Time
+	mux         *sync.Mutex
+}
+
+func newRateLimiter(limit int, interval time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:    float64(limit),
+		rate:     float64(limit) / interval.Seconds(),
+		interval: interval,
+		buckets:  make(map[string]*bucket),
+		mux:      &sync.Mutex{},
+	}
+}
+
+// unsafeCleanup removes full buckets not to grow the map forever
+func (l *rateLimiter) unsafeCleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < l.interval {
+		return
+	}
+
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= l.interval {
+			delete(l.buckets, key)
+		}
+	}
+
+	l.lastCleanup = now
+}
+
+// unsafeBucket returns the bucket of the key refilled by the time
+func (l *rateLimiter) unsafeBucket(key string, now time.Time) *bucket {
+	l.unsafeCleanup(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{
+			tokens: l.limit,
+			last:   now,
+		}
+		l.buckets[key] = b
+	} else {
+		b.tokens = math.Min(l.limit, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+		b.last = now
+	}
+
+	return b
+}
+
+// unsafeWait returns the time to wait for the next token in the bucket
+func (l *rateLimiter) unsafeWait(b *bucket) time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// take takes a token for the key. It returns zero on success, otherwise it
+// returns the time to wait for the next token
+func (l *rateLimiter) take(key string, now time.Time) time.Duration {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	b := l.unsafeBucket(key, now)
+	if wait := l.unsafeWait(b); wait > 0 {
+		return wait
+	}
+	b.tokens--
+
+	return 0
+}
+
+// wait returns zero if there is a token for the key, otherwise it returns the
+// time to wait for the next token. It does not take the token
+func (l *rateLimiter) wait(key string, now time.Time) time.Duration {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	return l.unsafeWait(l.unsafeBucket(key, now))
+}
+
+// charge takes a token for the key even if there are no tokens left, so that
+// requests which have been let through at the same time are all charged
+func (l *rateLimiter) charge(key string, now time.Time) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	l.unsafeBucket(key, now).tokens--
+}
+
+// connsCounter counts concurrent connections by keys
+type connsCounter struct {
+	limit  int
+	counts map[string]int
+	mux    *sync.Mutex
+}
+
+func newConnsCounter(limit int) *connsCounter {
+	return &connsCounter{
+		limit:  limit,
+		counts: make(map[string]int),
+		mux:    &sync.Mutex{},
+	}
+}
+
+func (c *connsCounter) acquire(key string) bool {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.counts[key] >= c.limit {
+		return false
+	}
+
+	c.counts[key]++
+
+	return true
+}
+
+func (c *connsCounter) release(key string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.counts[key] <= 1 {
+		delete(c.counts, key)
+	} else {
+		c.counts[key]--
+	}
+}
+
+// NewRateLimit returns a middleware which allows a client to make limit
+// requests per interval. Clients with unknown IP addresses are not limited
+func NewRateLimit(logger logrus.FieldLogger, resolver *ClientIPResolver, limit int, interval time.Duration) negroni.Handler {
+	limiter := newRateLimiter(limit, interval)
+
+	return negroni.HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		ip := resolver.ClientIP(r)
+		if len(ip) == 0 {
+			// The client is unknown, so it cannot be limited
+			next(rw, r)
+			return
+		}
+
+		if wait := limiter.take(ip, time.Now()); wait > 0 {
+			logger.WithFields(logrus.Fields{
+				"ip":       ip,
+				"limit":    limit,
+				"interval": interval,
+			}).Warn("rate limit exceeded")
+			writeTooManyRequests(logger, rw, wait)
+			return
+		}
+
+		next(rw, r)
+	})
+}
+
+// NewSuccessRateLimit returns a middleware which allows a client to make limit
+// successful requests per interval. A request is charged only if it succeeds,
+// so that invalid requests do not use up the budget of a client. Clients with
+// unknown IP addresses are not limited
+func NewSuccessRateLimit(logger logrus.FieldLogger, resolver *ClientIPResolver, limit int, interval time.Duration) negroni.Handler {
+	limiter := newRateLimiter(limit, interval)
+
+	return negroni.HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		ip := resolver.ClientIP(r)
+		if len(ip) == 0 {
+			// The client is unknown, so it cannot be limited
+			next(rw, r)
+			return
+		}
+
+		if wait := limiter.wait(ip, time.Now()); wait > 0 {
+			logger.WithFields(logrus.Fields{
+				"ip":       ip,
+				"limit":    limit,
+				"interval": interval,
+			}).Warn("rate limit exceeded")
+			writeTooManyRequests(logger, rw, wait)
+			return
+		}
+
+		res, ok := rw.(negroni.ResponseWriter)
+		if !ok {
+			res = negroni.NewResponseWriter(rw)
+		}
+
+		next(res, r)
+
+		if status := res.Status(); status >= http.StatusOK && status < http.StatusMultipleChoices {
+			limiter.charge(ip, time.Now())
+		}
+	})
+}
+
+// NewConnsLimit returns a middleware which allows a client to have limit
+// requests in progress at the same time. It is used to limit hijacked
+// web-socket connections. Clients with unknown IP addresses are not limited
+func NewConnsLimit(logger logrus.FieldLogger, resolver *ClientIPResolver, limit int) negroni.Handler {
+	counter := newConnsCounter(limit)
+
+	return negroni.HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		ip := resolver.ClientIP(r)
+		if len(ip) == 0 {
+			// The client is unknown, so it cannot be limited
+			next(rw, r)
+			return
+		}
+
+		if !counter.acquire(ip) {
+			logger.WithFields(logrus.Fields{
+				"ip":    ip,
+				"limit": limit,
+			}).Warn("connections limit exceeded")
+			writeTooManyRequests(logger, rw, connsLimitRetryAfter)
+			return
+		}
+		defer counter.release(ip)
+
+		next(rw, r)
+	})
+}
+
+func writeTooManyRequests(logger logrus.FieldLogger, w http.ResponseWriter, wait time.Duration) {
+	seconds := strconv.Itoa(int(math.Ceil(wait.Seconds())))
+
+	w.Header().Set(headerRetryAfter, seconds)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusTooManyRequests)
+
+	if err := json.NewEncoder(w).Encode(&responseRateLimitError{
+		Code: http.StatusTooManyRequests,
+		Text: "retry after " + seconds + " second(s)",
+	}); err != nil {
+		logger.WithError(err).Error("cannot send rate limit response")
+	}
+}
@@ -0,0 +1,146 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/negroni"
+)
+
+func Test_rateLimiter_take(t *testing.T) {
+	limiter := newRateLimiter(2, time.Second)
+	now := time.Now()
+
+	require.Zero(t, limiter.take("a", now))
+	require.Zero(t, limiter.take("a", now))
+	require.Equal(t, time.Millisecond*500, limiter.take("a", now))
+
+	// Other keys are not affected
+	require.Zero(t, limiter.take("b", now))
+
+	require.Zero(t, limiter.take("a", now.Add(time.Millisecond*500)))
+	require.Equal(t, time.Millisecond*500, limiter.take("a", now.Add(time.Millisecond*500)))
+}
+
+func Test_rateLimiter_unsafeCleanup_RemovesFullBuckets(t *testing.T) {
+	limiter := newRateLimiter(2, time.Second)
+	now := time.Now()
+
+	limiter.take("a", now)
+	limiter.take("b", now.Add(time.Millisecond*800))
+	limiter.take("c", now.Add(time.Millisecond*1500))
+
+	require.Len(t, limiter.buckets, 2)
+	require.Contains(t, limiter.buckets, "b")
+	require.Contains(t, limiter.buckets, "c")
+}
+
+func Test_connsCounter(t *testing.T) {
+	counter := newConnsCounter(2)
+
+	require.True(t, counter.acquire("a"))
+	require.True(t, counter.acquire("a"))
+	require.False(t, counter.acquire("a"))
+	require.True(t, counter.acquire("b"))
+
+	counter.release("a")
+	require.True(t, counter.acquire("a"))
+
+	counter.release("a")
+	counter.release("a")
+	counter.release("b")
+	require.Empty(t, counter.counts)
+}
+
+func Test_NewRateLimit_ReturnsTooManyRequests(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	resolver, err := NewClientIPResolver(nil)
+	require.Nil(t, err)
+
+	n := negroni.New(NewRateLimit(logger, resolver, 1, time.Hour), negroni.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	request := httptest.NewRequest(http.MethodPost, "/api/games", nil)
+	request.RemoteAddr = "203.0.113.5:1234"
+
+	recorder := httptest.NewRecorder()
+	n.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	n.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "3600", recorder.Header().Get(headerRetryAfter))
+}
+
+func Test_NewRateLimit_SkipsUnknownClients(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	resolver, err := NewClientIPResolver(nil)
+	require.Nil(t, err)
+
+	n := negroni.New(NewRateLimit(logger, resolver, 1, time.Hour), negroni.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	// Clients of a Unix domain socket do not share a bucket
+	request := httptest.NewRequest(http.MethodPost, "/api/games", nil)
+	request.RemoteAddr = "@"
+
+	for i := 0; i < 3; i++ {
+		recorder := httptest.NewRecorder()
+		n.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusOK, recorder.Code)
+	}
+
+	// A forwarded address is limited
+	request.Header.Set(headerXForwardedFor, "198.51.100.1")
+
+	recorder := httptest.NewRecorder()
+	n.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	n.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+}
+
+func Test_NewSuccessRateLimit_ChargesSuccessfulRequestsOnly(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	defer hook.Reset()
+
+	resolver, err := NewClientIPResolver(nil)
+	require.Nil(t, err)
+
+	n := negroni.New(NewSuccessRateLimit(logger, resolver, 1, time.Hour), negroni.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("valid") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})))
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, target, nil)
+		request.RemoteAddr = "203.0.113.5:1234"
+		recorder := httptest.NewRecorder()
+		n.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	require.Equal(t, http.StatusBadRequest, serve("/api/games").Code)
+	require.Equal(t, http.StatusBadRequest, serve("/api/games").Code)
+	require.Equal(t, http.StatusCreated, serve("/api/games?valid=1").Code)
+
+	recorder := serve("/api/games?valid=1")
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "3600", recorder.Header().Get(headerRetryAfter))
+}
@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/urfave/negroni"
+)
+
+// Switch is a middleware which can be turned on and off at runtime. When the
+// switch is off requests are passed to the next handler directly
+type Switch struct {
+	handler negroni.Handler
+	enabled int32
+}
+
+func NewSwitch(handler negroni.Handler, enabled bool) *Switch {
+	s := &Switch{
+		handler: handler,
+	}
+	s.Set(enabled)
+	return s
+}
+
+// Set turns the middleware on or off
+func (s *Switch) Set(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&s.enabled, 1)
+	} else {
+		atomic.StoreInt32(&s.enabled, 0)
+	}
+}
+
+func (s *Switch) Enabled() bool {
+	return atomic.LoadInt32(&s.enabled) == 1
+}
+
+func (s *Switch) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if s.Enabled() {
+		s.handler.ServeHTTP(rw, r, next)
+		return
+	}
+	next(rw, r)
+}
@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/negroni"
+)
+
+func Test_Switch_ServeHTTP(t *testing.T) {
+	forbid := negroni.HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		rw.WriteHeader(http.StatusForbidden)
+	})
+	s := NewSwitch(forbid, true)
+
+	n := negroni.New(s)
+	n.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	})
+
+	serve := func() int {
+		rec := httptest.NewRecorder()
+		n.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rec.Code
+	}
+
+	require.True(t, s.Enabled())
+	require.Equal(t, http.StatusForbidden, serve())
+
+	s.Set(false)
+	require.False(t, s.Enabled())
+	require.Equal(t, http.StatusOK, serve())
+
+	s.Set(true)
+	require.Equal(t, http.StatusForbidden, serve())
+}
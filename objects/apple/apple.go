@@ -7,6 +7,8 @@ import (
 	"github.com/pquerna/ffjson/ffjson"
 
 	"github.com/ivan1993spb/snake-server/engine"
+	"github.com/ivan1993spb/snake-server/objects"
+	"github.com/ivan1993spb/snake-server/playground"
 	"github.com/ivan1993spb/snake-server/world"
 )
 
@@ -60,6 +62,26 @@ func NewApple(world world.Interface) (*Apple, error) {
 	return apple, nil
 }
 
+// RestoreApple locates an apple with the given identifier at the dot. The
+// identifier must have been obtained in the world's registry beforehand.
+func RestoreApple(world world.Interface, id world.Identifier, dot engine.Dot) (*Apple, error) {
+	apple := &Apple{
+		id:    id,
+		world: world,
+		dot:   dot,
+		mux:   &sync.RWMutex{},
+	}
+
+	apple.mux.Lock()
+	defer apple.mux.Unlock()
+
+	if err := world.CreateObject(apple, engine.Location{dot}); err != nil {
+		return nil, errCreateApple(err.Error())
+	}
+
+	return apple, nil
+}
+
 func (a *Apple) String() string {
 	a.mux.RLock()
 	defer a.mux.RUnlock()
@@ -72,19 +94,21 @@ func (e errAppleBite) Error() string {
 	return "apple bite error: " + string(e)
 }
 
-func (a *Apple) Bite(dot engine.Dot) (nv uint16, success bool, err error) {
-	a.mux.RLock()
-	defer a.mux.RUnlock()
+func (a *Apple) Bite(dot engine.Dot, commit objects.Commit) (success bool, err error) {
+	a.mux.Lock()
+	defer a.mux.Unlock()
 
 	if a.dot.Equals(dot) {
-		a.world.IdentifierRegistry().Release(a.id)
-		if err := a.world.DeleteObject(a, engine.Location{a.dot}); err != nil {
-			return 0, false, errAppleBite(err.Error())
+		if err := commit(appleNutritionalValue, []playground.Operation{
+			playground.DeleteOperation(a, engine.Location{a.dot}),
+		}); err != nil {
+			return false, errAppleBite(err.Error())
 		}
-		return appleNutritionalValue, true, nil
+		a.world.IdentifierRegistry().Release(a.id)
+		return true, nil
 	}
 
-	return 0, false, errAppleBite("apple does not contain dot")
+	return false, errAppleBite("apple does not contain dot")
 }
 
 func (a *Apple) MarshalJSON() ([]byte, error) {
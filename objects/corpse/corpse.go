@@ -9,22 +9,53 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/ivan1993spb/snake-server/engine"
+	"github.com/ivan1993spb/snake-server/objects"
+	"github.com/ivan1993spb/snake-server/playground"
 	"github.com/ivan1993spb/snake-server/world"
 )
 
-// Time for which corpse will be lie on playground
-const corpseMaxExperience = time.Second * 15
+// Period after which a corpse loses its tail dot by default
+const corpseDecayInterval = time.Second * 2
 
+// Period after which a corpse loses a unit of nutritional value by default
+const corpseFreshnessInterval = time.Second * 5
+
+// Nutritional value of a fresh corpse by default
 const corpseNutritionalValue uint16 = 2
 
+// Stale corpses are still edible
+const corpseMinNutritionalValue uint16 = 1
+
 const corpseTypeLabel = "corpse"
 
+// Config defines how fast corpses decay and go stale
+type Config struct {
+	// DecayInterval is a period after which a corpse loses its tail dot
+	DecayInterval time.Duration
+	// FreshnessInterval is a period after which a corpse loses a unit of
+	// nutritional value
+	FreshnessInterval time.Duration
+	// NutritionalValue is the nutritional value of a fresh corpse
+	NutritionalValue uint16
+}
+
+// DefaultConfig returns the corpse configuration by default
+func DefaultConfig() Config {
+	return Config{
+		DecayInterval:     corpseDecayInterval,
+		FreshnessInterval: corpseFreshnessInterval,
+		NutritionalValue:  corpseNutritionalValue,
+	}
+}
+
 // Snakes can eat corpses
 // ffjson: skip
 type Corpse struct {
 	id       world.Identifier
 	world    world.Interface
 	location engine.Location
+	config   Config
+	born     time.Time
 	mux      *sync.RWMutex
 	stop     chan struct{}
 	stopper  *sync.Once
@@ -37,14 +68,20 @@ func (e errCreateCorpse) Error() string {
 }
 
 // Corpse are created when a snake dies
-func NewCorpse(world world.Interface, location engine.Location) (*Corpse, error) {
+func NewCorpse(world world.Interface, location engine.Location, config Config) (*Corpse, error) {
 	if location.Empty() {
 		return nil, errCreateCorpse("location is empty")
 	}
 
+	if config.DecayInterval <= 0 {
+		return nil, errCreateCorpse("invalid decay interval")
+	}
+
 	corpse := &Corpse{
 		id:      world.IdentifierRegistry().Obtain(),
 		world:   world,
+		config:  config,
+		born:    time.Now(),
 		mux:     &sync.RWMutex{},
 		stop:    make(chan struct{}),
 		stopper: &sync.Once{},
@@ -53,21 +90,61 @@ func NewCorpse(world world.Interface, location engine.Location) (*Corpse, error)
 	corpse.mux.Lock()
 	defer corpse.mux.Unlock()
 
-	location, err := world.CreateObjectAvailableDots(corpse, location)
+	locatedDots, err := world.CreateObjectAvailableDots(corpse, location)
 	if err != nil {
 		world.IdentifierRegistry().Release(corpse.id)
 		return nil, errCreateCorpse(err.Error())
 	}
 
-	if location.Empty() {
+	if locatedDots.Empty() {
 		world.IdentifierRegistry().Release(corpse.id)
-		if err := world.DeleteObject(corpse, location); err != nil {
+		if err := world.DeleteObject(corpse, locatedDots); err != nil {
 			return nil, errCreateCorpse("no location located and cannot delete corpse")
 		}
 		return nil, errCreateCorpse("no location located")
 	}
 
-	corpse.location = location
+	// The playground doesn't keep the order of dots, but a corpse must know
+	// where its tail is to decay from it.
+	corpse.location = make(engine.Location, 0, len(locatedDots))
+	for _, dot := range location {
+		if locatedDots.Contains(dot) {
+			corpse.location = append(corpse.location, dot)
+		}
+	}
+
+	return corpse, nil
+}
+
+// RestoreCorpse locates a fresh corpse with the given identifier at the
+// location. The identifier must have been obtained in the world's registry
+// beforehand.
+func RestoreCorpse(world world.Interface, id world.Identifier, location engine.Location, config Config) (*Corpse, error) {
+	if location.Empty() {
+		return nil, errCreateCorpse("location is empty")
+	}
+
+	if config.DecayInterval <= 0 {
+		return nil, errCreateCorpse("invalid decay interval")
+	}
+
+	corpse := &Corpse{
+		id:       id,
+		world:    world,
+		location: location.Copy(),
+		config:   config,
+		born:     time.Now(),
+		mux:      &sync.RWMutex{},
+		stop:     make(chan struct{}),
+		stopper:  &sync.Once{},
+	}
+
+	corpse.mux.Lock()
+	defer corpse.mux.Unlock()
+
+	if err := world.CreateObject(corpse, corpse.location); err != nil {
+		return nil, errCreateCorpse(err.Error())
+	}
 
 	return corpse, nil
 }
@@ -78,77 +155,134 @@ func (c *Corpse) String() string {
 	return fmt.Sprint("corpse ", c.location)
 }
 
+// unsafeNutritionalValue returns the nutritional value of the corpse
+// depending on its freshness
+func (c *Corpse) unsafeNutritionalValue() uint16 {
+	if c.config.NutritionalValue <= corpseMinNutritionalValue {
+		return corpseMinNutritionalValue
+	}
+
+	if c.config.FreshnessInterval <= 0 {
+		return c.config.NutritionalValue
+	}
+
+	loss := time.Since(c.born) / c.config.FreshnessInterval
+	if loss >= time.Duration(c.config.NutritionalValue-corpseMinNutritionalValue) {
+		return corpseMinNutritionalValue
+	}
+
+	return c.config.NutritionalValue - uint16(loss)
+}
+
 type errCorpseBite string
 
 func (e errCorpseBite) Error() string {
 	return "corpse bite error: " + string(e)
 }
 
-func (c *Corpse) Bite(dot engine.Dot) (nv uint16, success bool, err error) {
+func (c *Corpse) Bite(dot engine.Dot, commit objects.Commit) (success bool, err error) {
 	c.mux.Lock()
 	defer c.mux.Unlock()
 
 	if c.location.Contains(dot) {
+		nv := c.unsafeNutritionalValue()
 		newDots := c.location.Delete(dot)
 
 		if len(newDots) > 0 {
-			newLocation, err := c.world.UpdateObjectAvailableDots(c, c.location, newDots)
-			if err != nil {
-				return 0, false, errCorpseBite(err.Error())
-			}
-			if len(newLocation) > 0 {
-				c.location = newLocation
-				return corpseNutritionalValue, true, nil
+			if err := commit(nv, []playground.Operation{
+				playground.UpdateOperation(c, c.location, newDots),
+			}); err != nil {
+				return false, errCorpseBite(err.Error())
 			}
+			c.location = newDots
+			return true, nil
 		}
 
-		var err error
+		if err := commit(nv, []playground.Operation{
+			playground.DeleteOperation(c, c.location),
+		}); err != nil {
+			return false, errCorpseBite(err.Error())
+		}
 
 		c.stopper.Do(func() {
 			close(c.stop)
 			c.world.IdentifierRegistry().Release(c.id)
-			err = c.world.DeleteObject(c, c.location)
 		})
+		c.location = c.location[:0]
+
+		return true, nil
+	}
 
-		if err != nil {
-			return 0, false, errCorpseBite(err.Error())
+	return false, nil
+}
+
+// unsafeVanish deletes the corpse from the world
+func (c *Corpse) unsafeVanish() error {
+	var err error
+
+	c.stopper.Do(func() {
+		close(c.stop)
+		c.world.IdentifierRegistry().Release(c.id)
+		err = c.world.DeleteObject(c, c.location)
+	})
+
+	c.location = c.location[:0]
+
+	return err
+}
+
+type errCorpseDecay string
+
+func (e errCorpseDecay) Error() string {
+	return "corpse decay error: " + string(e)
+}
+
+// decay deletes the tail dot of the corpse. It returns true when the corpse
+// has fully decayed.
+func (c *Corpse) decay() (bool, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if len(c.location) <= 1 {
+		if err := c.unsafeVanish(); err != nil {
+			return true, errCorpseDecay(err.Error())
 		}
+		return true, nil
+	}
 
-		c.location = c.location[:0]
+	newLocation := c.location[:len(c.location)-1].Copy()
 
-		return corpseNutritionalValue, true, nil
+	if err := c.world.UpdateObject(c, c.location, newLocation); err != nil {
+		return false, errCorpseDecay(err.Error())
 	}
 
-	return 0, false, nil
+	c.location = newLocation
+
+	return false, nil
 }
 
 func (c *Corpse) Run(stop <-chan struct{}, logger logrus.FieldLogger) {
 	go func() {
-		var timer = time.NewTimer(corpseMaxExperience)
-		defer timer.Stop()
-		select {
-		case <-stop:
-			// global stop
-		case <-timer.C:
-			c.mux.Lock()
-
-			var err error
-
-			c.stopper.Do(func() {
-				close(c.stop)
-				c.world.IdentifierRegistry().Release(c.id)
-				err = c.world.DeleteObject(c, c.location)
-			})
-
-			if err != nil {
-				logger.WithError(err).Error("corpse stop error")
+		var ticker = time.NewTicker(c.config.DecayInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				// global stop
+				return
+			case <-ticker.C:
+				done, err := c.decay()
+				if err != nil {
+					logger.WithError(err).Error("corpse decay error")
+				}
+				if done {
+					return
+				}
+			case <-c.stop:
+				// Corpse was eaten.
+				return
 			}
-
-			c.location = c.location[:0]
-
-			c.mux.Unlock()
-		case <-c.stop:
-			// Corpse was eaten.
 		}
 	}()
 }
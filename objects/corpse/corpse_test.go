@@ -3,13 +3,25 @@ package corpse
 import (
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/ivan1993spb/snake-server/engine"
+	"github.com/ivan1993spb/snake-server/playground"
 	"github.com/ivan1993spb/snake-server/world"
 )
 
+// bite bites the corpse applying the operations of the bite alone
+func bite(w world.Interface, corpse *Corpse, dot engine.Dot) (uint16, bool, error) {
+	var nutritionalValue uint16
+	ok, err := corpse.Bite(dot, func(nv uint16, operations []playground.Operation) error {
+		nutritionalValue = nv
+		return w.Transaction(operations)
+	})
+	return nutritionalValue, ok, err
+}
+
 func Test_NewCorpse_CreatesCorpseAndLocatesObject(t *testing.T) {
 	w, err := world.NewWorld(100, 100)
 	require.Nil(t, err, "cannot initialize world")
@@ -20,7 +32,7 @@ func Test_NewCorpse_CreatesCorpseAndLocatesObject(t *testing.T) {
 		engine.Dot{9, 0},
 		engine.Dot{8, 0},
 		engine.Dot{7, 0},
-	})
+	}, DefaultConfig())
 	require.Nil(t, err)
 	require.True(t, corpse.location.Equals(engine.Location{
 		engine.Dot{10, 0},
@@ -43,8 +55,10 @@ func Test_Corpse_Bite_ReturnsValidNutritionalValue(t *testing.T) {
 			engine.Dot{8, 0},
 			engine.Dot{7, 0},
 		},
-		mux:  &sync.RWMutex{},
-		stop: make(chan struct{}),
+		config: DefaultConfig(),
+		born:   time.Now(),
+		mux:    &sync.RWMutex{},
+		stop:   make(chan struct{}),
 	}
 
 	err = w.CreateObject(corpse, engine.Location{
@@ -55,7 +69,7 @@ func Test_Corpse_Bite_ReturnsValidNutritionalValue(t *testing.T) {
 	})
 	require.Nil(t, err, "cannot create object")
 
-	nutritionalValue, ok, err := corpse.Bite(engine.Dot{10, 0})
+	nutritionalValue, ok, err := bite(w, corpse, engine.Dot{10, 0})
 	require.Nil(t, err)
 	require.True(t, ok)
 	require.Equal(t, corpseNutritionalValue, nutritionalValue)
@@ -79,8 +93,10 @@ func Test_Corpse_Bite_ReturnsZeroForInvalidDot(t *testing.T) {
 			engine.Dot{8, 0},
 			engine.Dot{7, 0},
 		},
-		mux:  &sync.RWMutex{},
-		stop: make(chan struct{}),
+		config: DefaultConfig(),
+		born:   time.Now(),
+		mux:    &sync.RWMutex{},
+		stop:   make(chan struct{}),
 	}
 
 	err = w.CreateObject(corpse, engine.Location{
@@ -91,7 +107,7 @@ func Test_Corpse_Bite_ReturnsZeroForInvalidDot(t *testing.T) {
 	})
 	require.Nil(t, err, "cannot create object")
 
-	nutritionalValue, ok, err := corpse.Bite(engine.Dot{10, 10})
+	nutritionalValue, ok, err := bite(w, corpse, engine.Dot{10, 10})
 	require.Nil(t, err)
 	require.False(t, ok)
 	require.Equal(t, uint16(0), nutritionalValue)
@@ -102,3 +118,92 @@ func Test_Corpse_Bite_ReturnsZeroForInvalidDot(t *testing.T) {
 		engine.Dot{7, 0},
 	}, corpse.location)
 }
+
+func Test_NewCorpse_KeepsDotsOrder(t *testing.T) {
+	w, err := world.NewWorld(100, 100)
+	require.Nil(t, err, "cannot initialize world")
+	require.NotNil(t, w, "cannot initialize world")
+
+	location := engine.Location{
+		engine.Dot{10, 5},
+		engine.Dot{10, 4},
+		engine.Dot{10, 3},
+		engine.Dot{11, 3},
+		engine.Dot{12, 3},
+	}
+
+	corpse, err := NewCorpse(w, location, DefaultConfig())
+	require.Nil(t, err)
+	require.True(t, corpse.location.EqualsStrict(location))
+}
+
+func Test_Corpse_Bite_ReturnsLessNutritionalValueForStaleCorpse(t *testing.T) {
+	w, err := world.NewWorld(100, 100)
+	require.Nil(t, err, "cannot initialize world")
+	require.NotNil(t, w, "cannot initialize world")
+
+	config := Config{
+		DecayInterval:     time.Second,
+		FreshnessInterval: time.Second,
+		NutritionalValue:  5,
+	}
+
+	corpse := &Corpse{
+		world: w,
+		location: engine.Location{
+			engine.Dot{10, 0},
+			engine.Dot{9, 0},
+			engine.Dot{8, 0},
+		},
+		config: config,
+		born:   time.Now().Add(-time.Second*2 - time.Millisecond*500),
+		mux:    &sync.RWMutex{},
+		stop:   make(chan struct{}),
+	}
+
+	err = w.CreateObject(corpse, engine.Location{
+		engine.Dot{10, 0},
+		engine.Dot{9, 0},
+		engine.Dot{8, 0},
+	})
+	require.Nil(t, err, "cannot create object")
+
+	nutritionalValue, ok, err := bite(w, corpse, engine.Dot{10, 0})
+	require.Nil(t, err)
+	require.True(t, ok)
+	require.Equal(t, uint16(3), nutritionalValue)
+
+	corpse.born = time.Now().Add(-time.Hour)
+
+	nutritionalValue, ok, err = bite(w, corpse, engine.Dot{9, 0})
+	require.Nil(t, err)
+	require.True(t, ok)
+	require.Equal(t, corpseMinNutritionalValue, nutritionalValue)
+}
+
+func Test_Corpse_decay_DeletesTailDot(t *testing.T) {
+	w, err := world.NewWorld(100, 100)
+	require.Nil(t, err, "cannot initialize world")
+	require.NotNil(t, w, "cannot initialize world")
+
+	corpse, err := NewCorpse(w, engine.Location{
+		engine.Dot{10, 0},
+		engine.Dot{9, 0},
+	}, DefaultConfig())
+	require.Nil(t, err)
+
+	done, err := corpse.decay()
+	require.Nil(t, err)
+	require.False(t, done)
+	require.True(t, corpse.location.EqualsStrict(engine.Location{
+		engine.Dot{10, 0},
+	}))
+	require.Nil(t, w.GetObjectByDot(engine.Dot{9, 0}))
+
+	done, err = corpse.decay()
+	require.Nil(t, err)
+	require.True(t, done)
+	require.Empty(t, corpse.location)
+	require.Nil(t, w.GetObjectByDot(engine.Dot{10, 0}))
+	require.Empty(t, w.GetObjects())
+}
@@ -1,14 +1,25 @@
 package objects
 
-import "github.com/ivan1993spb/snake-server/engine"
+import (
+	"errors"
+
+	"github.com/ivan1993spb/snake-server/engine"
+	"github.com/ivan1993spb/snake-server/playground"
+)
+
+// Commit applies the operations of an interaction together with the changes
+// of the initiator of the interaction atomically. The nutritional value nv is
+// what the initiator gets from the interaction
+type Commit func(nv uint16, operations []playground.Operation) error
 
 // Food interface describes methods which must be implemented by all edible
 // objects
 type Food interface {
-	// Bite bites an object at the passed dot and returns the nutritional
-	// value nv, success flag true if the dot has been released or an error err
-	// if one occurred
-	Bite(dot engine.Dot) (nv uint16, success bool, err error)
+	// Bite bites an object at the passed dot. The operations of the bite are
+	// applied by the function commit, the object is changed only if commit
+	// succeeds. Bite returns success flag true if the dot has been released
+	// or an error err if one occurred
+	Bite(dot engine.Dot, commit Commit) (success bool, err error)
 }
 
 // Alive interface describes methods which must be implemented by all living
@@ -16,10 +27,15 @@ type Food interface {
 type Alive interface {
 	// Hit hits an object at the passed dot with the given force force and
 	// returns success flag true if the dot has been released or an error err
-	// if one occurred
+	// if one occurred. The error is ErrInvulnerable if the object cannot be
+	// hit for a while
 	Hit(dot engine.Dot, force float64) (success bool, err error)
 }
 
+// ErrInvulnerable is returned by Hit if the object cannot be hit for a while.
+// The initiator of the hit waits instead of failing the interaction
+var ErrInvulnerable = errors.New("object is invulnerable")
+
 // Breakable interface describes methods that must be implemented by all
 // objects which could be broken
 type Breakable interface {
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/ivan1993spb/snake-server/engine"
+	"github.com/ivan1993spb/snake-server/objects"
+	"github.com/ivan1993spb/snake-server/playground"
 	"github.com/ivan1993spb/snake-server/world"
 )
 
@@ -67,6 +69,36 @@ func NewMouse(world world.Interface) (*Mouse, error) {
 	return mouse, nil
 }
 
+// RestoreMouse locates a mouse with the given identifier and direction at
+// the dot. The identifier must have been obtained in the world's registry
+// beforehand.
+func RestoreMouse(world world.Interface, id world.Identifier, dot engine.Dot, direction engine.Direction) (*Mouse, error) {
+	if !engine.ValidDirection(direction) {
+		return nil, errCreateMouse("invalid direction")
+	}
+
+	mouse := &Mouse{
+		id: id,
+
+		dot:       dot,
+		direction: direction,
+
+		world: world,
+		mux:   &sync.RWMutex{},
+
+		stop: make(chan struct{}),
+	}
+
+	mouse.mux.Lock()
+	defer mouse.mux.Unlock()
+
+	if err := world.CreateObject(mouse, engine.Location{dot}); err != nil {
+		return nil, errCreateMouse(err.Error())
+	}
+
+	return mouse, nil
+}
+
 const mouseNutritionalValue uint16 = 15
 
 type errMouseBite string
@@ -75,20 +107,21 @@ func (e errMouseBite) Error() string {
 	return "mouse bite error: " + string(e)
 }
 
-func (m *Mouse) Bite(dot engine.Dot) (nv uint16, success bool, err error) {
-	m.mux.RLock()
-	defer m.mux.RUnlock()
+func (m *Mouse) Bite(dot engine.Dot, commit objects.Commit) (success bool, err error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
 
 	if m.dot.Equals(dot) {
-		m.die()
-
-		if err := m.world.DeleteObject(m, engine.Location{m.dot}); err != nil {
-			return 0, false, errMouseBite(err.Error())
+		if err := commit(mouseNutritionalValue, []playground.Operation{
+			playground.DeleteOperation(m, engine.Location{m.dot}),
+		}); err != nil {
+			return false, errMouseBite(err.Error())
 		}
-		return mouseNutritionalValue, true, nil
+		m.die()
+		return true, nil
 	}
 
-	return 0, false, errMouseBite("mouse does not contain dot")
+	return false, errMouseBite("mouse does not contain dot")
 }
 
 func (m *Mouse) String() string {
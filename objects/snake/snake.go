@@ -12,6 +12,7 @@ import (
 
 	"github.com/ivan1993spb/snake-server/engine"
 	"github.com/ivan1993spb/snake-server/objects"
+	"github.com/ivan1993spb/snake-server/playground"
 	"github.com/ivan1993spb/snake-server/world"
 )
 
@@ -24,13 +25,35 @@ const (
 	snakeStartLength = 3
 	snakeStartMargin = 1
 
+	// snakeMaxInteractionRetries limits hits and breaks in one move. A hit
+	// or a break releases the dot before the snake moves, so another object
+	// can take the dot in between. Food is bitten in the same transaction
+	// with the move, so bites are never retried
 	snakeMaxInteractionRetries = 5
 
 	hitStrengthExp = 2
 
 	snakeHitAward = 3
+
+	// snakeInvulnerability is the invulnerability window of new snakes by default
+	snakeInvulnerability = time.Second * 2
 )
 
+// Config defines how snakes are spawned
+// ffjson: skip
+type Config struct {
+	// Invulnerability is a period after spawning during which a snake cannot
+	// be hit and does not die on unsuccessful interactions. Zero disables it
+	Invulnerability time.Duration
+}
+
+// DefaultConfig returns the snake configuration by default
+func DefaultConfig() Config {
+	return Config{
+		Invulnerability: snakeInvulnerability,
+	}
+}
+
 type Command string
 
 const (
@@ -54,25 +77,35 @@ type Snake struct {
 
 	world world.Interface
 
+	// player is a name of the player who controls the snake
+	player string
+	born   time.Time
+	kills  uint16
+
 	location engine.Location
 	length   uint16
 
 	direction engine.Direction
 
+	config Config
+
 	mux *sync.RWMutex
 
 	stopper *sync.Once
 	stop    chan struct{}
 }
 
-// NewSnake creates new snake
-func NewSnake(world world.Interface) (*Snake, error) {
+// NewSnake creates new snake for a player with the given name
+func NewSnake(world world.Interface, player string, config Config) (*Snake, error) {
 	snake := &Snake{
 		id:        world.IdentifierRegistry().Obtain(),
 		world:     world,
+		player:    player,
+		born:      time.Now(),
 		location:  make(engine.Location, snakeStartLength),
 		length:    snakeStartLength,
 		direction: engine.RandomDirection(),
+		config:    config,
 		mux:       &sync.RWMutex{},
 		stopper:   &sync.Once{},
 		stop:      make(chan struct{}),
@@ -92,10 +125,20 @@ func (e errSnakeInitLocate) Error() string {
 	return "snake initial locate error: " + string(e)
 }
 
+// initLocate locates the snake at the best of scored random locations. If
+// there are no vacant candidates, a random location with a margin is taken
 func (s *Snake) initLocate() error {
 	s.mux.Lock()
 	defer s.mux.Unlock()
 
+	for _, candidate := range spawnCandidates(s.world, snakeSpawnCandidates) {
+		if err := s.world.CreateObject(s, candidate.location); err == nil {
+			s.location = candidate.location
+			s.direction = candidate.direction
+			return nil
+		}
+	}
+
 	var err error
 	var location engine.Location
 
@@ -156,6 +199,33 @@ func (s *Snake) feed(f uint16) {
 	}
 }
 
+func (s *Snake) kill() {
+	s.mux.Lock()
+	s.kills++
+	s.mux.Unlock()
+}
+
+// Stats contains achievements of a snake
+// ffjson: skip
+type Stats struct {
+	Player string
+	Length uint16
+	Kills  uint16
+	Born   time.Time
+}
+
+// Stats returns the snake's achievements
+func (s *Snake) Stats() Stats {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return Stats{
+		Player: s.player,
+		Length: s.length,
+		Kills:  s.kills,
+		Born:   s.born,
+	}
+}
+
 type errSnakeHit string
 
 func (e errSnakeHit) Error() string {
@@ -167,6 +237,10 @@ func (s *Snake) Hit(dot engine.Dot, force float64) (success bool, err error) {
 	defer s.mux.Unlock()
 
 	if s.location.Contains(dot) {
+		if s.unsafeInvulnerable() {
+			return false, objects.ErrInvulnerable
+		}
+
 		if force >= math.Pow(s.unsafeGetForce(), hitStrengthExp) {
 			newLocation := s.location.Delete(dot)
 			if err := s.world.UpdateObject(s, s.location, newLocation); err != nil {
@@ -188,6 +262,17 @@ func (s *Snake) Hit(dot engine.Dot, force float64) (success bool, err error) {
 	return false, errSnakeHit("snake does not contain dot")
 }
 
+// unsafeInvulnerable returns true during the invulnerability window after spawning
+func (s *Snake) unsafeInvulnerable() bool {
+	return s.config.Invulnerability > 0 && time.Since(s.born) < s.config.Invulnerability
+}
+
+func (s *Snake) invulnerable() bool {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.unsafeInvulnerable()
+}
+
 func (s *Snake) unsafeGetForce() float64 {
 	return float64(s.length)
 }
@@ -255,16 +340,40 @@ func (s *Snake) move() error {
 	retries := 0
 
 	for {
-		if object := s.world.GetObjectByDot(dot); object != nil {
-			if success, err := s.interactObject(object, dot); err != nil {
-				return errSnakeMove(err.Error())
-			} else if !success {
-				return errUnsuccessfulInteraction
-			}
-		} else {
+		object := s.world.GetObjectByDot(dot)
+		if object == nil {
 			break
 		}
 
+		var success bool
+
+		food, isFood := object.(objects.Food)
+		if isFood {
+			success, err = food.Bite(dot, s.commitBite(dot))
+		} else {
+			success, err = s.interactObject(object, dot)
+		}
+
+		if err == errInteractObjectInvulnerable {
+			// The snake waits for the other snake to become vulnerable
+			// instead of dying, so new snakes are not lethal obstacles
+			return nil
+		}
+		if err != nil {
+			return errSnakeMove(err.Error())
+		}
+		if !success {
+			if s.invulnerable() {
+				// An invulnerable snake waits instead of dying
+				return nil
+			}
+			return errUnsuccessfulInteraction
+		}
+		if isFood {
+			// The snake has moved in the transaction of the bite
+			return nil
+		}
+
 		if retries >= snakeMaxInteractionRetries {
 			return errSnakeMove("interaction retries limit reached")
 		}
@@ -275,50 +384,78 @@ func (s *Snake) move() error {
 	s.mux.Lock()
 	defer s.mux.Unlock()
 
-	tmpLocation := make(engine.Location, len(s.location)+1)
-	copy(tmpLocation[1:], s.location)
-	tmpLocation[0] = dot
+	location := s.unsafeNextLocation(dot, s.length)
 
-	if s.length < uint16(len(tmpLocation)) {
-		tmpLocation = tmpLocation[:len(tmpLocation)-1]
-	}
-
-	if err := s.world.UpdateObject(s, s.location, tmpLocation); err != nil {
+	if err := s.world.UpdateObject(s, s.location, location); err != nil {
 		return fmt.Errorf("update snake error: %s", err)
 	}
 
-	s.location = tmpLocation
+	s.location = location
 
 	return nil
 }
 
+// unsafeNextLocation returns the location of the snake with the head at the
+// dot and the given length
+func (s *Snake) unsafeNextLocation(dot engine.Dot, length uint16) engine.Location {
+	location := make(engine.Location, len(s.location)+1)
+	copy(location[1:], s.location)
+	location[0] = dot
+
+	if length < uint16(len(location)) {
+		location = location[:len(location)-1]
+	}
+
+	return location
+}
+
+// commitBite returns a function which feeds the snake and moves its head to
+// the bitten dot in one transaction with the operations of the bite
+func (s *Snake) commitBite(dot engine.Dot) objects.Commit {
+	return func(nv uint16, operations []playground.Operation) error {
+		s.mux.Lock()
+		defer s.mux.Unlock()
+
+		length := s.length + nv
+		location := s.unsafeNextLocation(dot, length)
+
+		operations = append(operations, playground.UpdateOperation(s, s.location, location))
+		if err := s.world.Transaction(operations); err != nil {
+			return err
+		}
+
+		s.length = length
+		s.location = location
+
+		return nil
+	}
+}
+
 type errInteractObject string
 
 func (e errInteractObject) Error() string {
 	return "object interaction error: " + string(e)
 }
 
-var errInteractObjectUnexpectedType = errInteractObject("unexpected object type")
+var (
+	errInteractObjectUnexpectedType = errInteractObject("unexpected object type")
+	errInteractObjectInvulnerable   = errInteractObject("object is invulnerable")
+)
 
 func (s *Snake) interactObject(object interface{}, dot engine.Dot) (success bool, err error) {
-	if food, ok := object.(objects.Food); ok {
-		nv, success, err := food.Bite(dot)
-		if err != nil {
-			return false, errInteractObject(err.Error())
-		}
-		if success {
-			s.feed(nv)
-		}
-		return success, nil
-	}
-
 	if alive, ok := object.(objects.Alive); ok {
 		success, err := alive.Hit(dot, s.getForce())
+		if err == objects.ErrInvulnerable {
+			return false, errInteractObjectInvulnerable
+		}
 		if err != nil {
 			return false, errInteractObject(err.Error())
 		}
 		if success {
 			s.feed(snakeHitAward)
+			if _, ok := alive.(*Snake); ok {
+				s.kill()
+			}
 		}
 		return success, nil
 	}
@@ -416,9 +553,10 @@ func (s *Snake) MarshalJSON() ([]byte, error) {
 	s.mux.RLock()
 	defer s.mux.RUnlock()
 	return ffjson.Marshal(&snake{
-		ID:   s.id,
-		Dots: s.location,
-		Type: snakeTypeLabel,
+		ID:           s.id,
+		Dots:         s.location,
+		Type:         snakeTypeLabel,
+		Invulnerable: s.unsafeInvulnerable(),
 	})
 }
 
@@ -429,4 +567,6 @@ type snake struct {
 	ID   world.Identifier `json:"id"`
 	Dots []engine.Dot     `json:"dots,omitempty"`
 	Type string           `json:"type"`
+	// Invulnerable is set during the invulnerability window after spawning
+	Invulnerable bool `json:"invulnerable,omitempty"`
 }
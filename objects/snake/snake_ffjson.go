@@ -31,7 +31,7 @@ func (j *snake) MarshalJSONBuf(buf fflib.EncodingBuffer) error {
 	var obj []byte
 	_ = obj
 	_ = err
-	buf.WriteString(`{"id":`)
+	buf.WriteString(`{ "id":`)
 	fflib.FormatBits2(buf, uint64(j.ID), 10, false)
 	buf.WriteByte(',')
 	if len(j.Dots) != 0 {
@@ -61,6 +61,16 @@ func (j *snake) MarshalJSONBuf(buf fflib.EncodingBuffer) error {
 	}
 	buf.WriteString(`"type":`)
 	fflib.WriteJsonString(buf, string(j.Type))
+	buf.WriteByte(',')
+	if j.Invulnerable != false {
+		if j.Invulnerable {
+			buf.WriteString(`"invulnerable":true`)
+		} else {
+			buf.WriteString(`"invulnerable":false`)
+		}
+		buf.WriteByte(',')
+	}
+	buf.Rewind(1)
 	buf.WriteByte('}')
 	return nil
 }
@@ -3,15 +3,29 @@ package snake
 import (
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/ivan1993spb/snake-server/engine"
+	"github.com/ivan1993spb/snake-server/objects"
+	"github.com/ivan1993spb/snake-server/objects/apple"
 	"github.com/ivan1993spb/snake-server/world"
 )
 
 func Test_NewSnake(t *testing.T) {
+	world, err := world.NewWorld(100, 100)
+	require.Nil(t, err, "cannot initialize world")
+
+	snake, err := NewSnake(world, "player", DefaultConfig())
+	require.Nil(t, err)
+	require.Len(t, snake.location, snakeStartLength)
+	require.Equal(t, []engine.Object{snake}, world.GetObjects())
 
+	// The snake moves away from its tail
+	dot, err := snake.getNextHeadDot()
+	require.Nil(t, err)
+	require.False(t, snake.location.Contains(dot))
 }
 
 func Test_Snake_calculateDelay_ReturnsNotZero(t *testing.T) {
@@ -236,3 +250,191 @@ func Test_Snake_move_validLocation(t *testing.T) {
 		{11, 0},
 	}, snake.location)
 }
+
+func Test_Snake_move_BitesFoodInOneTransaction(t *testing.T) {
+	w, err := world.NewWorld(100, 100)
+	require.Nil(t, err, "cannot initialize world")
+
+	stop := make(chan struct{})
+	defer close(stop)
+	w.Start(stop)
+	events := w.Events(stop, 16)
+
+	snake := &Snake{
+		id:     w.IdentifierRegistry().Obtain(),
+		world:  w,
+		length: 3,
+		location: engine.Location{
+			{10, 0},
+			{9, 0},
+			{8, 0},
+		},
+		direction: engine.DirectionEast,
+		mux:       &sync.RWMutex{},
+	}
+	require.Nil(t, w.CreateObject(snake, snake.location))
+
+	a, err := apple.RestoreApple(w, w.IdentifierRegistry().Obtain(), engine.Dot{11, 0})
+	require.Nil(t, err)
+
+	require.Nil(t, snake.move())
+	require.Equal(t, engine.Location{
+		{11, 0},
+		{10, 0},
+		{9, 0},
+		{8, 0},
+	}, snake.location)
+	require.Equal(t, uint16(4), snake.length)
+	require.Equal(t, []engine.Object{snake}, w.GetObjects())
+	require.Equal(t, 1, w.IdentifierRegistry().Count())
+
+	var bite []world.Event
+	for len(bite) < 2 {
+		event := <-events
+		if event.Type == world.EventTypeObjectCreate || event.Type == world.EventTypeObjectChecked {
+			continue
+		}
+		bite = append(bite, event)
+	}
+
+	// The bite and the move are emitted together
+	require.Equal(t, world.EventTypeObjectDelete, bite[0].Type)
+	require.Equal(t, a, bite[0].Payload)
+	require.Equal(t, world.EventTypeObjectUpdate, bite[1].Type)
+	require.Equal(t, snake, bite[1].Payload)
+	require.Equal(t, bite[0].Seq+1, bite[1].Seq)
+}
+
+func Test_scoreSpawn(t *testing.T) {
+	world, err := world.NewWorld(100, 100)
+	require.Nil(t, err, "cannot initialize world")
+
+	snake := &Snake{
+		location: engine.Location{
+			{50, 50},
+			{49, 50},
+			{48, 50},
+		},
+		mux: &sync.RWMutex{},
+	}
+	require.Nil(t, world.CreateObject(snake, snake.location))
+
+	// Far from heads and nothing ahead
+	require.Equal(t, snakeSpawnHeadDistanceLimit+snakeSpawnRunAheadWeight*snakeSpawnRunAheadLimit,
+		scoreSpawn(world, engine.Dot{10, 10}, engine.DirectionEast))
+
+	// Close to the head
+	require.Equal(t, 3+snakeSpawnRunAheadWeight*snakeSpawnRunAheadLimit,
+		scoreSpawn(world, engine.Dot{50, 53}, engine.DirectionSouth))
+
+	// Close to the tail, the distance is measured to the head
+	require.Equal(t, 5, nearestHeadDistance(world, engine.Dot{48, 53}))
+
+	// An obstacle ahead
+	require.Nil(t, world.CreateObject(&struct{ n int }{}, engine.Location{{14, 10}}))
+	require.Equal(t, 3, runAhead(world, engine.Dot{10, 10}, engine.DirectionEast, snakeSpawnRunAheadLimit))
+	require.Equal(t, snakeSpawnHeadDistanceLimit+snakeSpawnRunAheadWeight*3,
+		scoreSpawn(world, engine.Dot{10, 10}, engine.DirectionEast))
+}
+
+func Test_spawnCandidates_SortedAndVacant(t *testing.T) {
+	world, err := world.NewWorld(30, 30)
+	require.Nil(t, err, "cannot initialize world")
+
+	_, err = NewSnake(world, "", DefaultConfig())
+	require.Nil(t, err)
+
+	candidates := spawnCandidates(world, snakeSpawnCandidates)
+	require.NotEmpty(t, candidates)
+
+	for i, candidate := range candidates {
+		require.Len(t, candidate.location, snakeStartLength)
+		require.False(t, world.LocationOccupied(candidate.location))
+		if i > 0 {
+			require.True(t, candidates[i-1].score >= candidate.score)
+		}
+	}
+}
+
+func Test_Snake_Hit_Invulnerable(t *testing.T) {
+	world, err := world.NewWorld(100, 100)
+	require.Nil(t, err, "cannot initialize world")
+
+	snake, err := NewSnake(world, "", Config{Invulnerability: time.Hour})
+	require.Nil(t, err)
+
+	data, err := snake.MarshalJSON()
+	require.Nil(t, err)
+	require.Contains(t, string(data), `"invulnerable":true`)
+
+	success, err := snake.Hit(snake.location[1], 1000)
+	require.Equal(t, objects.ErrInvulnerable, err)
+	require.False(t, success)
+	require.Len(t, snake.location, snakeStartLength)
+
+	snake.config.Invulnerability = 0
+
+	data, err = snake.MarshalJSON()
+	require.Nil(t, err)
+	require.NotContains(t, string(data), `invulnerable`)
+
+	success, err = snake.Hit(snake.location[1], 1000)
+	require.Nil(t, err)
+	require.True(t, success)
+	require.Len(t, snake.location, snakeStartLength-1)
+}
+
+func Test_Snake_move_WaitsForInvulnerableSnake(t *testing.T) {
+	w, err := world.NewWorld(100, 100)
+	require.Nil(t, err, "cannot initialize world")
+
+	attacker := &Snake{
+		id:     w.IdentifierRegistry().Obtain(),
+		world:  w,
+		length: 10,
+		location: engine.Location{
+			{10, 0},
+			{9, 0},
+			{8, 0},
+		},
+		direction: engine.DirectionEast,
+		mux:       &sync.RWMutex{},
+		stopper:   &sync.Once{},
+		stop:      make(chan struct{}),
+	}
+	require.Nil(t, w.CreateObject(attacker, attacker.location))
+
+	victim := &Snake{
+		id:     w.IdentifierRegistry().Obtain(),
+		world:  w,
+		born:   time.Now(),
+		length: 3,
+		location: engine.Location{
+			{11, 2},
+			{11, 1},
+			{11, 0},
+		},
+		direction: engine.DirectionSouth,
+		config:    Config{Invulnerability: time.Hour},
+		mux:       &sync.RWMutex{},
+		stopper:   &sync.Once{},
+		stop:      make(chan struct{}),
+	}
+	require.Nil(t, w.CreateObject(victim, victim.location))
+
+	// The attacker stops in front of the invulnerable snake instead of dying
+	require.Nil(t, attacker.move())
+	require.Equal(t, engine.Location{{10, 0}, {9, 0}, {8, 0}}, attacker.GetLocation())
+	require.Equal(t, engine.Location{{11, 2}, {11, 1}, {11, 0}}, victim.GetLocation())
+	require.Equal(t, uint16(0), attacker.Stats().Kills)
+
+	victim.mux.Lock()
+	victim.config.Invulnerability = 0
+	victim.mux.Unlock()
+
+	// The snake can be hit when the window is over
+	require.Nil(t, attacker.move())
+	require.Equal(t, engine.Dot{X: 11, Y: 0}, attacker.GetLocation()[0])
+	require.Equal(t, uint16(1), attacker.Stats().Kills)
+	require.Equal(t, engine.Location{{11, 2}, {11, 1}}, victim.GetLocation())
+}
@@ -0,0 +1,125 @@
+package snake
+
+import (
+	"sort"
+
+	"github.com/ivan1993spb/snake-server/engine"
+	"github.com/ivan1993spb/snake-server/world"
+)
+
+const (
+	// snakeSpawnCandidates is the number of random locations which are
+	// scored to spawn a snake
+	snakeSpawnCandidates = 16
+
+	// snakeSpawnHeadDistanceLimit limits the distance to the nearest head of
+	// another snake which is taken into account. Snakes further away are
+	// considered to be harmless
+	snakeSpawnHeadDistanceLimit = 20
+
+	// snakeSpawnRunAheadLimit limits the number of vacant dots in front of
+	// the head which are taken into account
+	snakeSpawnRunAheadLimit = 10
+
+	// snakeSpawnRunAheadWeight makes a dot of free run more important than
+	// a dot of distance to another head
+	snakeSpawnRunAheadWeight = 2
+)
+
+// spawnCandidate is a vacant location for a new snake with its head first
+type spawnCandidate struct {
+	location  engine.Location
+	direction engine.Direction
+	score     int
+}
+
+// newSpawnLocation returns a random location with the head first for a
+// snake which is going to move in the direction
+func newSpawnLocation(area engine.Area, direction engine.Direction) (engine.Location, error) {
+	var rw, rh uint8 = snakeStartLength, 1
+	if direction == engine.DirectionNorth || direction == engine.DirectionSouth {
+		rw, rh = 1, snakeStartLength
+	}
+
+	rect, err := area.NewRandomRect(rw, rh, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	location := rect.Location()
+	if direction == engine.DirectionSouth || direction == engine.DirectionEast {
+		location = location.Reverse()
+	}
+
+	return location, nil
+}
+
+// spawnCandidates returns random vacant locations for a snake sorted by score
+// in descending order
+func spawnCandidates(w world.Interface, count int) []spawnCandidate {
+	area := w.Area()
+	candidates := make([]spawnCandidate, 0, count)
+
+	for i := 0; i < count; i++ {
+		direction := engine.RandomDirection()
+
+		location, err := newSpawnLocation(area, direction)
+		if err != nil || w.LocationOccupied(location) {
+			continue
+		}
+
+		candidates = append(candidates, spawnCandidate{
+			location:  location,
+			direction: direction,
+			score:     scoreSpawn(w, location[0], direction),
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	return candidates
+}
+
+// nearestHeadDistance returns the distance from the dot to the nearest head of
+// a snake or the distance limit if there is no head closer. Only snakes within
+// the limit are looked up
+func nearestHeadDistance(w world.Interface, dot engine.Dot) int {
+	area := w.Area()
+
+	distance := snakeSpawnHeadDistanceLimit
+	for _, object := range w.GetObjectsInRadius(dot, snakeSpawnHeadDistanceLimit) {
+		if snake, ok := object.(*Snake); ok {
+			if location := snake.GetLocation(); len(location) > 0 {
+				if d := int(area.WrapDistance(dot, location[0])); d < distance {
+					distance = d
+				}
+			}
+		}
+	}
+
+	return distance
+}
+
+// scoreSpawn scores a spawn by the distance from the head to the nearest head
+// of another snake and by the number of vacant dots in front of the head
+func scoreSpawn(w world.Interface, head engine.Dot, direction engine.Direction) int {
+	return nearestHeadDistance(w, head) + snakeSpawnRunAheadWeight*runAhead(w, head, direction, snakeSpawnRunAheadLimit)
+}
+
+// runAhead returns the number of vacant dots in front of the head
+func runAhead(w world.Interface, head engine.Dot, direction engine.Direction, limit int) int {
+	area := w.Area()
+	dot := head
+
+	for i := 0; i < limit; i++ {
+		next, err := area.Navigate(dot, direction, 1)
+		if err != nil || w.LocationOccupied(engine.Location{next}) {
+			return i
+		}
+		dot = next
+	}
+
+	return limit
+}
@@ -0,0 +1,168 @@
+package wall
+
+import (
+	"fmt"
+
+	"github.com/ivan1993spb/snake-server/engine"
+	"github.com/ivan1993spb/snake-server/world"
+)
+
+// ErrDisconnectedFreeSpace means that walls enclose pockets of free space
+// which cannot be reached from the rest of the map
+type ErrDisconnectedFreeSpace struct {
+	Pockets int
+}
+
+func (e *ErrDisconnectedFreeSpace) Error() string {
+	return fmt.Sprintf("free space is disconnected: %d enclosed pockets", e.Pockets)
+}
+
+type ErrConnectWalls string
+
+func (e ErrConnectWalls) Error() string {
+	return "connect walls error: " + string(e)
+}
+
+func dotsSet(location engine.Location) map[engine.Dot]struct{} {
+	set := make(map[engine.Dot]struct{}, len(location))
+	for _, dot := range location {
+		set[dot] = struct{}{}
+	}
+	return set
+}
+
+// freeSpaceRegions returns the largest connected region of free space and
+// the pockets disconnected from it
+func freeSpaceRegions(area engine.Area, walls engine.Location) (engine.Location, []engine.Location) {
+	wallDots := dotsSet(walls)
+	passable := func(dot engine.Dot) bool {
+		_, ok := wallDots[dot]
+		return !ok
+	}
+
+	visited := make(map[engine.Dot]struct{}, area.Size())
+	regions := make([]engine.Location, 0)
+	largest := -1
+
+	for _, dot := range area.Dots() {
+		if _, ok := visited[dot]; ok || !passable(dot) {
+			continue
+		}
+
+		region := area.FloodFill(dot, passable)
+		for _, regionDot := range region {
+			visited[regionDot] = struct{}{}
+		}
+
+		regions = append(regions, region)
+		if largest < 0 || len(region) > len(regions[largest]) {
+			largest = len(regions) - 1
+		}
+	}
+
+	if largest < 0 {
+		return engine.Location{}, nil
+	}
+
+	largestRegion := regions[largest]
+	pockets := append(regions[:largest], regions[largest+1:]...)
+
+	return largestRegion, pockets
+}
+
+// FreeSpacePockets returns regions of free space which are disconnected from
+// the largest region by the walls
+func FreeSpacePockets(area engine.Area, walls engine.Location) []engine.Location {
+	_, pockets := freeSpaceRegions(area, walls)
+	return pockets
+}
+
+// ValidateConnectivity returns an error if the walls enclose pockets of free
+// space. It is used for generated ruins and for walls restored from snapshots
+func ValidateConnectivity(area engine.Area, walls engine.Location) error {
+	if pockets := FreeSpacePockets(area, walls); len(pockets) > 0 {
+		return &ErrDisconnectedFreeSpace{
+			Pockets: len(pockets),
+		}
+	}
+	return nil
+}
+
+// ConnectFreeSpace returns the dots of the walls to be removed to connect
+// all pockets of free space with the largest region. Every pocket is
+// connected with the nearest free dot through the walls
+func ConnectFreeSpace(area engine.Area, walls engine.Location) engine.Location {
+	connected, pockets := freeSpaceRegions(area, walls)
+	if len(pockets) == 0 {
+		return engine.Location{}
+	}
+
+	wallDots := dotsSet(walls)
+	removed := make(engine.Location, 0)
+	passableAll := func(dot engine.Dot) bool {
+		return true
+	}
+
+	for _, pocket := range pockets {
+		from := pocket[0]
+
+		to := connected[0]
+		for _, dot := range connected[1:] {
+			if area.WrapDistance(from, dot) < area.WrapDistance(from, to) {
+				to = dot
+			}
+		}
+
+		path, err := area.FindPath(from, to, passableAll)
+		if err != nil {
+			continue
+		}
+
+		for _, dot := range path {
+			if _, ok := wallDots[dot]; ok {
+				delete(wallDots, dot)
+				removed = append(removed, dot)
+			}
+		}
+
+		// Next pockets could be connected through the pocket
+		connected = append(connected, pocket...)
+		connected = append(connected, path...)
+	}
+
+	return removed
+}
+
+// ConnectWalls validates the connectivity of free space between the walls in
+// the world and breaks walls to connect enclosed pockets with the rest of the
+// map. It returns the number of broken dots
+func ConnectWalls(w world.Interface) (int, error) {
+	walls := make(map[engine.Dot]*Wall)
+	location := make(engine.Location, 0)
+
+	for _, object := range w.GetObjects() {
+		if wall, ok := object.(*Wall); ok {
+			wall.mux.RLock()
+			for _, dot := range wall.location {
+				walls[dot] = wall
+			}
+			location = append(location, wall.location...)
+			wall.mux.RUnlock()
+		}
+	}
+
+	if err := ValidateConnectivity(w.Area(), location); err == nil {
+		return 0, nil
+	}
+
+	broken := 0
+
+	for _, dot := range ConnectFreeSpace(w.Area(), location) {
+		if _, err := walls[dot].Break(dot, wallMinBreakForce); err != nil {
+			return broken, ErrConnectWalls(err.Error())
+		}
+		broken++
+	}
+
+	return broken, nil
+}
@@ -0,0 +1,107 @@
+package wall
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ivan1993spb/snake-server/engine"
+	"github.com/ivan1993spb/snake-server/world"
+)
+
+// ringLocation returns a closed ring of dots around the rect
+func ringLocation(x, y, w, h uint8) engine.Location {
+	location := make(engine.Location, 0)
+	for i := x; i < x+w; i++ {
+		location = append(location, engine.Dot{X: i, Y: y}, engine.Dot{X: i, Y: y + h - 1})
+	}
+	for j := y + 1; j < y+h-1; j++ {
+		location = append(location, engine.Dot{X: x, Y: j}, engine.Dot{X: x + w - 1, Y: j})
+	}
+	return location
+}
+
+func Test_ValidateConnectivity(t *testing.T) {
+	area := engine.MustArea(20, 20)
+
+	require.Nil(t, ValidateConnectivity(area, engine.Location{}))
+	require.Nil(t, ValidateConnectivity(area, engine.NewRect(2, 2, 5, 5).Location()))
+
+	walls := append(ringLocation(2, 2, 5, 5), ringLocation(10, 10, 4, 4)...)
+	err := ValidateConnectivity(area, walls)
+	require.Equal(t, &ErrDisconnectedFreeSpace{Pockets: 2}, err)
+
+	pockets := FreeSpacePockets(area, walls)
+	require.Len(t, pockets, 2)
+	require.Len(t, pockets[0], 9)
+	require.Len(t, pockets[1], 4)
+}
+
+func Test_ConnectFreeSpace(t *testing.T) {
+	area := engine.MustArea(20, 20)
+
+	require.Empty(t, ConnectFreeSpace(area, engine.NewRect(2, 2, 5, 5).Location()))
+
+	// A thick ring needs two dots to be broken
+	walls := append(ringLocation(2, 2, 7, 7), ringLocation(3, 3, 5, 5)...)
+	walls = append(walls, ringLocation(12, 12, 3, 3)...)
+
+	removed := ConnectFreeSpace(area, walls)
+	require.Len(t, removed, 3)
+
+	wallDots := dotsSet(walls)
+	for _, dot := range removed {
+		delete(wallDots, dot)
+	}
+	rest := make(engine.Location, 0, len(wallDots))
+	for dot := range wallDots {
+		rest = append(rest, dot)
+	}
+	require.Nil(t, ValidateConnectivity(area, rest))
+}
+
+func Test_RuinsGenerator_Connect(t *testing.T) {
+	w, err := world.NewWorld(20, 20)
+	require.Nil(t, err)
+
+	_, err = NewWallLocation(w, ringLocation(2, 2, 5, 5))
+	require.Nil(t, err)
+	_, err = NewWallLocation(w, ringLocation(10, 10, 4, 4))
+	require.Nil(t, err)
+
+	broken, err := NewRuinsGenerator(w).Connect()
+	require.Nil(t, err)
+	require.Equal(t, 2, broken)
+
+	walls := make(engine.Location, 0)
+	for _, object := range w.GetObjects() {
+		if wall, ok := object.(*Wall); ok {
+			walls = append(walls, wall.location...)
+		}
+	}
+	require.Len(t, walls, 16+12-2)
+	require.Nil(t, ValidateConnectivity(w.Area(), walls))
+}
+
+func Test_RuinsGenerator_GeneratesConnectedRuins(t *testing.T) {
+	for i := 0; i < 5; i++ {
+		w, err := world.NewWorld(60, 60)
+		require.Nil(t, err)
+
+		rg := NewRuinsGenerator(w)
+		for !rg.Done() && rg.Err() == nil {
+			rg.GenerateWall()
+		}
+
+		_, err = rg.Connect()
+		require.Nil(t, err)
+
+		walls := make(engine.Location, 0)
+		for _, object := range w.GetObjects() {
+			if wall, ok := object.(*Wall); ok {
+				walls = append(walls, wall.location...)
+			}
+		}
+		require.Nil(t, ValidateConnectivity(w.Area(), walls))
+	}
+}
@@ -202,3 +202,13 @@ func (rg *RuinsGenerator) findLocation(mask *engine.DotsMask) (engine.Location,
 
 	return location, nil
 }
+
+// Connect breaks walls to connect enclosed pockets of free space with the rest
+// of the map, so that every apple and every snake could be reached. It should
+// be called when the generation is done. It returns the number of broken dots
+func (rg *RuinsGenerator) Connect() (int, error) {
+	rg.mux.Lock()
+	defer rg.mux.Unlock()
+
+	return ConnectWalls(rg.world)
+}
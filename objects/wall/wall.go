@@ -69,6 +69,26 @@ func NewWallLocation(world world.Interface, location engine.Location) (*Wall, er
 	return wall, nil
 }
 
+// RestoreWall locates a wall with the given identifier at the location. The
+// identifier must have been obtained in the world's registry beforehand.
+func RestoreWall(world world.Interface, id world.Identifier, location engine.Location) (*Wall, error) {
+	wall := &Wall{
+		id:       id,
+		world:    world,
+		location: location.Copy(),
+		mux:      &sync.RWMutex{},
+	}
+
+	wall.mux.Lock()
+	defer wall.mux.Unlock()
+
+	if err := world.CreateObject(wall, wall.location); err != nil {
+		return nil, ErrCreateWall(err.Error())
+	}
+
+	return wall, nil
+}
+
 type errWallBreak string
 
 func (e errWallBreak) Error() string {
@@ -8,6 +8,8 @@ import (
 	"github.com/pquerna/ffjson/ffjson"
 
 	"github.com/ivan1993spb/snake-server/engine"
+	"github.com/ivan1993spb/snake-server/objects"
+	"github.com/ivan1993spb/snake-server/playground"
 	"github.com/ivan1993spb/snake-server/world"
 )
 
@@ -64,6 +66,27 @@ func NewWatermelon(world world.Interface) (*Watermelon, error) {
 	return watermelon, nil
 }
 
+// RestoreWatermelon locates a watermelon with the given identifier at the
+// location. The identifier must have been obtained in the world's registry
+// beforehand.
+func RestoreWatermelon(world world.Interface, id world.Identifier, location engine.Location) (*Watermelon, error) {
+	watermelon := &Watermelon{
+		id:       id,
+		world:    world,
+		location: location.Copy(),
+		mux:      &sync.RWMutex{},
+	}
+
+	watermelon.mux.Lock()
+	defer watermelon.mux.Unlock()
+
+	if err := world.CreateObject(watermelon, watermelon.location); err != nil {
+		return nil, ErrCreateWatermelon(err.Error())
+	}
+
+	return watermelon, nil
+}
+
 func (w *Watermelon) String() string {
 	w.mux.RLock()
 	defer w.mux.RUnlock()
@@ -76,7 +99,7 @@ func (e errWatermelonBite) Error() string {
 	return "watermelon bite error: " + string(e)
 }
 
-func (w *Watermelon) Bite(dot engine.Dot) (nv uint16, success bool, err error) {
+func (w *Watermelon) Bite(dot engine.Dot, commit objects.Commit) (success bool, err error) {
 	w.mux.Lock()
 	defer w.mux.Unlock()
 
@@ -84,28 +107,28 @@ func (w *Watermelon) Bite(dot engine.Dot) (nv uint16, success bool, err error) {
 		newDots := w.location.Delete(dot)
 
 		if len(newDots) > 0 {
-			newLocation, err := w.world.UpdateObjectAvailableDots(w, w.location, newDots)
-			if err != nil {
-				return 0, false, errWatermelonBite(err.Error())
-			}
-			if len(newLocation) > 0 {
-				w.location = newLocation
-				return watermelonNutritionalValue, true, nil
+			if err := commit(watermelonNutritionalValue, []playground.Operation{
+				playground.UpdateOperation(w, w.location, newDots),
+			}); err != nil {
+				return false, errWatermelonBite(err.Error())
 			}
+			w.location = newDots
+			return true, nil
 		}
 
-		w.world.IdentifierRegistry().Release(w.id)
-
-		if err := w.world.DeleteObject(w, w.location); err != nil {
-			return 0, false, errWatermelonBite(err.Error())
+		if err := commit(watermelonNutritionalValue, []playground.Operation{
+			playground.DeleteOperation(w, w.location),
+		}); err != nil {
+			return false, errWatermelonBite(err.Error())
 		}
 
+		w.world.IdentifierRegistry().Release(w.id)
 		w.location = w.location[:0]
 
-		return watermelonNutritionalValue, true, nil
+		return true, nil
 	}
 
-	return 0, false, errWatermelonBite("watermelon does not contain dot")
+	return false, errWatermelonBite("watermelon does not contain dot")
 }
 
 func (w *Watermelon) MarshalJSON() ([]byte, error) {
@@ -12,6 +12,12 @@ import (
 
 const chanAppleObserverEventsBuffer = 64
 
+// appleEventFilter selects deletes of apples, other events are ignored
+var appleEventFilter = world.EventFilter{
+	Types:       []world.EventType{world.EventTypeObjectDelete},
+	ObjectTypes: world.ObjectTypesOf((*apple.Apple)(nil)),
+}
+
 const defaultAppleCount = 1
 
 const oneAppleArea = 50
@@ -38,7 +44,8 @@ func (ao *AppleObserver) run(stop <-chan struct{}) {
 }
 
 func (ao *AppleObserver) init() {
-	for i := 0; i < ao.calcAppleCount(); i++ {
+	// The world could have apples restored from a snapshot
+	for i := ao.countApples(); i < ao.calcAppleCount(); i++ {
 		// TODO: Create abstraction layer for adding of objects.
 		if _, err := apple.NewApple(ao.world); err != nil {
 			ao.logger.WithError(err).Error("cannot create apple")
@@ -47,7 +54,7 @@ func (ao *AppleObserver) init() {
 }
 
 func (ao *AppleObserver) listen(stop <-chan struct{}) {
-	for event := range ao.world.Events(stop, chanAppleObserverEventsBuffer) {
+	for event := range ao.world.FilteredEvents(stop, chanAppleObserverEventsBuffer, appleEventFilter) {
 		if err := ao.handleEvent(event); err != nil {
 			ao.logger.WithError(err).Error("handling event error")
 		}
@@ -65,6 +72,16 @@ func (ao *AppleObserver) calcAppleCount() int {
 	return appleCount
 }
 
+func (ao *AppleObserver) countApples() int {
+	count := 0
+	for _, object := range ao.world.GetObjects() {
+		if _, ok := object.(*apple.Apple); ok {
+			count++
+		}
+	}
+	return count
+}
+
 func (ao *AppleObserver) handleEvent(event world.Event) error {
 	// Event type is only delete
 	if event.Type != world.EventTypeObjectDelete {
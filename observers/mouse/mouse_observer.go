@@ -15,6 +15,12 @@ const mouseTickerDelay = time.Minute
 
 const chanMouseObserverEventsBuffer = 64
 
+// mouseEventFilter selects deletes of mouses, other events are ignored
+var mouseEventFilter = world.EventFilter{
+	Types:       []world.EventType{world.EventTypeObjectDelete},
+	ObjectTypes: world.ObjectTypesOf((*mouse.Mouse)(nil)),
+}
+
 const oneMouseArea = 400
 
 type MouseObserver struct {
@@ -54,6 +60,19 @@ func (mo *MouseObserver) init() {
 	}).Debug("mouse observer")
 
 	mo.maxMouseNumber = maxMouseNumber
+
+	// The world could have mice restored from a snapshot
+	atomic.StoreInt32(&mo.mouseNumber, mo.countMice())
+}
+
+func (mo *MouseObserver) countMice() int32 {
+	var count int32 = 0
+	for _, object := range mo.world.GetObjects() {
+		if _, ok := object.(*mouse.Mouse); ok {
+			count++
+		}
+	}
+	return count
 }
 
 func (mo *MouseObserver) calcMaxMouseCount() int32 {
@@ -104,7 +123,7 @@ func (mo *MouseObserver) addMouse(stop <-chan struct{}) {
 }
 
 func (mo *MouseObserver) listen(stop <-chan struct{}) {
-	for event := range mo.world.Events(stop, chanMouseObserverEventsBuffer) {
+	for event := range mo.world.FilteredEvents(stop, chanMouseObserverEventsBuffer, mouseEventFilter) {
 		mo.handleEvent(event)
 	}
 }
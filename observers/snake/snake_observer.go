@@ -1,8 +1,11 @@
 package snake_observer
 
 import (
+	"time"
+
 	"github.com/sirupsen/logrus"
 
+	"github.com/ivan1993spb/snake-server/highscores"
 	"github.com/ivan1993spb/snake-server/objects/corpse"
 	"github.com/ivan1993spb/snake-server/objects/snake"
 	"github.com/ivan1993spb/snake-server/observers"
@@ -11,15 +14,28 @@ import (
 
 const chanSnakeObserverEventsBuffer = 64
 
+// snakeEventFilter selects deletes of snakes, other events are ignored
+var snakeEventFilter = world.EventFilter{
+	Types:       []world.EventType{world.EventTypeObjectDelete},
+	ObjectTypes: world.ObjectTypesOf((*snake.Snake)(nil)),
+}
+
 type SnakeObserver struct {
-	world  world.Interface
-	logger logrus.FieldLogger
+	world        world.Interface
+	logger       logrus.FieldLogger
+	corpseConfig corpse.Config
+	recorder     highscores.Recorder
 }
 
-func NewSnakeObserver(w world.Interface, logger logrus.FieldLogger) observers.Observer {
+// NewSnakeObserver creates an observer which turns dead snakes into corpses.
+// If recorder is not nil, results of named players' snakes are recorded
+func NewSnakeObserver(w world.Interface, logger logrus.FieldLogger, corpseConfig corpse.Config,
+	recorder highscores.Recorder) observers.Observer {
 	return &SnakeObserver{
-		world:  w,
-		logger: logger,
+		world:        w,
+		logger:       logger,
+		corpseConfig: corpseConfig,
+		recorder:     recorder,
 	}
 }
 
@@ -32,7 +48,7 @@ func (so *SnakeObserver) run(stop <-chan struct{}) {
 }
 
 func (so *SnakeObserver) listen(stop <-chan struct{}) {
-	for event := range so.world.Events(stop, chanSnakeObserverEventsBuffer) {
+	for event := range so.world.FilteredEvents(stop, chanSnakeObserverEventsBuffer, snakeEventFilter) {
 		so.handleEvent(event, stop)
 	}
 }
@@ -43,6 +59,11 @@ func (so *SnakeObserver) handleEvent(event world.Event, stop <-chan struct{}) {
 	}
 
 	if s, ok := event.Payload.(*snake.Snake); ok {
+		if record, ok := so.record(s); ok {
+			// Writing of the record must not delay the observer
+			go so.save(record)
+		}
+
 		location := s.GetLocation().Copy()
 		if location.Empty() {
 			so.logger.Warn("snake dies and returns empty location")
@@ -50,10 +71,41 @@ func (so *SnakeObserver) handleEvent(event world.Event, stop <-chan struct{}) {
 		}
 
 		// TODO: Create abstraction layer for adding of objects.
-		if c, err := corpse.NewCorpse(so.world, location); err != nil {
+		if c, err := corpse.NewCorpse(so.world, location, so.corpseConfig); err != nil {
 			so.logger.WithError(err).Error("cannot create corpse")
 		} else {
 			c.Run(stop, so.logger)
 		}
 	}
 }
+
+// record returns the high score record of the snake if it has to be saved
+func (so *SnakeObserver) record(s *snake.Snake) (highscores.Record, bool) {
+	if so.recorder == nil {
+		return highscores.Record{}, false
+	}
+
+	stats := s.Stats()
+	if stats.Player == "" {
+		// Anonymous players have no records
+		return highscores.Record{}, false
+	}
+
+	now := time.Now()
+
+	return highscores.Record{
+		Player:   stats.Player,
+		Length:   stats.Length,
+		Kills:    stats.Kills,
+		Survived: now.Sub(stats.Born),
+		Width:    so.world.Area().Width(),
+		Height:   so.world.Area().Height(),
+		Time:     now,
+	}, true
+}
+
+func (so *SnakeObserver) save(record highscores.Record) {
+	if err := so.recorder.Record(record); err != nil {
+		so.logger.WithError(err).Error("cannot record high score")
+	}
+}
@@ -25,9 +25,23 @@ func (wo *WallObserver) Observe(stop <-chan struct{}) {
 }
 
 func (wo *WallObserver) run(stop <-chan struct{}) {
+	// Ruins could have been restored from a snapshot
+	if wo.hasWalls() {
+		return
+	}
+
 	wo.generateRuins()
 }
 
+func (wo *WallObserver) hasWalls() bool {
+	for _, object := range wo.world.GetObjects() {
+		if _, ok := object.(*wall.Wall); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (wo *WallObserver) generateRuins() {
 	ruinsGenerator := wall.NewRuinsGenerator(wo.world)
 
@@ -41,4 +55,12 @@ func (wo *WallObserver) generateRuins() {
 			wo.logger.WithError(err).Error("error on ruins generation")
 		}
 	}
+
+	broken, err := ruinsGenerator.Connect()
+	if err != nil {
+		wo.logger.WithError(err).Error("error on ruins connection")
+	}
+	if broken > 0 {
+		wo.logger.WithField("broken", broken).Debug("ruins have been broken to connect free space")
+	}
 }
@@ -13,6 +13,12 @@ import (
 
 const chanWatermelonObserverEventsBuffer = 64
 
+// watermelonEventFilter selects deletes of watermelons, other events are ignored
+var watermelonEventFilter = world.EventFilter{
+	Types:       []world.EventType{world.EventTypeObjectDelete},
+	ObjectTypes: world.ObjectTypesOf((*watermelon.Watermelon)(nil)),
+}
+
 const addWatermelonDelay = time.Second * 15
 
 const addWatermelonsDuringTickLimit = 2
@@ -56,6 +62,19 @@ func (wo *WatermelonObserver) init() {
 	}).Debug("watermelon observer")
 
 	wo.maxWatermelonCount = maxWatermelonCount
+
+	// The world could have watermelons restored from a snapshot
+	atomic.StoreInt32(&wo.watermelonCount, wo.countWatermelons())
+}
+
+func (wo *WatermelonObserver) countWatermelons() int32 {
+	var count int32 = 0
+	for _, object := range wo.world.GetObjects() {
+		if _, ok := object.(*watermelon.Watermelon); ok {
+			count++
+		}
+	}
+	return count
 }
 
 // calcMaxWatermelonCount returns max possible watermelon count
@@ -66,7 +85,7 @@ func (wo *WatermelonObserver) calcMaxWatermelonCount() int32 {
 }
 
 func (wo *WatermelonObserver) listen(stop <-chan struct{}) {
-	for event := range wo.world.Events(stop, chanWatermelonObserverEventsBuffer) {
+	for event := range wo.world.FilteredEvents(stop, chanWatermelonObserverEventsBuffer, watermelonEventFilter) {
 		wo.handleEvent(event)
 	}
 }
@@ -2,7 +2,11 @@
 
 package player
 
-import "github.com/ivan1993spb/snake-server/world"
+import (
+	"time"
+
+	"github.com/ivan1993spb/snake-server/world"
+)
 
 type MessageType uint8
 
@@ -13,6 +17,7 @@ const (
 	MessageTypeError
 	MessageTypeCountdown
 	MessageTypeObjects
+	MessageTypePong
 )
 
 var messageTypeJSONs = map[MessageType][]byte{
@@ -22,6 +27,7 @@ var messageTypeJSONs = map[MessageType][]byte{
 	MessageTypeError:     []byte(`"error"`),
 	MessageTypeCountdown: []byte(`"countdown"`),
 	MessageTypeObjects:   []byte(`"objects"`),
+	MessageTypePong:      []byte(`"pong"`),
 }
 
 func (t MessageType) MarshalJSON() ([]byte, error) {
@@ -38,6 +44,7 @@ var messageTypeLabels = map[MessageType]string{
 	MessageTypeError:     "error",
 	MessageTypeCountdown: "countdown",
 	MessageTypeObjects:   "objects",
+	MessageTypePong:      "pong",
 }
 
 func (t MessageType) String() string {
@@ -113,3 +120,32 @@ func NewMessageObjects(objects interface{}) Message {
 		Payload: MessageObjects(objects),
 	}
 }
+
+// ffjson: nodecoder
+type MessagePong struct {
+	// Payload is the payload of the client's ping
+	Payload string `json:"payload"`
+	// ReceivedAt and SentAt are unix times in milliseconds when the server
+	// received the ping and sent the pong
+	ReceivedAt int64 `json:"received_at"`
+	SentAt     int64 `json:"sent_at"`
+	// RTT is the last round-trip time in milliseconds measured by the server
+	// or zero if it hasn't been measured yet
+	RTT float64 `json:"rtt"`
+}
+
+func unixMilli(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func NewMessagePong(payload string, receivedAt, sentAt time.Time, rtt time.Duration) Message {
+	return Message{
+		Type: MessageTypePong,
+		Payload: MessagePong{
+			Payload:    payload,
+			ReceivedAt: unixMilli(receivedAt),
+			SentAt:     unixMilli(sentAt),
+			RTT:        float64(rtt) / float64(time.Millisecond),
+		},
+	}
+}
@@ -52,6 +52,42 @@ func (j *Message) MarshalJSONBuf(buf fflib.EncodingBuffer) error {
 	return nil
 }
 
+// MarshalJSON marshal bytes to json - template
+func (j *MessagePong) MarshalJSON() ([]byte, error) {
+	var buf fflib.Buffer
+	if j == nil {
+		buf.WriteString("null")
+		return buf.Bytes(), nil
+	}
+	err := j.MarshalJSONBuf(&buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalJSONBuf marshal buff to json - template
+func (j *MessagePong) MarshalJSONBuf(buf fflib.EncodingBuffer) error {
+	if j == nil {
+		buf.WriteString("null")
+		return nil
+	}
+	var err error
+	var obj []byte
+	_ = obj
+	_ = err
+	buf.WriteString(`{"payload":`)
+	fflib.WriteJsonString(buf, string(j.Payload))
+	buf.WriteString(`,"received_at":`)
+	fflib.FormatBits2(buf, uint64(j.ReceivedAt), 10, j.ReceivedAt < 0)
+	buf.WriteString(`,"sent_at":`)
+	fflib.FormatBits2(buf, uint64(j.SentAt), 10, j.SentAt < 0)
+	buf.WriteString(`,"rtt":`)
+	fflib.AppendFloat(buf, float64(j.RTT), 'g', -1, 64)
+	buf.WriteByte('}')
+	return nil
+}
+
 // MarshalJSON marshal bytes to json - template
 func (j *MessageSize) MarshalJSON() ([]byte, error) {
 	var buf fflib.Buffer
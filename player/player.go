@@ -17,14 +17,20 @@ const chanMessageBuffer = 128
 const chanErrorBuffer = 32
 
 type Player struct {
-	world  world.Interface
-	logger logrus.FieldLogger
+	world       world.Interface
+	logger      logrus.FieldLogger
+	name        string
+	snakeConfig snake.Config
 }
 
-func NewPlayer(logger logrus.FieldLogger, world world.Interface) *Player {
+// NewPlayer creates a player. The name is optional and it is used to keep
+// the player's high scores. Snakes of the player are spawned with the config
+func NewPlayer(logger logrus.FieldLogger, world world.Interface, name string, snakeConfig snake.Config) *Player {
 	return &Player{
-		logger: logger,
-		world:  world,
+		logger:      logger,
+		world:       world,
+		name:        name,
+		snakeConfig: snakeConfig,
 	}
 }
 
@@ -64,7 +70,7 @@ func (p *Player) Start(stop <-chan struct{}, chin <-chan string) <-chan Message
 
 			chout <- NewMessageNotice("start")
 
-			s, err := snake.NewSnake(p.world)
+			s, err := snake.NewSnake(p.world, p.name, p.snakeConfig)
 			if err != nil {
 				chout <- NewMessageError("cannot create snake")
 				p.logger.Errorln("cannot create snake to player:", err)
@@ -0,0 +1,81 @@
+package playground
+
+import (
+	"errors"
+	"strings"
+)
+
+// Backend is an implementation of the playground
+type Backend uint8
+
+const (
+	// BackendCMap is the playground built on the sharded concurrent map
+	BackendCMap Backend = iota
+	// BackendLockFree is the playground built on the lock-free engine.Map.
+	// It scales better on large maps with many players
+	BackendLockFree
+)
+
+var backendLabels = map[Backend]string{
+	BackendCMap:     "cmap",
+	BackendLockFree: "lockfree",
+}
+
+func (b Backend) String() string {
+	if label, ok := backendLabels[b]; ok {
+		return label
+	}
+	return "unknown"
+}
+
+var ErrUnknownBackend = errors.New("unknown playground backend")
+
+// ParseBackend returns the backend by its label
+func ParseBackend(label string) (Backend, error) {
+	for backend, backendLabel := range backendLabels {
+		if strings.EqualFold(backendLabel, label) {
+			return backend, nil
+		}
+	}
+	return 0, ErrUnknownBackend
+}
+
+func (b Backend) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + b.String() + `"`), nil
+}
+
+func (b *Backend) UnmarshalJSON(data []byte) error {
+	backend, err := ParseBackend(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*b = backend
+	return nil
+}
+
+var (
+	_ Storage    = (*PlaygroundCMap)(nil)
+	_ Storage    = (*ExperimentalPlayground)(nil)
+	_ Playground = (*transactional)(nil)
+)
+
+// New creates a new empty playground of the specified area with the backend.
+// The backend is wrapped to support transactions
+func New(backend Backend, width, height uint8) (Playground, error) {
+	// Constructors' results are checked to not return typed nil interfaces
+	switch backend {
+	case BackendCMap:
+		pg, err := NewPlaygroundCMap(width, height)
+		if err != nil {
+			return nil, err
+		}
+		return newTransactional(pg), nil
+	case BackendLockFree:
+		pg, err := NewExperimentalPlayground(width, height)
+		if err != nil {
+			return nil, err
+		}
+		return newTransactional(pg), nil
+	}
+	return nil, ErrCreatePlayground{ErrUnknownBackend}
+}
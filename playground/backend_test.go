@@ -0,0 +1,41 @@
+package playground
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseBackend(t *testing.T) {
+	backend, err := ParseBackend("cmap")
+	require.Nil(t, err)
+	require.Equal(t, BackendCMap, backend)
+
+	backend, err = ParseBackend("LockFree")
+	require.Nil(t, err)
+	require.Equal(t, BackendLockFree, backend)
+
+	_, err = ParseBackend("unknown")
+	require.Equal(t, ErrUnknownBackend, err)
+}
+
+func Test_Backend_JSON(t *testing.T) {
+	data, err := json.Marshal(BackendLockFree)
+	require.Nil(t, err)
+	require.Equal(t, `"lockfree"`, string(data))
+
+	var backend Backend
+	require.Nil(t, json.Unmarshal(data, &backend))
+	require.Equal(t, BackendLockFree, backend)
+}
+
+func Test_New_UnknownBackend(t *testing.T) {
+	pg, err := New(Backend(100), 10, 10)
+	require.NotNil(t, err)
+	require.Nil(t, pg)
+
+	pg, err = New(BackendLockFree, 0, 10)
+	require.NotNil(t, err)
+	require.Nil(t, pg)
+}
@@ -0,0 +1,457 @@
+package playground
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ivan1993spb/snake-server/engine"
+)
+
+// conformanceTests are run against every backend to make sure that the
+// backends are interchangeable
+var conformanceTests = []struct {
+	name string
+	test func(t *testing.T, pg Playground)
+}{
+	{"CreateObject", conformanceCreateObject},
+	{"CreateObjectAvailableDots", conformanceCreateObjectAvailableDots},
+	{"UpdateObject", conformanceUpdateObject},
+	{"UpdateObjectAvailableDots", conformanceUpdateObjectAvailableDots},
+	{"DeleteObject", conformanceDeleteObject},
+	{"GetObjectsByDots", conformanceGetObjectsByDots},
+	{"LocationOccupied", conformanceLocationOccupied},
+	{"CreateObjectRandom", conformanceCreateObjectRandom},
+	{"Concurrency", conformanceConcurrency},
+	{"Transaction", conformanceTransaction},
+	{"TransactionRollback", conformanceTransactionRollback},
+	{"TransactionConcurrency", conformanceTransactionConcurrency},
+	{"SpatialQueries", conformanceSpatialQueries},
+}
+
+const (
+	conformanceAreaWidth  = 40
+	conformanceAreaHeight = 30
+)
+
+func Test_Playground_Conformance(t *testing.T) {
+	for _, backend := range []Backend{BackendCMap, BackendLockFree} {
+		backend := backend
+
+		t.Run(backend.String(), func(t *testing.T) {
+			for _, test := range conformanceTests {
+				test := test
+
+				t.Run(test.name, func(t *testing.T) {
+					pg, err := New(backend, conformanceAreaWidth, conformanceAreaHeight)
+					require.Nil(t, err)
+					require.NotNil(t, pg)
+					require.Equal(t, uint8(conformanceAreaWidth), pg.Area().Width())
+					require.Equal(t, uint8(conformanceAreaHeight), pg.Area().Height())
+
+					test.test(t, pg)
+				})
+			}
+		})
+	}
+}
+
+type conformanceObject struct {
+	name string
+}
+
+func conformanceCreateObject(t *testing.T, pg Playground) {
+	first := &conformanceObject{"first"}
+	second := &conformanceObject{"second"}
+
+	location := engine.Location{{X: 1, Y: 1}, {X: 1, Y: 2}}
+
+	require.Nil(t, pg.CreateObject(first, location))
+	require.Equal(t, first, pg.GetObjectByDot(engine.Dot{X: 1, Y: 1}))
+	require.Equal(t, first, pg.GetObjectByDot(engine.Dot{X: 1, Y: 2}))
+	require.Nil(t, pg.GetObjectByDot(engine.Dot{X: 1, Y: 3}))
+	require.Equal(t, []engine.Object{first}, pg.GetObjects())
+
+	// The same object cannot be created twice
+	require.NotNil(t, pg.CreateObject(first, engine.Location{{X: 5, Y: 5}}))
+	require.Nil(t, pg.GetObjectByDot(engine.Dot{X: 5, Y: 5}))
+
+	// Occupied location
+	require.NotNil(t, pg.CreateObject(second, engine.Location{{X: 1, Y: 2}, {X: 1, Y: 3}}))
+	require.Nil(t, pg.GetObjectByDot(engine.Dot{X: 1, Y: 3}))
+
+	// Empty location and location outside the area
+	require.NotNil(t, pg.CreateObject(second, engine.Location{}))
+	require.NotNil(t, pg.CreateObject(second, engine.Location{{X: conformanceAreaWidth, Y: 0}}))
+
+	require.Equal(t, []engine.Object{first}, pg.GetObjects())
+}
+
+func conformanceCreateObjectAvailableDots(t *testing.T, pg Playground) {
+	first := &conformanceObject{"first"}
+	second := &conformanceObject{"second"}
+	third := &conformanceObject{"third"}
+
+	require.Nil(t, pg.CreateObject(first, engine.Location{{X: 2, Y: 2}}))
+
+	location, err := pg.CreateObjectAvailableDots(second, engine.Location{{X: 2, Y: 1}, {X: 2, Y: 2}, {X: 2, Y: 3}})
+	require.Nil(t, err)
+	require.True(t, location.Equals(engine.Location{{X: 2, Y: 1}, {X: 2, Y: 3}}))
+	require.Equal(t, first, pg.GetObjectByDot(engine.Dot{X: 2, Y: 2}))
+	require.Equal(t, second, pg.GetObjectByDot(engine.Dot{X: 2, Y: 3}))
+
+	// All dots are occupied
+	location, err = pg.CreateObjectAvailableDots(third, engine.Location{{X: 2, Y: 2}, {X: 2, Y: 3}})
+	require.NotNil(t, err)
+	require.Empty(t, location)
+	require.Len(t, pg.GetObjects(), 2)
+
+	_, err = pg.CreateObjectAvailableDots(third, engine.Location{})
+	require.NotNil(t, err)
+}
+
+func conformanceUpdateObject(t *testing.T, pg Playground) {
+	first := &conformanceObject{"first"}
+	second := &conformanceObject{"second"}
+
+	old := engine.Location{{X: 3, Y: 3}, {X: 3, Y: 4}}
+	new := engine.Location{{X: 3, Y: 4}, {X: 3, Y: 5}}
+
+	require.Nil(t, pg.CreateObject(first, old))
+	require.Nil(t, pg.CreateObject(second, engine.Location{{X: 3, Y: 6}}))
+
+	require.Nil(t, pg.UpdateObject(first, old, new))
+	require.Nil(t, pg.GetObjectByDot(engine.Dot{X: 3, Y: 3}))
+	require.Equal(t, first, pg.GetObjectByDot(engine.Dot{X: 3, Y: 4}))
+	require.Equal(t, first, pg.GetObjectByDot(engine.Dot{X: 3, Y: 5}))
+
+	// Nothing has changed
+	require.Nil(t, pg.UpdateObject(first, new, new))
+
+	// The new location is occupied, so the old one is kept
+	require.NotNil(t, pg.UpdateObject(first, new, engine.Location{{X: 3, Y: 5}, {X: 3, Y: 6}}))
+	require.Equal(t, first, pg.GetObjectByDot(engine.Dot{X: 3, Y: 4}))
+	require.Equal(t, first, pg.GetObjectByDot(engine.Dot{X: 3, Y: 5}))
+	require.Equal(t, second, pg.GetObjectByDot(engine.Dot{X: 3, Y: 6}))
+}
+
+func conformanceUpdateObjectAvailableDots(t *testing.T, pg Playground) {
+	first := &conformanceObject{"first"}
+	second := &conformanceObject{"second"}
+
+	old := engine.Location{{X: 4, Y: 4}}
+
+	require.Nil(t, pg.CreateObject(first, old))
+	require.Nil(t, pg.CreateObject(second, engine.Location{{X: 4, Y: 6}}))
+
+	location, err := pg.UpdateObjectAvailableDots(first, old, engine.Location{{X: 4, Y: 5}, {X: 4, Y: 6}})
+	require.Nil(t, err)
+	require.True(t, location.Equals(engine.Location{{X: 4, Y: 5}}))
+	require.Nil(t, pg.GetObjectByDot(engine.Dot{X: 4, Y: 4}))
+	require.Equal(t, first, pg.GetObjectByDot(engine.Dot{X: 4, Y: 5}))
+	require.Equal(t, second, pg.GetObjectByDot(engine.Dot{X: 4, Y: 6}))
+
+	// All dots to set are occupied
+	location, err = pg.UpdateObjectAvailableDots(first, location, engine.Location{{X: 4, Y: 6}})
+	require.NotNil(t, err)
+	require.Empty(t, location)
+	require.Equal(t, second, pg.GetObjectByDot(engine.Dot{X: 4, Y: 6}))
+}
+
+func conformanceDeleteObject(t *testing.T, pg Playground) {
+	first := &conformanceObject{"first"}
+	second := &conformanceObject{"second"}
+
+	location := engine.Location{{X: 5, Y: 5}, {X: 5, Y: 6}}
+
+	require.Nil(t, pg.CreateObject(first, location))
+	require.Nil(t, pg.CreateObject(second, engine.Location{{X: 5, Y: 7}}))
+
+	// Dots of other objects are kept
+	require.Nil(t, pg.DeleteObject(first, append(location.Copy(), engine.Dot{X: 5, Y: 7})))
+	require.Nil(t, pg.GetObjectByDot(engine.Dot{X: 5, Y: 5}))
+	require.Nil(t, pg.GetObjectByDot(engine.Dot{X: 5, Y: 6}))
+	require.Equal(t, second, pg.GetObjectByDot(engine.Dot{X: 5, Y: 7}))
+	require.Equal(t, []engine.Object{second}, pg.GetObjects())
+
+	// The object has been deleted already
+	require.NotNil(t, pg.DeleteObject(first, location))
+}
+
+func conformanceGetObjectsByDots(t *testing.T, pg Playground) {
+	first := &conformanceObject{"first"}
+	second := &conformanceObject{"second"}
+
+	require.Nil(t, pg.CreateObject(first, engine.Location{{X: 6, Y: 6}, {X: 6, Y: 7}}))
+	require.Nil(t, pg.CreateObject(second, engine.Location{{X: 7, Y: 7}}))
+
+	require.Nil(t, pg.GetObjectsByDots(nil))
+	require.Empty(t, pg.GetObjectsByDots([]engine.Dot{{X: 0, Y: 0}}))
+
+	objects := pg.GetObjectsByDots([]engine.Dot{{X: 6, Y: 6}, {X: 6, Y: 7}, {X: 7, Y: 7}, {X: 8, Y: 8}})
+	require.Len(t, objects, 2)
+	require.Contains(t, objects, first)
+	require.Contains(t, objects, second)
+}
+
+func conformanceLocationOccupied(t *testing.T, pg Playground) {
+	object := &conformanceObject{"object"}
+
+	require.Nil(t, pg.CreateObject(object, engine.Location{{X: 8, Y: 8}, {X: 8, Y: 9}}))
+
+	require.True(t, pg.LocationOccupied(engine.Location{{X: 8, Y: 8}, {X: 8, Y: 9}}))
+	require.False(t, pg.LocationOccupied(engine.Location{{X: 8, Y: 9}, {X: 8, Y: 10}}))
+	require.False(t, pg.LocationOccupied(engine.Location{{X: 9, Y: 9}}))
+}
+
+func conformanceCreateObjectRandom(t *testing.T, pg Playground) {
+	requireOwns := func(object engine.Object, location engine.Location) {
+		require.NotEmpty(t, location)
+		require.True(t, pg.Area().ContainsLocation(location))
+		for _, dot := range location {
+			require.Equal(t, object, pg.GetObjectByDot(dot))
+		}
+	}
+
+	dot := &conformanceObject{"dot"}
+	location, err := pg.CreateObjectRandomDot(dot)
+	require.Nil(t, err)
+	require.Len(t, location, 1)
+	requireOwns(dot, location)
+
+	rect := &conformanceObject{"rect"}
+	location, err = pg.CreateObjectRandomRect(rect, 3, 2)
+	require.Nil(t, err)
+	require.Len(t, location, 6)
+	requireOwns(rect, location)
+
+	_, err = pg.CreateObjectRandomRect(&conformanceObject{"zero"}, 0, 2)
+	require.NotNil(t, err)
+	_, err = pg.CreateObjectRandomRect(&conformanceObject{"large"}, conformanceAreaWidth+1, 1)
+	require.NotNil(t, err)
+
+	margin := &conformanceObject{"margin"}
+	location, err = pg.CreateObjectRandomRectMargin(margin, 2, 2, 1)
+	require.Nil(t, err)
+	require.Len(t, location, 4)
+	requireOwns(margin, location)
+
+	mask := &conformanceObject{"mask"}
+	dm := engine.NewDotsMask([][]uint8{
+		{1, 0},
+		{1, 1},
+	})
+	location, err = pg.CreateObjectRandomByDotsMask(mask, dm)
+	require.Nil(t, err)
+	require.Len(t, location, 3)
+	requireOwns(mask, location)
+
+	require.Len(t, pg.GetObjects(), 4)
+}
+
+func conformanceConcurrency(t *testing.T, pg Playground) {
+	const (
+		workers          = 8
+		objectsPerWorker = 50
+	)
+
+	var wg sync.WaitGroup
+	locations := make([][]engine.Location, workers)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < objectsPerWorker; j++ {
+				object := &conformanceObject{"object"}
+				location, err := pg.CreateObjectRandomDot(object)
+				if err != nil {
+					continue
+				}
+				locations[i] = append(locations[i], location)
+				if j%2 == 0 {
+					if err := pg.DeleteObject(object, location); err == nil {
+						locations[i] = locations[i][:len(locations[i])-1]
+					}
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	count := 0
+	dots := make(map[engine.Dot]struct{})
+	for _, workerLocations := range locations {
+		for _, location := range workerLocations {
+			for _, dot := range location {
+				_, ok := dots[dot]
+				require.False(t, ok, "dot %s is occupied twice", dot)
+				dots[dot] = struct{}{}
+				require.NotNil(t, pg.GetObjectByDot(dot))
+			}
+			count++
+		}
+	}
+
+	require.Len(t, pg.GetObjects(), count)
+}
+
+func conformanceTransaction(t *testing.T, pg Playground) {
+	first := &conformanceObject{"first"}
+	second := &conformanceObject{"second"}
+	third := &conformanceObject{"third"}
+
+	require.Nil(t, pg.CreateObject(first, engine.Location{{X: 4, Y: 4}}))
+	require.Nil(t, pg.CreateObject(second, engine.Location{{X: 4, Y: 5}}))
+
+	// The third object takes the dot of the deleted first object and the
+	// second object moves
+	err := pg.Transaction([]Operation{
+		DeleteOperation(first, engine.Location{{X: 4, Y: 4}}),
+		CreateOperation(third, engine.Location{{X: 4, Y: 4}}),
+		UpdateOperation(second, engine.Location{{X: 4, Y: 5}}, engine.Location{{X: 4, Y: 6}}),
+	})
+	require.Nil(t, err)
+
+	require.Equal(t, third, pg.GetObjectByDot(engine.Dot{X: 4, Y: 4}))
+	require.Nil(t, pg.GetObjectByDot(engine.Dot{X: 4, Y: 5}))
+	require.Equal(t, second, pg.GetObjectByDot(engine.Dot{X: 4, Y: 6}))
+	require.Len(t, pg.GetObjects(), 2)
+
+	require.Nil(t, pg.Transaction(nil))
+}
+
+func conformanceTransactionRollback(t *testing.T, pg Playground) {
+	first := &conformanceObject{"first"}
+	second := &conformanceObject{"second"}
+	third := &conformanceObject{"third"}
+
+	require.Nil(t, pg.CreateObject(first, engine.Location{{X: 5, Y: 5}, {X: 5, Y: 6}}))
+	require.Nil(t, pg.CreateObject(second, engine.Location{{X: 7, Y: 7}}))
+
+	// The last operation fails because the dot is occupied by the second object
+	err := pg.Transaction([]Operation{
+		UpdateOperation(first, engine.Location{{X: 5, Y: 5}, {X: 5, Y: 6}}, engine.Location{{X: 5, Y: 6}, {X: 5, Y: 7}}),
+		DeleteOperation(second, engine.Location{{X: 7, Y: 7}}),
+		CreateOperation(third, engine.Location{{X: 8, Y: 8}}),
+		CreateOperation(third, engine.Location{{X: 5, Y: 7}}),
+	})
+	require.NotNil(t, err)
+	require.Equal(t, 3, err.(*ErrTransaction).Index)
+	require.Nil(t, err.(*ErrTransaction).Rollback)
+
+	require.Equal(t, first, pg.GetObjectByDot(engine.Dot{X: 5, Y: 5}))
+	require.Equal(t, first, pg.GetObjectByDot(engine.Dot{X: 5, Y: 6}))
+	require.Nil(t, pg.GetObjectByDot(engine.Dot{X: 5, Y: 7}))
+	require.Equal(t, second, pg.GetObjectByDot(engine.Dot{X: 7, Y: 7}))
+	require.Nil(t, pg.GetObjectByDot(engine.Dot{X: 8, Y: 8}))
+	require.Len(t, pg.GetObjects(), 2)
+}
+
+func conformanceTransactionConcurrency(t *testing.T, pg Playground) {
+	const (
+		workers = 8
+		moves   = 100
+	)
+
+	// Every worker swaps two objects on two dots. A single update would fail
+	// on an occupied dot, whereas the transaction moves both objects at once
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		first := &conformanceObject{"first"}
+		second := &conformanceObject{"second"}
+		a := engine.Location{{X: uint8(i), Y: 0}}
+		b := engine.Location{{X: uint8(i), Y: 1}}
+
+		require.Nil(t, pg.CreateObject(first, a))
+		require.Nil(t, pg.CreateObject(second, b))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < moves; j++ {
+				err := pg.Transaction([]Operation{
+					DeleteOperation(first, a),
+					UpdateOperation(second, b, a),
+					CreateOperation(first, b),
+				})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				a, b = b, a
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < moves; j++ {
+			// Readers never see a worker's object missing
+			for i := 0; i < workers; i++ {
+				objects := pg.GetObjectsByDots([]engine.Dot{{X: uint8(i), Y: 0}, {X: uint8(i), Y: 1}})
+				if len(objects) != 2 {
+					t.Errorf("worker %d objects found: %d", i, len(objects))
+					return
+				}
+			}
+		}
+	}()
+
+	wg.Wait()
+
+	require.Len(t, pg.GetObjects(), workers*2)
+}
+
+type conformanceOtherObject struct {
+	name string
+}
+
+func conformanceSpatialQueries(t *testing.T, pg Playground) {
+	first := &conformanceObject{"first"}
+	second := &conformanceObject{"second"}
+	other := &conformanceOtherObject{"other"}
+
+	require.Nil(t, pg.CreateObject(first, engine.Location{{X: 10, Y: 10}, {X: 11, Y: 10}}))
+	require.Nil(t, pg.CreateObject(second, engine.Location{{X: 0, Y: 0}}))
+	require.Nil(t, pg.CreateObject(other, engine.Location{{X: 12, Y: 12}}))
+
+	objects := pg.GetObjectsInRect(engine.NewRect(9, 9, 4, 4))
+	require.Len(t, objects, 2)
+	require.Contains(t, objects, first)
+	require.Contains(t, objects, other)
+	require.Empty(t, pg.GetObjectsInRect(engine.NewRect(20, 20, 5, 5)))
+
+	require.Equal(t, uint16(16-3), pg.CountFreeDotsInRect(engine.NewRect(9, 9, 4, 4)))
+	require.Equal(t, uint16(25), pg.CountFreeDotsInRect(engine.NewRect(20, 20, 5, 5)))
+
+	objects = pg.GetObjectsInRadius(engine.Dot{X: 10, Y: 12}, 2)
+	require.Len(t, objects, 2)
+	require.Contains(t, objects, first)
+	require.Contains(t, objects, other)
+	require.Equal(t, []engine.Object{other}, pg.GetObjectsInRadius(engine.Dot{X: 12, Y: 13}, 1))
+
+	// The radius crosses the borders of the area
+	require.Equal(t, []engine.Object{second},
+		pg.GetObjectsInRadius(engine.Dot{X: conformanceAreaWidth - 1, Y: conformanceAreaHeight - 1}, 2))
+
+	object, dot := pg.GetNearestObjectOfType(engine.Dot{X: 13, Y: 10}, reflect.TypeOf(first))
+	require.Equal(t, first, object)
+	require.Equal(t, engine.Dot{X: 11, Y: 10}, dot)
+
+	object, dot = pg.GetNearestObjectOfType(engine.Dot{X: 13, Y: 10}, reflect.TypeOf(other))
+	require.Equal(t, other, object)
+	require.Equal(t, engine.Dot{X: 12, Y: 12}, dot)
+
+	object, dot = pg.GetNearestObjectOfType(engine.Dot{X: conformanceAreaWidth - 1, Y: 1}, reflect.TypeOf(first))
+	require.Equal(t, second, object)
+	require.Equal(t, engine.Dot{X: 0, Y: 0}, dot)
+
+	object, _ = pg.GetNearestObjectOfType(engine.Dot{X: 1, Y: 1}, reflect.TypeOf(&struct{}{}))
+	require.Nil(t, object)
+}
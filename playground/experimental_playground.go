@@ -12,9 +12,11 @@ const findRetriesNumber = 64
 const (
 	errRetriesLimitMessage               = "retries limit was reached"
 	errAreaDoesNotContainLocationMessage = "area does not contain location"
+	errEmptyLocationMessage              = "passed empty location"
 )
 
-// ExperimentalPlayground is a framework which allows locating for game objects
+// ExperimentalPlayground is a framework which allows locating for game objects.
+// It is built on the lock-free engine.Map and is selected with BackendLockFree
 type ExperimentalPlayground struct {
 	gameMap *engine.Map
 
@@ -135,15 +137,18 @@ func (p *ExperimentalPlayground) GetObjectsByDots(dots []engine.Dot) []engine.Ob
 
 // CreateObject creates and registers an object at the given location on the playground.
 // If some dots are occupied by other objects, the operation will be turn down with an error.
-// Initial location could be empty.
 func (p *ExperimentalPlayground) CreateObject(object engine.Object, location engine.Location) error {
+	if location.Empty() {
+		return errCreateObject(errEmptyLocationMessage)
+	}
+
 	if !p.gameMap.Area().ContainsLocation(location) {
 		return errCreateObject(errAreaDoesNotContainLocationMessage)
 	}
 
 	container := engine.NewContainer(object)
 
-	if !location.Empty() && !p.gameMap.MSetIfAllVacant(location, container) {
+	if !p.gameMap.MSetIfAllVacant(location, container) {
 		return errCreateObject("location is occupied")
 	}
 
@@ -160,8 +165,12 @@ func (p *ExperimentalPlayground) CreateObject(object engine.Object, location eng
 
 // CreateObjectAvailableDots creates and registers an object at the given location on the playground.
 // If some dots are occupied by other objects, the dots will be ignored. If all dots are occupied
-// the object will be registered without location and no error will be returned.
+// the object will not be registered and an error will be returned.
 func (p *ExperimentalPlayground) CreateObjectAvailableDots(object engine.Object, location engine.Location) (engine.Location, error) {
+	if location.Empty() {
+		return nil, errCreateObjectAvailableDots(errEmptyLocationMessage)
+	}
+
 	if !p.gameMap.Area().ContainsLocation(location) {
 		return nil, errCreateObjectAvailableDots(errAreaDoesNotContainLocationMessage)
 	}
@@ -169,6 +178,10 @@ func (p *ExperimentalPlayground) CreateObjectAvailableDots(object engine.Object,
 	container := engine.NewContainer(object)
 	resultLocation := p.gameMap.MSetIfVacant(location, container)
 
+	if len(resultLocation) == 0 {
+		return nil, errCreateObjectAvailableDots("all dots in location are occupied")
+	}
+
 	if err := p.addObject(object, container); err != nil {
 		// An object has been successfully placed but hasn't been registered in the playground.
 		// Hence, roll the map back and return the error.
@@ -605,8 +605,9 @@ func Test_ExperimentalPlayground_CreateObjectAvailableDots_LocationNotAvailable(
 	}
 
 	location1Actual, err := pg.CreateObjectAvailableDots(object1, location1)
-	require.Nil(t, err)
+	require.NotNil(t, err)
 	require.Empty(t, location1Actual)
+	require.NotContains(t, pg.objectsContainers, object1)
 
 	for _, dot := range pg.Area().Dots() {
 		actualContainer, ok := pg.gameMap.Get(dot)
@@ -1,10 +1,35 @@
 package playground
 
-import "github.com/ivan1993spb/snake-server/engine"
+import (
+	"reflect"
+
+	"github.com/ivan1993spb/snake-server/engine"
+)
 
 // Playground interface declares a set of methods to be implemented by
 // a playground structure operating with objects on a map.
 type Playground interface {
+	Storage
+
+	// Transaction should apply the operations atomically. Either all the operations are applied
+	// or none of them, and no other operation is applied in between
+	Transaction(operations []Operation) error
+
+	// GetObjectsInRect should return a slice of objects which occupy dots in the rect
+	GetObjectsInRect(rect engine.Rect) []engine.Object
+	// GetObjectsInRadius should return a slice of objects which occupy dots within the
+	// radius from the dot across the borders of the area
+	GetObjectsInRadius(dot engine.Dot, radius uint8) []engine.Object
+	// GetNearestObjectOfType should return the nearest object of the type to the dot and the
+	// dot of the object nearest to the dot. It should return nil if there is no such object
+	GetNearestObjectOfType(dot engine.Dot, objectType reflect.Type) (engine.Object, engine.Dot)
+	// CountFreeDotsInRect should return the number of vacant dots in the rect
+	CountFreeDotsInRect(rect engine.Rect) uint16
+}
+
+// Storage interface declares a set of methods to be implemented by playground backends
+// operating with objects on a map.
+type Storage interface {
 	// CreateObject should create an object on a predefined location
 	CreateObject(object engine.Object, location engine.Location) error
 	// CreateObjectAvailableDots should create an object on a predefined location. The method
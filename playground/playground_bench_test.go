@@ -0,0 +1,90 @@
+package playground
+
+import (
+	"testing"
+
+	"github.com/ivan1993spb/snake-server/engine"
+)
+
+// rawBenchmarkPlaygroundContention runs goroutines which create objects, move
+// them around the map and look at the map like snakes do
+func rawBenchmarkPlaygroundContention(b *testing.B, pg Storage) {
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		object := &struct{ n int }{}
+
+		location, err := pg.CreateObjectRandomDot(object)
+		if err != nil {
+			b.Error(err)
+			return
+		}
+
+		area := pg.Area()
+		dir := engine.RandomDirection()
+
+		for pb.Next() {
+			dot, err := area.Navigate(location[0], dir, 1)
+			if err != nil {
+				b.Error(err)
+				return
+			}
+
+			if pg.GetObjectByDot(dot) != nil {
+				dir = engine.RandomDirection()
+				continue
+			}
+
+			next := engine.Location{dot}
+			if err := pg.UpdateObject(object, location, next); err != nil {
+				dir = engine.RandomDirection()
+				continue
+			}
+			location = next
+		}
+
+		if err := pg.DeleteObject(object, location); err != nil {
+			b.Error(err)
+		}
+	})
+}
+
+// newBenchmarkStorage returns the backend without the transactional wrapper
+func newBenchmarkStorage(backend Backend, width, height uint8) (Storage, error) {
+	if backend == BackendLockFree {
+		return NewExperimentalPlayground(width, height)
+	}
+	return NewPlaygroundCMap(width, height)
+}
+
+// benchmarkPlaygroundContention compares the playgrounds returned by New with
+// the bare backends to measure the cost of the transactional wrapper
+func benchmarkPlaygroundContention(b *testing.B, width, height uint8) {
+	for _, backend := range []Backend{BackendCMap, BackendLockFree} {
+		backend := backend
+
+		b.Run(backend.String(), func(b *testing.B) {
+			pg, err := New(backend, width, height)
+			if err != nil {
+				b.Fatal(err)
+			}
+			rawBenchmarkPlaygroundContention(b, pg)
+		})
+		b.Run(backend.String()+"-bare", func(b *testing.B) {
+			storage, err := newBenchmarkStorage(backend, width, height)
+			if err != nil {
+				b.Fatal(err)
+			}
+			rawBenchmarkPlaygroundContention(b, storage)
+		})
+	}
+}
+
+func Benchmark_Playground_Contention_64x64(b *testing.B) {
+	benchmarkPlaygroundContention(b, 64, 64)
+}
+
+func Benchmark_Playground_Contention_255x255(b *testing.B) {
+	benchmarkPlaygroundContention(b, 255, 255)
+}
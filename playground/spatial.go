@@ -0,0 +1,121 @@
+package playground
+
+import (
+	"reflect"
+
+	"github.com/ivan1993spb/snake-server/engine"
+)
+
+// Spatial queries use the dot index of a backend, so a query takes time
+// proportional to the number of dots in the region rather than to the number
+// of objects on the playground. Distances are Manhattan distances measured
+// across the borders of the area, since objects move through the borders
+
+// wrapCoordinates returns distinct coordinates within the distance from the
+// coordinate c across the border
+func wrapCoordinates(c uint8, distance uint16, size uint8) []uint8 {
+	if 2*distance+1 >= uint16(size) {
+		coordinates := make([]uint8, size)
+		for i := range coordinates {
+			coordinates[i] = uint8(i)
+		}
+		return coordinates
+	}
+
+	coordinates := make([]uint8, 0, 2*distance+1)
+	for d := -int(distance); d <= int(distance); d++ {
+		coordinates = append(coordinates, uint8((int(c)+d+int(size))%int(size)))
+	}
+	return coordinates
+}
+
+// radiusDots returns the dots of the area within the radius from the dot
+func radiusDots(area engine.Area, dot engine.Dot, radius uint16) []engine.Dot {
+	dots := make([]engine.Dot, 0)
+	for _, y := range wrapCoordinates(dot.Y, radius, area.Height()) {
+		dy := area.WrapDistance(dot, engine.Dot{X: dot.X, Y: y})
+		if dy > radius {
+			continue
+		}
+		for _, x := range wrapCoordinates(dot.X, radius-dy, area.Width()) {
+			dots = append(dots, engine.Dot{X: x, Y: y})
+		}
+	}
+	return dots
+}
+
+// ringDots returns the dots of the area on the distance from the dot
+func ringDots(area engine.Area, dot engine.Dot, distance uint16) []engine.Dot {
+	dots := make([]engine.Dot, 0, 4*distance+1)
+	for _, y := range wrapCoordinates(dot.Y, distance, area.Height()) {
+		dy := area.WrapDistance(dot, engine.Dot{X: dot.X, Y: y})
+		if dy > distance {
+			continue
+		}
+		for _, x := range wrapCoordinates(dot.X, distance-dy, area.Width()) {
+			if area.WrapDistance(dot, engine.Dot{X: x, Y: y}) == distance {
+				dots = append(dots, engine.Dot{X: x, Y: y})
+			}
+		}
+	}
+	return dots
+}
+
+// rectDots returns the dots of the rect which are in the area
+func rectDots(area engine.Area, rect engine.Rect) []engine.Dot {
+	dots := make([]engine.Dot, 0, rect.DotCount())
+	for i := uint16(0); i < rect.DotCount(); i++ {
+		if dot := rect.Dot(i); area.ContainsDot(dot) {
+			dots = append(dots, dot)
+		}
+	}
+	return dots
+}
+
+func (t *transactional) GetObjectsInRect(rect engine.Rect) []engine.Object {
+	defer t.readUnlock(t.readLock())
+	return t.storage.GetObjectsByDots(rectDots(t.storage.Area(), rect))
+}
+
+func (t *transactional) GetObjectsInRadius(dot engine.Dot, radius uint8) []engine.Object {
+	area := t.storage.Area()
+	if !area.ContainsDot(dot) {
+		return nil
+	}
+
+	defer t.readUnlock(t.readLock())
+	return t.storage.GetObjectsByDots(radiusDots(area, dot, uint16(radius)))
+}
+
+func (t *transactional) GetNearestObjectOfType(dot engine.Dot, objectType reflect.Type) (engine.Object, engine.Dot) {
+	area := t.storage.Area()
+	if !area.ContainsDot(dot) {
+		return nil, engine.Dot{}
+	}
+
+	maxDistance := uint16(area.Width()/2) + uint16(area.Height()/2)
+
+	defer t.readUnlock(t.readLock())
+
+	for distance := uint16(0); distance <= maxDistance; distance++ {
+		for _, ringDot := range ringDots(area, dot, distance) {
+			if object := t.storage.GetObjectByDot(ringDot); object != nil && reflect.TypeOf(object) == objectType {
+				return object, ringDot
+			}
+		}
+	}
+
+	return nil, engine.Dot{}
+}
+
+func (t *transactional) CountFreeDotsInRect(rect engine.Rect) uint16 {
+	defer t.readUnlock(t.readLock())
+
+	var count uint16
+	for _, dot := range rectDots(t.storage.Area(), rect) {
+		if t.storage.GetObjectByDot(dot) == nil {
+			count++
+		}
+	}
+	return count
+}
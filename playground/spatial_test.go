@@ -0,0 +1,41 @@
+package playground
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ivan1993spb/snake-server/engine"
+)
+
+func Test_radiusDots_ringDots(t *testing.T) {
+	area := engine.MustArea(10, 8)
+
+	for _, dot := range []engine.Dot{{X: 0, Y: 0}, {X: 5, Y: 4}, {X: 9, Y: 1}} {
+		for radius := uint16(0); radius <= 10; radius++ {
+			expected := make(map[engine.Dot]struct{})
+			ring := make(map[engine.Dot]struct{})
+			for _, d := range area.Dots() {
+				distance := area.WrapDistance(dot, d)
+				if distance <= radius {
+					expected[d] = struct{}{}
+				}
+				if distance == radius {
+					ring[d] = struct{}{}
+				}
+			}
+
+			dots := radiusDots(area, dot, radius)
+			require.Len(t, dots, len(expected), "dot %s radius %d", dot, radius)
+			for _, d := range dots {
+				require.Contains(t, expected, d)
+			}
+
+			dots = ringDots(area, dot, radius)
+			require.Len(t, dots, len(ring), "dot %s ring %d", dot, radius)
+			for _, d := range dots {
+				require.Contains(t, ring, d)
+			}
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package playground
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ivan1993spb/snake-server/engine"
+)
+
+// OperationType is a type of change of an object in a transaction
+type OperationType uint8
+
+const (
+	OperationCreate OperationType = iota
+	OperationUpdate
+	OperationDelete
+)
+
+var operationTypeLabels = map[OperationType]string{
+	OperationCreate: "create",
+	OperationUpdate: "update",
+	OperationDelete: "delete",
+}
+
+func (t OperationType) String() string {
+	if label, ok := operationTypeLabels[t]; ok {
+		return label
+	}
+	return "unknown"
+}
+
+// Operation is a change of an object applied in a transaction
+type Operation struct {
+	Type   OperationType
+	Object engine.Object
+	// Old is the current location of an updated object
+	Old engine.Location
+	// Location is the location of a created or deleted object or the new
+	// location of an updated object
+	Location engine.Location
+}
+
+// CreateOperation returns an operation which creates the object on the location
+func CreateOperation(object engine.Object, location engine.Location) Operation {
+	return Operation{
+		Type:     OperationCreate,
+		Object:   object,
+		Location: location,
+	}
+}
+
+// UpdateOperation returns an operation which moves the object from the old
+// location to the new one
+func UpdateOperation(object engine.Object, old, new engine.Location) Operation {
+	return Operation{
+		Type:     OperationUpdate,
+		Object:   object,
+		Old:      old,
+		Location: new,
+	}
+}
+
+// DeleteOperation returns an operation which deletes the object from the location
+func DeleteOperation(object engine.Object, location engine.Location) Operation {
+	return Operation{
+		Type:     OperationDelete,
+		Object:   object,
+		Location: location,
+	}
+}
+
+// inverse returns an operation which reverts the operation
+func (op Operation) inverse() Operation {
+	switch op.Type {
+	case OperationCreate:
+		return DeleteOperation(op.Object, op.Location)
+	case OperationUpdate:
+		return UpdateOperation(op.Object, op.Location, op.Old)
+	case OperationDelete:
+		return CreateOperation(op.Object, op.Location)
+	}
+	return op
+}
+
+var errUnknownOperation = errors.New("unknown operation")
+
+type ErrTransaction struct {
+	// Index is the index of the failed operation
+	Index int
+	Err   error
+	// Rollback is the error of the rollback. If it is not nil, the applied
+	// operations have not been reverted completely
+	Rollback error
+}
+
+func (e *ErrTransaction) Error() string {
+	if e.Rollback != nil {
+		return fmt.Sprintf("transaction error: operation %d: %s: rollback: %s", e.Index, e.Err, e.Rollback)
+	}
+	return fmt.Sprintf("transaction error: operation %d: %s", e.Index, e.Err)
+}
+
+// transactional makes a storage transactional. Changes are applied
+// concurrently under the read lock, whereas transactions take the write lock,
+// so no change is applied in the middle of a transaction. Reads take the lock
+// only while a transaction is running, so the lock-free backend is not slowed
+// down by the lock unless transactions are used. A read which has begun just
+// before a transaction may observe the transaction in progress. The contention
+// benchmarks compare wrapped backends with bare ones
+type transactional struct {
+	storage Storage
+	mux     *sync.RWMutex
+
+	// transactions is the number of running and waiting transactions
+	transactions int32
+}
+
+func newTransactional(storage Storage) *transactional {
+	return &transactional{
+		storage: storage,
+		mux:     &sync.RWMutex{},
+	}
+}
+
+// Transaction applies the operations one by one. If an operation fails, the
+// applied operations are reverted in reverse order. Locations of operations
+// must be exact for the operations to be reverted: the location of a deleted
+// object must be the whole location of the object
+func (t *transactional) Transaction(operations []Operation) error {
+	atomic.AddInt32(&t.transactions, 1)
+	defer atomic.AddInt32(&t.transactions, -1)
+
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	for i, op := range operations {
+		if err := t.apply(op); err != nil {
+			return &ErrTransaction{
+				Index:    i,
+				Err:      err,
+				Rollback: t.rollback(operations[:i]),
+			}
+		}
+	}
+
+	return nil
+}
+
+func (t *transactional) apply(op Operation) error {
+	switch op.Type {
+	case OperationCreate:
+		return t.storage.CreateObject(op.Object, op.Location)
+	case OperationUpdate:
+		return t.storage.UpdateObject(op.Object, op.Old, op.Location)
+	case OperationDelete:
+		return t.storage.DeleteObject(op.Object, op.Location)
+	}
+	return errUnknownOperation
+}
+
+// rollback reverts the applied operations. Inverse operations restore the
+// state which has been changed under the same lock, so they fail only if the
+// storage is not consistent. Then the rollback goes on and the first error is
+// returned
+func (t *transactional) rollback(applied []Operation) error {
+	var rollbackErr error
+	for i := len(applied) - 1; i >= 0; i-- {
+		if err := t.apply(applied[i].inverse()); err != nil && rollbackErr == nil {
+			rollbackErr = &ErrTransaction{
+				Index: i,
+				Err:   err,
+			}
+		}
+	}
+	return rollbackErr
+}
+
+// readLock takes the read lock if a transaction is running and returns
+// whether the lock has been taken
+func (t *transactional) readLock() bool {
+	if atomic.LoadInt32(&t.transactions) == 0 {
+		return false
+	}
+	t.mux.RLock()
+	return true
+}
+
+// readUnlock releases the read lock taken by readLock
+func (t *transactional) readUnlock(locked bool) {
+	if locked {
+		t.mux.RUnlock()
+	}
+}
+
+func (t *transactional) CreateObject(object engine.Object, location engine.Location) error {
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+	return t.storage.CreateObject(object, location)
+}
+
+func (t *transactional) CreateObjectAvailableDots(object engine.Object, location engine.Location) (engine.Location, error) {
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+	return t.storage.CreateObjectAvailableDots(object, location)
+}
+
+func (t *transactional) CreateObjectRandomDot(object engine.Object) (engine.Location, error) {
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+	return t.storage.CreateObjectRandomDot(object)
+}
+
+func (t *transactional) CreateObjectRandomRect(object engine.Object, rw, rh uint8) (engine.Location, error) {
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+	return t.storage.CreateObjectRandomRect(object, rw, rh)
+}
+
+func (t *transactional) CreateObjectRandomRectMargin(object engine.Object, rw, rh, margin uint8) (engine.Location, error) {
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+	return t.storage.CreateObjectRandomRectMargin(object, rw, rh, margin)
+}
+
+func (t *transactional) CreateObjectRandomByDotsMask(object engine.Object, dm *engine.DotsMask) (engine.Location, error) {
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+	return t.storage.CreateObjectRandomByDotsMask(object, dm)
+}
+
+func (t *transactional) UpdateObject(object engine.Object, old, new engine.Location) error {
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+	return t.storage.UpdateObject(object, old, new)
+}
+
+func (t *transactional) UpdateObjectAvailableDots(object engine.Object, old, new engine.Location) (engine.Location, error) {
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+	return t.storage.UpdateObjectAvailableDots(object, old, new)
+}
+
+func (t *transactional) DeleteObject(object engine.Object, location engine.Location) error {
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+	return t.storage.DeleteObject(object, location)
+}
+
+func (t *transactional) GetObjectsByDots(dots []engine.Dot) []engine.Object {
+	defer t.readUnlock(t.readLock())
+	return t.storage.GetObjectsByDots(dots)
+}
+
+func (t *transactional) GetObjectByDot(dot engine.Dot) engine.Object {
+	defer t.readUnlock(t.readLock())
+	return t.storage.GetObjectByDot(dot)
+}
+
+func (t *transactional) LocationOccupied(location engine.Location) bool {
+	defer t.readUnlock(t.readLock())
+	return t.storage.LocationOccupied(location)
+}
+
+func (t *transactional) Area() engine.Area {
+	return t.storage.Area()
+}
+
+func (t *transactional) GetObjects() []engine.Object {
+	defer t.readUnlock(t.readLock())
+	return t.storage.GetObjects()
+}
@@ -0,0 +1,46 @@
+package playground
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ivan1993spb/snake-server/engine"
+)
+
+var errTestCreateObject = errors.New("cannot create object")
+
+// failingCreateStorage is a storage which cannot create objects
+type failingCreateStorage struct {
+	Storage
+}
+
+func (s failingCreateStorage) CreateObject(object engine.Object, location engine.Location) error {
+	return errTestCreateObject
+}
+
+func Test_transactional_Transaction_ReportsRollbackError(t *testing.T) {
+	storage, err := NewPlaygroundCMap(10, 10)
+	require.Nil(t, err)
+
+	first := &struct{ n int }{1}
+	second := &struct{ n int }{2}
+	require.Nil(t, storage.CreateObject(first, engine.Location{{X: 1, Y: 1}}))
+
+	pg := newTransactional(failingCreateStorage{storage})
+
+	// The deleted object cannot be created back by the rollback
+	err = pg.Transaction([]Operation{
+		DeleteOperation(first, engine.Location{{X: 1, Y: 1}}),
+		CreateOperation(second, engine.Location{{X: 1, Y: 1}}),
+	})
+	require.NotNil(t, err)
+
+	transactionErr := err.(*ErrTransaction)
+	require.Equal(t, 1, transactionErr.Index)
+	require.Equal(t, errTestCreateObject, transactionErr.Err)
+	require.NotNil(t, transactionErr.Rollback)
+	require.Equal(t, errTestCreateObject, transactionErr.Rollback.(*ErrTransaction).Err)
+	require.Contains(t, err.Error(), "rollback")
+}
@@ -2,6 +2,12 @@
 
 package world
 
+import (
+	"time"
+
+	"github.com/ivan1993spb/snake-server/engine"
+)
+
 type EventType uint8
 
 const (
@@ -46,4 +52,14 @@ func (event EventType) MarshalJSON() ([]byte, error) {
 type Event struct {
 	Type    EventType
 	Payload interface{}
+	// Seq is the sequence number of the event in the world. Every
+	// subscriber receives events in the order of the sequence
+	Seq uint64
+	// Time is a monotonic time of the event since the world has started
+	Time time.Duration
+
+	// location is the location of the object for filtering by region
+	location engine.Location
+	// previous is the old location of the object in update events
+	previous engine.Location
 }
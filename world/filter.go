@@ -0,0 +1,78 @@
+package world
+
+import (
+	"reflect"
+
+	"github.com/ivan1993spb/snake-server/engine"
+)
+
+// EventFilter selects events for a subscription. Empty fields match any event.
+// Filters are applied by the world before events are copied to subscribers,
+// so a subscriber receives a subsequence of the world's events with gaps in
+// sequence numbers
+type EventFilter struct {
+	// Types are event types to receive
+	Types []EventType
+	// ObjectTypes are types of event payloads to receive
+	ObjectTypes []reflect.Type
+	// Region is a map region to receive events of. An event matches if any
+	// dot of the object location is in the region. For update events both
+	// the old and the new locations are checked, so a subscriber is notified
+	// when an object leaves the region. Events without a location, such as
+	// errors, do not match
+	Region *engine.Rect
+}
+
+// ObjectTypesOf returns types of the given objects for EventFilter
+func ObjectTypesOf(objects ...engine.Object) []reflect.Type {
+	types := make([]reflect.Type, 0, len(objects))
+	for _, object := range objects {
+		types = append(types, reflect.TypeOf(object))
+	}
+	return types
+}
+
+func (f EventFilter) match(event Event) bool {
+	return f.matchType(event.Type) && f.matchObjectType(event.Payload) && f.matchRegion(event)
+}
+
+func (f EventFilter) matchType(eventType EventType) bool {
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (f EventFilter) matchObjectType(payload interface{}) bool {
+	if len(f.ObjectTypes) == 0 {
+		return true
+	}
+	payloadType := reflect.TypeOf(payload)
+	for _, t := range f.ObjectTypes {
+		if t == payloadType {
+			return true
+		}
+	}
+	return false
+}
+
+func (f EventFilter) matchRegion(event Event) bool {
+	if f.Region == nil {
+		return true
+	}
+	return locationInRect(event.location, *f.Region) || locationInRect(event.previous, *f.Region)
+}
+
+func locationInRect(location engine.Location, rect engine.Rect) bool {
+	for _, dot := range location {
+		if rect.ContainsDot(dot) {
+			return true
+		}
+	}
+	return false
+}
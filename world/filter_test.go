@@ -0,0 +1,87 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ivan1993spb/snake-server/engine"
+)
+
+type testFilterObject struct {
+	n int
+}
+
+func Test_EventFilter_match(t *testing.T) {
+	object := &testFilterObject{}
+	region := engine.NewRect(10, 10, 5, 5)
+
+	tests := []struct {
+		filter   EventFilter
+		event    Event
+		expected bool
+	}{
+		{
+			filter:   EventFilter{},
+			event:    Event{Type: EventTypeError},
+			expected: true,
+		},
+		{
+			filter:   EventFilter{Types: []EventType{EventTypeObjectDelete}},
+			event:    Event{Type: EventTypeObjectDelete, Payload: object},
+			expected: true,
+		},
+		{
+			filter:   EventFilter{Types: []EventType{EventTypeObjectDelete}},
+			event:    Event{Type: EventTypeObjectUpdate, Payload: object},
+			expected: false,
+		},
+		{
+			filter:   EventFilter{ObjectTypes: ObjectTypesOf((*testFilterObject)(nil))},
+			event:    Event{Type: EventTypeObjectCreate, Payload: object},
+			expected: true,
+		},
+		{
+			filter:   EventFilter{ObjectTypes: ObjectTypesOf((*testFilterObject)(nil))},
+			event:    Event{Type: EventTypeObjectCreate, Payload: &struct{ n int }{}},
+			expected: false,
+		},
+		{
+			filter: EventFilter{Region: &region},
+			event: Event{
+				Type:     EventTypeObjectCreate,
+				Payload:  object,
+				location: engine.Location{engine.Dot{X: 0, Y: 0}, engine.Dot{X: 12, Y: 14}},
+			},
+			expected: true,
+		},
+		{
+			filter: EventFilter{Region: &region},
+			event: Event{
+				Type:     EventTypeObjectCreate,
+				Payload:  object,
+				location: engine.Location{engine.Dot{X: 15, Y: 15}},
+			},
+			expected: false,
+		},
+		{
+			filter: EventFilter{Region: &region},
+			event: Event{
+				Type:     EventTypeObjectUpdate,
+				Payload:  object,
+				location: engine.Location{engine.Dot{X: 20, Y: 20}},
+				previous: engine.Location{engine.Dot{X: 14, Y: 14}},
+			},
+			expected: true,
+		},
+		{
+			filter:   EventFilter{Region: &region},
+			event:    Event{Type: EventTypeError},
+			expected: false,
+		},
+	}
+
+	for i, test := range tests {
+		require.Equal(t, test.expected, test.filter.match(test.event), "number %d", i)
+	}
+}
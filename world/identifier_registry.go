@@ -2,12 +2,12 @@ package world
 
 import (
 	"math"
+	"math/bits"
+	"sort"
 	"strconv"
 	"sync"
 )
 
-const identifiersBufferSize = 2 << 12
-
 const (
 	indexStart uint32 = 0
 	indexDelta uint32 = 1
@@ -19,17 +19,103 @@ func (i Identifier) String() string {
 	return strconv.FormatUint(uint64(i), 10)
 }
 
+const identifierSetWordBits = 64
+
+// identifierSet is a sparse bitmap of identifiers. Every word of the bitmap
+// holds 64 consecutive identifiers, so obtained identifiers are skipped by
+// words rather than one by one
+type identifierSet struct {
+	words map[uint32]uint64
+	count int
+}
+
+func newIdentifierSet() *identifierSet {
+	return &identifierSet{
+		words: make(map[uint32]uint64),
+	}
+}
+
+func identifierWord(id Identifier) (uint32, uint64) {
+	return uint32(id) / identifierSetWordBits, 1 << (uint32(id) % identifierSetWordBits)
+}
+
+func (s *identifierSet) contains(id Identifier) bool {
+	key, bit := identifierWord(id)
+	return s.words[key]&bit != 0
+}
+
+func (s *identifierSet) add(id Identifier) bool {
+	key, bit := identifierWord(id)
+	word := s.words[key]
+	if word&bit != 0 {
+		return false
+	}
+	s.words[key] = word | bit
+	s.count++
+	return true
+}
+
+func (s *identifierSet) remove(id Identifier) bool {
+	key, bit := identifierWord(id)
+	word, ok := s.words[key]
+	if !ok || word&bit == 0 {
+		return false
+	}
+	if word &^= bit; word == 0 {
+		delete(s.words, key)
+	} else {
+		s.words[key] = word
+	}
+	s.count--
+	return true
+}
+
+// nextFree returns the first identifier which is not in the set starting from
+// the given one. It returns false if all identifiers up to the maximal one are
+// in the set
+func (s *identifierSet) nextFree(from uint64) (Identifier, bool) {
+	for from <= math.MaxUint32 {
+		key := uint32(from / identifierSetWordBits)
+		offset := from % identifierSetWordBits
+		if free := ^s.words[key] >> offset; free != 0 {
+			return Identifier(from + uint64(bits.TrailingZeros64(free))), true
+		}
+		from = (from/identifierSetWordBits + 1) * identifierSetWordBits
+	}
+	return 0, false
+}
+
+// identifiers returns sorted identifiers of the set
+func (s *identifierSet) identifiers() []Identifier {
+	ids := make([]Identifier, 0, s.count)
+	for key, word := range s.words {
+		for ; word != 0; word &= word - 1 {
+			ids = append(ids, Identifier(key*identifierSetWordBits+uint32(bits.TrailingZeros64(word))))
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// IdentifierRegistry issues unique identifiers of objects. Identifiers are
+// issued in ascending order and the counter wraps around, so a released
+// identifier is not reused soon. Release takes constant time. Obtain skips
+// obtained identifiers by 64 at once only after the counter has wrapped
+// around and every identifier is skipped once per cycle of the counter, so
+// Obtain takes amortized constant time
 type IdentifierRegistry struct {
-	index               uint32
-	obtainedIdentifiers []Identifier
-	mux                 *sync.Mutex
+	index    uint32
+	obtained *identifierSet
+	mux      *sync.Mutex
 }
 
 func NewIdentifierRegistry() *IdentifierRegistry {
 	return &IdentifierRegistry{
-		index:               indexStart,
-		obtainedIdentifiers: make([]Identifier, 0, identifiersBufferSize),
-		mux:                 &sync.Mutex{},
+		index:    indexStart,
+		obtained: newIdentifierSet(),
+		mux:      &sync.Mutex{},
 	}
 }
 
@@ -39,49 +125,64 @@ func (ir *IdentifierRegistry) Obtain() Identifier {
 
 	ir.incrementIndex()
 	id := Identifier(ir.index)
-	ir.unsafeRegisterIdentifier(id)
+	ir.obtained.add(id)
 
 	return id
 }
 
 func (ir *IdentifierRegistry) incrementIndex() {
-	for {
-		if ir.index == math.MaxUint32 {
-			ir.index = indexStart
-		}
+	id, ok := ir.obtained.nextFree(uint64(ir.index) + uint64(indexDelta))
+	if !ok {
+		// Wrap around. All identifiers cannot be obtained at once in practice
+		id, _ = ir.obtained.nextFree(uint64(indexStart + indexDelta))
+	}
+	ir.index = uint32(id)
+}
 
-		ir.index += indexDelta
+func (ir *IdentifierRegistry) unsafeIsObtainedIdentifier(id Identifier) bool {
+	return ir.obtained.contains(id)
+}
 
-		if !ir.unsafeIsObtainedIdentifier(Identifier(ir.index)) {
-			break
-		}
-	}
+func (ir *IdentifierRegistry) Release(id Identifier) {
+	ir.mux.Lock()
+	defer ir.mux.Unlock()
+	ir.obtained.remove(id)
 }
 
-func (ir *IdentifierRegistry) unsafeRegisterIdentifier(id Identifier) {
-	ir.obtainedIdentifiers = append(ir.obtainedIdentifiers, id)
+// Count returns the number of obtained identifiers
+func (ir *IdentifierRegistry) Count() int {
+	ir.mux.Lock()
+	defer ir.mux.Unlock()
+	return ir.obtained.count
 }
 
-func (ir *IdentifierRegistry) unsafeIsObtainedIdentifier(id Identifier) bool {
-	for _, obtainedIdentifier := range ir.obtainedIdentifiers {
-		if obtainedIdentifier == id {
-			return true
-		}
-	}
-	return false
+// IdentifierRegistrySnapshot represents the state of a registry which
+// could be saved and restored later
+type IdentifierRegistrySnapshot struct {
+	Index    uint32       `json:"index"`
+	Obtained []Identifier `json:"obtained"`
 }
 
-func (ir *IdentifierRegistry) Release(id Identifier) {
+// Snapshot returns the current state of the registry. Obtained identifiers
+// are sorted
+func (ir *IdentifierRegistry) Snapshot() IdentifierRegistrySnapshot {
 	ir.mux.Lock()
 	defer ir.mux.Unlock()
-	ir.unsafeRelease(id)
+
+	return IdentifierRegistrySnapshot{
+		Index:    ir.index,
+		Obtained: ir.obtained.identifiers(),
+	}
 }
 
-func (ir *IdentifierRegistry) unsafeRelease(id Identifier) {
-	for i, obtainedIdentifier := range ir.obtainedIdentifiers {
-		if obtainedIdentifier == id {
-			ir.obtainedIdentifiers = append(ir.obtainedIdentifiers[:i], ir.obtainedIdentifiers[i+1:]...)
-			return
-		}
+// Restore replaces the state of the registry with the given snapshot
+func (ir *IdentifierRegistry) Restore(snapshot IdentifierRegistrySnapshot) {
+	ir.mux.Lock()
+	defer ir.mux.Unlock()
+
+	ir.index = snapshot.Index
+	ir.obtained = newIdentifierSet()
+	for _, id := range snapshot.Obtained {
+		ir.obtained.add(id)
 	}
 }
@@ -0,0 +1,57 @@
+package world
+
+import (
+	"math"
+	"testing"
+)
+
+func rawBenchmarkIdentifierRegistryObtainRelease(b *testing.B, live int) {
+	b.ReportAllocs()
+
+	ir := NewIdentifierRegistry()
+	ids := make([]Identifier, live)
+	for i := range ids {
+		ids[i] = ir.Obtain()
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		j := i % live
+		ir.Release(ids[j])
+		ids[j] = ir.Obtain()
+	}
+}
+
+func Benchmark_IdentifierRegistry_ObtainRelease_100(b *testing.B) {
+	rawBenchmarkIdentifierRegistryObtainRelease(b, 100)
+}
+
+func Benchmark_IdentifierRegistry_ObtainRelease_10000(b *testing.B) {
+	rawBenchmarkIdentifierRegistryObtainRelease(b, 10000)
+}
+
+// Benchmark_IdentifierRegistry_Obtain_WrapAround measures the worst case:
+// the counter wraps around to a long run of obtained identifiers every time
+func Benchmark_IdentifierRegistry_Obtain_WrapAround(b *testing.B) {
+	const live = 10000
+
+	b.ReportAllocs()
+
+	obtained := make([]Identifier, 0, live)
+	for id := Identifier(1); id <= live; id++ {
+		obtained = append(obtained, id)
+	}
+
+	ir := NewIdentifierRegistry()
+	ir.Restore(IdentifierRegistrySnapshot{
+		Obtained: obtained,
+	})
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		ir.index = math.MaxUint32
+		ir.Release(ir.Obtain())
+	}
+}
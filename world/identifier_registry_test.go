@@ -3,19 +3,27 @@ package world
 import (
 	"fmt"
 	"math"
-	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 )
 
+func newTestIdentifierRegistry(index uint32, identifiers []Identifier) *IdentifierRegistry {
+	ir := NewIdentifierRegistry()
+	ir.Restore(IdentifierRegistrySnapshot{
+		Index:    index,
+		Obtained: identifiers,
+	})
+	return ir
+}
+
 func Test_NewIdentifierRegistry_CreatesIdentifierRegistry(t *testing.T) {
 	ir := NewIdentifierRegistry()
 
 	require.True(t, indexStart == ir.index)
 
-	require.Len(t, ir.obtainedIdentifiers, 0)
-	require.Equal(t, identifiersBufferSize, cap(ir.obtainedIdentifiers))
+	require.Equal(t, 0, ir.Count())
+	require.NotNil(t, ir.obtained)
 
 	require.NotNil(t, ir.mux)
 }
@@ -24,42 +32,30 @@ func Test_IdentifierRegistry_Release_ReleasesIdentifierFromStartCorrectly(t *tes
 	identifiers := []Identifier{1, 2, 3, 4, 5, 6}
 	index := indexStart + indexDelta*uint32(len(identifiers))
 
-	ir := &IdentifierRegistry{
-		obtainedIdentifiers: identifiers,
-		mux:                 &sync.Mutex{},
-		index:               index,
-	}
+	ir := newTestIdentifierRegistry(index, identifiers)
 
 	ir.Release(Identifier(1))
-	require.Equal(t, []Identifier{2, 3, 4, 5, 6}, ir.obtainedIdentifiers)
+	require.Equal(t, []Identifier{2, 3, 4, 5, 6}, ir.Snapshot().Obtained)
 }
 
 func Test_IdentifierRegistry_Release_ReleasesIdentifierFromMiddleCorrectly(t *testing.T) {
 	identifiers := []Identifier{1, 2, 3, 4, 5, 6}
 	index := indexStart + indexDelta*uint32(len(identifiers))
 
-	ir := &IdentifierRegistry{
-		obtainedIdentifiers: identifiers,
-		mux:                 &sync.Mutex{},
-		index:               index,
-	}
+	ir := newTestIdentifierRegistry(index, identifiers)
 
 	ir.Release(Identifier(4))
-	require.Equal(t, []Identifier{1, 2, 3, 5, 6}, ir.obtainedIdentifiers)
+	require.Equal(t, []Identifier{1, 2, 3, 5, 6}, ir.Snapshot().Obtained)
 }
 
 func Test_IdentifierRegistry_Release_ReleasesIdentifierFromEndCorrectly(t *testing.T) {
 	identifiers := []Identifier{1, 2, 3, 4, 5, 6}
 	index := indexStart + indexDelta*uint32(len(identifiers))
 
-	ir := &IdentifierRegistry{
-		obtainedIdentifiers: identifiers,
-		mux:                 &sync.Mutex{},
-		index:               index,
-	}
+	ir := newTestIdentifierRegistry(index, identifiers)
 
 	ir.Release(Identifier(6))
-	require.Equal(t, []Identifier{1, 2, 3, 4, 5}, ir.obtainedIdentifiers)
+	require.Equal(t, []Identifier{1, 2, 3, 4, 5}, ir.Snapshot().Obtained)
 }
 
 func Test_IdentifierRegistry_unsafeIsObtainedIdentifier_worksCorrectly(t *testing.T) {
@@ -78,11 +74,7 @@ func Test_IdentifierRegistry_unsafeIsObtainedIdentifier_worksCorrectly(t *testin
 
 	for number, test := range tests {
 		index := indexStart + indexDelta*uint32(len(test.identifiers))
-		ir := &IdentifierRegistry{
-			obtainedIdentifiers: test.identifiers,
-			mux:                 &sync.Mutex{},
-			index:               index,
-		}
+		ir := newTestIdentifierRegistry(index, test.identifiers)
 		msg := fmt.Sprintf("error test case: %d", number)
 		require.Equal(t, test.expected, ir.unsafeIsObtainedIdentifier(test.identifier), msg)
 	}
@@ -107,11 +99,7 @@ func Test_IdentifierRegistry_incrementIndex_worksCorrectly(t *testing.T) {
 	}
 
 	for number, test := range tests {
-		ir := &IdentifierRegistry{
-			obtainedIdentifiers: test.obtainedIdentifiers,
-			mux:                 &sync.Mutex{},
-			index:               test.currentIndex,
-		}
+		ir := newTestIdentifierRegistry(test.currentIndex, test.obtainedIdentifiers)
 
 		msg := fmt.Sprintf("error test case: %d", number)
 
@@ -174,11 +162,7 @@ func TestIdentifierRegistry_Obtain_ObtainsIdentifierCorrectly(t *testing.T) {
 	}
 
 	for number, test := range tests {
-		ir := &IdentifierRegistry{
-			obtainedIdentifiers: test.currentObtainedIdentifiers,
-			mux:                 &sync.Mutex{},
-			index:               test.currentIndex,
-		}
+		ir := newTestIdentifierRegistry(test.currentIndex, test.currentObtainedIdentifiers)
 
 		msg := fmt.Sprintf("error test case: %d", number)
 
@@ -204,3 +188,63 @@ func Test_Identifier_String(t *testing.T) {
 		require.Equal(t, test.str, test.id.String(), fmt.Sprintf("error test case: %d", number))
 	}
 }
+
+func Test_IdentifierRegistry_Restore_RestoresSnapshot(t *testing.T) {
+	ir := NewIdentifierRegistry()
+	for i := 0; i < 10; i++ {
+		ir.Obtain()
+	}
+	ir.Release(Identifier(3))
+	ir.Release(Identifier(7))
+
+	snapshot := ir.Snapshot()
+	require.Equal(t, uint32(10), snapshot.Index)
+	require.Equal(t, []Identifier{1, 2, 4, 5, 6, 8, 9, 10}, snapshot.Obtained)
+
+	restored := NewIdentifierRegistry()
+	restored.Restore(snapshot)
+	require.Equal(t, snapshot, restored.Snapshot())
+	require.Equal(t, Identifier(11), restored.Obtain())
+}
+
+func Test_identifierSet_nextFree_SkipsObtainedIdentifiers(t *testing.T) {
+	s := newIdentifierSet()
+	for id := Identifier(1); id < 200; id++ {
+		require.True(t, s.add(id))
+	}
+	require.False(t, s.add(Identifier(10)))
+	require.Equal(t, 199, s.count)
+
+	id, ok := s.nextFree(1)
+	require.True(t, ok)
+	require.Equal(t, Identifier(200), id)
+
+	require.True(t, s.remove(Identifier(64)))
+	require.False(t, s.remove(Identifier(64)))
+
+	id, ok = s.nextFree(1)
+	require.True(t, ok)
+	require.Equal(t, Identifier(64), id)
+
+	require.True(t, s.add(Identifier(math.MaxUint32)))
+	_, ok = s.nextFree(math.MaxUint32)
+	require.False(t, ok)
+}
+
+func Test_IdentifierRegistry_Obtain_WrapsAroundObtainedIdentifiers(t *testing.T) {
+	identifiers := make([]Identifier, 0, 1000)
+	for id := Identifier(1); id <= 1000; id++ {
+		identifiers = append(identifiers, id)
+	}
+
+	ir := newTestIdentifierRegistry(math.MaxUint32, identifiers)
+	require.Equal(t, 1000, ir.Count())
+
+	require.Equal(t, Identifier(1001), ir.Obtain())
+	require.Equal(t, 1001, ir.Count())
+
+	ir.Release(Identifier(500))
+	ir.Release(Identifier(500))
+	require.Equal(t, 1000, ir.Count())
+	require.Equal(t, Identifier(1002), ir.Obtain())
+}
@@ -5,6 +5,8 @@ import "github.com/ivan1993spb/snake-server/playground"
 type Interface interface {
 	Start(stop <-chan struct{})
 	Events(stop <-chan struct{}, buffer uint) <-chan Event
+	LosslessEvents(stop <-chan struct{}, buffer uint) <-chan Event
+	FilteredEvents(stop <-chan struct{}, buffer uint, filter EventFilter) <-chan Event
 
 	IdentifierRegistry() *IdentifierRegistry
 
@@ -2,6 +2,7 @@ package world
 
 import (
 	"fmt"
+	"reflect"
 	"sync"
 	"time"
 
@@ -16,20 +17,38 @@ const (
 
 const worldEventsSendTimeout = time.Millisecond
 
+// chanProxy is a subscriber's channel with the filter of events to receive
+type chanProxy struct {
+	ch     chan Event
+	filter EventFilter
+}
+
 type World struct {
 	pg          playground.Playground
 	chMain      chan Event
-	chsProxy    []chan Event
+	chMainMux   *sync.Mutex
+	chsProxy    []chanProxy
 	chsProxyMux *sync.RWMutex
 	stopGlobal  chan struct{}
 	flagStarted bool
 	startedMux  *sync.Mutex
 
+	// started is the start time of the world's clock
+	started time.Time
+	// seq is the sequence number of the last broadcasted event
+	seq uint64
+
 	identifierRegistry *IdentifierRegistry
 }
 
+// NewWorld creates a world with the default playground backend
 func NewWorld(width, height uint8) (*World, error) {
-	pg, err := playground.NewPlaygroundCMap(width, height)
+	return NewWorldWithBackend(playground.BackendCMap, width, height)
+}
+
+// NewWorldWithBackend creates a world with the given playground backend
+func NewWorldWithBackend(backend playground.Backend, width, height uint8) (*World, error) {
+	pg, err := playground.New(backend, width, height)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create world: %s", err)
 	}
@@ -37,7 +56,8 @@ func NewWorld(width, height uint8) (*World, error) {
 	return &World{
 		pg:          pg,
 		chMain:      make(chan Event, worldEventsChanMainBufferSize),
-		chsProxy:    make([]chan Event, 0),
+		chMainMux:   &sync.Mutex{},
+		chsProxy:    make([]chanProxy, 0),
 		chsProxyMux: &sync.RWMutex{},
 		stopGlobal:  make(chan struct{}),
 
@@ -49,12 +69,29 @@ func NewWorld(width, height uint8) (*World, error) {
 }
 
 func (w *World) event(event Event) {
+	w.chMainMux.Lock()
+	defer w.chMainMux.Unlock()
+
 	select {
 	case w.chMain <- event:
 	case <-w.stopGlobal:
 	}
 }
 
+// events sends the events in a row, so they get consecutive sequence numbers
+func (w *World) events(events []Event) {
+	w.chMainMux.Lock()
+	defer w.chMainMux.Unlock()
+
+	for _, event := range events {
+		select {
+		case w.chMain <- event:
+		case <-w.stopGlobal:
+			return
+		}
+	}
+}
+
 func (w *World) Start(stop <-chan struct{}) {
 	w.startedMux.Lock()
 	defer w.startedMux.Unlock()
@@ -63,6 +100,7 @@ func (w *World) Start(stop <-chan struct{}) {
 		return
 	}
 	w.flagStarted = true
+	w.started = time.Now()
 
 	go func() {
 		select {
@@ -78,6 +116,9 @@ func (w *World) Start(stop <-chan struct{}) {
 				if !ok {
 					return
 				}
+				w.seq++
+				event.Seq = w.seq
+				event.Time = time.Since(w.started)
 				w.broadcast(event)
 			case <-w.stopGlobal:
 				return
@@ -91,18 +132,25 @@ func (w *World) broadcast(event Event) {
 	defer w.chsProxyMux.RUnlock()
 
 	for _, chProxy := range w.chsProxy {
+		if !chProxy.filter.match(event) {
+			continue
+		}
+
 		select {
-		case chProxy <- event:
+		case chProxy.ch <- event:
 		case <-w.stopGlobal:
 		}
 	}
 }
 
-func (w *World) createChanProxy() chan Event {
+func (w *World) createChanProxy(filter EventFilter) chan Event {
 	chProxy := make(chan Event, worldEventsChanProxyBufferSize)
 
 	w.chsProxyMux.Lock()
-	w.chsProxy = append(w.chsProxy, chProxy)
+	w.chsProxy = append(w.chsProxy, chanProxy{
+		ch:     chProxy,
+		filter: filter,
+	})
 	w.chsProxyMux.Unlock()
 
 	return chProxy
@@ -116,7 +164,7 @@ func (w *World) deleteChanProxy(chProxy chan Event) {
 
 	w.chsProxyMux.Lock()
 	for i := range w.chsProxy {
-		if w.chsProxy[i] == chProxy {
+		if w.chsProxy[i].ch == chProxy {
 			w.chsProxy = append(w.chsProxy[:i], w.chsProxy[i+1:]...)
 			close(chProxy)
 			break
@@ -125,8 +173,26 @@ func (w *World) deleteChanProxy(chProxy chan Event) {
 	w.chsProxyMux.Unlock()
 }
 
+// Events returns a channel of all events in the world
 func (w *World) Events(stop <-chan struct{}, buffer uint) <-chan Event {
-	chProxy := w.createChanProxy()
+	return w.FilteredEvents(stop, buffer, EventFilter{})
+}
+
+// LosslessEvents returns a channel of all events in the world. Unlike Events
+// it doesn't drop update and checked events which cannot be sent in time, so
+// the world waits for the subscriber
+func (w *World) LosslessEvents(stop <-chan struct{}, buffer uint) <-chan Event {
+	return w.subscribe(stop, buffer, EventFilter{}, true)
+}
+
+// FilteredEvents returns a channel of events in the world which match the
+// filter. The filter must not be modified after the call
+func (w *World) FilteredEvents(stop <-chan struct{}, buffer uint, filter EventFilter) <-chan Event {
+	return w.subscribe(stop, buffer, filter, false)
+}
+
+func (w *World) subscribe(stop <-chan struct{}, buffer uint, filter EventFilter, lossless bool) <-chan Event {
+	chProxy := w.createChanProxy(filter)
 	chOut := make(chan Event, buffer)
 
 	go func() {
@@ -140,7 +206,11 @@ func (w *World) Events(stop <-chan struct{}, buffer uint) <-chan Event {
 			case <-w.stopGlobal:
 				return
 			case event := <-chProxy:
-				w.sendEvent(chOut, event, stop)
+				if lossless {
+					w.sendEventStrict(chOut, event, stop)
+				} else {
+					w.sendEvent(chOut, event, stop)
+				}
 			}
 		}
 	}()
@@ -182,8 +252,8 @@ func (w *World) stop() {
 	w.chsProxyMux.Lock()
 	defer w.chsProxyMux.Unlock()
 
-	for _, ch := range w.chsProxy {
-		close(ch)
+	for _, chProxy := range w.chsProxy {
+		close(chProxy.ch)
 	}
 
 	w.chsProxy = w.chsProxy[:0]
@@ -192,8 +262,9 @@ func (w *World) stop() {
 func (w *World) GetObjectByDot(dot engine.Dot) engine.Object {
 	if object := w.pg.GetObjectByDot(dot); object != nil {
 		w.event(Event{
-			Type:    EventTypeObjectChecked,
-			Payload: object,
+			Type:     EventTypeObjectChecked,
+			Payload:  object,
+			location: engine.Location{dot},
 		})
 		return object
 	}
@@ -206,6 +277,8 @@ func (w *World) GetObjectsByDots(dots []engine.Dot) []engine.Object {
 			w.event(Event{
 				Type:    EventTypeObjectChecked,
 				Payload: object,
+				// The exact location of every object is unknown here
+				location: dots,
 			})
 		}
 		return objects
@@ -222,8 +295,9 @@ func (w *World) CreateObject(object engine.Object, location engine.Location) err
 		return err
 	}
 	w.event(Event{
-		Type:    EventTypeObjectCreate,
-		Payload: object,
+		Type:     EventTypeObjectCreate,
+		Payload:  object,
+		location: location,
 	})
 	return nil
 }
@@ -238,8 +312,9 @@ func (w *World) CreateObjectAvailableDots(object engine.Object, location engine.
 		return nil, err
 	}
 	w.event(Event{
-		Type:    EventTypeObjectCreate,
-		Payload: object,
+		Type:     EventTypeObjectCreate,
+		Payload:  object,
+		location: location,
 	})
 	return location, nil
 }
@@ -254,8 +329,9 @@ func (w *World) DeleteObject(object engine.Object, location engine.Location) err
 		return err
 	}
 	w.event(Event{
-		Type:    EventTypeObjectDelete,
-		Payload: object,
+		Type:     EventTypeObjectDelete,
+		Payload:  object,
+		location: location,
 	})
 	return nil
 }
@@ -269,8 +345,10 @@ func (w *World) UpdateObject(object engine.Object, old, new engine.Location) err
 		return err
 	}
 	w.event(Event{
-		Type:    EventTypeObjectUpdate,
-		Payload: object,
+		Type:     EventTypeObjectUpdate,
+		Payload:  object,
+		location: new,
+		previous: old,
 	})
 	return nil
 }
@@ -285,8 +363,10 @@ func (w *World) UpdateObjectAvailableDots(object engine.Object, old, new engine.
 		return nil, err
 	}
 	w.event(Event{
-		Type:    EventTypeObjectUpdate,
-		Payload: object,
+		Type:     EventTypeObjectUpdate,
+		Payload:  object,
+		location: location,
+		previous: old,
 	})
 	return location, nil
 }
@@ -301,8 +381,9 @@ func (w *World) CreateObjectRandomDot(object engine.Object) (engine.Location, er
 		return nil, err
 	}
 	w.event(Event{
-		Type:    EventTypeObjectCreate,
-		Payload: object,
+		Type:     EventTypeObjectCreate,
+		Payload:  object,
+		location: location,
 	})
 	return location, nil
 }
@@ -317,8 +398,9 @@ func (w *World) CreateObjectRandomRect(object engine.Object, rw, rh uint8) (engi
 		return nil, err
 	}
 	w.event(Event{
-		Type:    EventTypeObjectCreate,
-		Payload: object,
+		Type:     EventTypeObjectCreate,
+		Payload:  object,
+		location: location,
 	})
 	return location, nil
 }
@@ -333,8 +415,9 @@ func (w *World) CreateObjectRandomRectMargin(object engine.Object, rw, rh, margi
 		return nil, err
 	}
 	w.event(Event{
-		Type:    EventTypeObjectCreate,
-		Payload: object,
+		Type:     EventTypeObjectCreate,
+		Payload:  object,
+		location: location,
 	})
 	return location, nil
 }
@@ -349,12 +432,60 @@ func (w *World) CreateObjectRandomByDotsMask(object engine.Object, dm *engine.Do
 		return nil, err
 	}
 	w.event(Event{
-		Type:    EventTypeObjectCreate,
-		Payload: object,
+		Type:     EventTypeObjectCreate,
+		Payload:  object,
+		location: location,
 	})
 	return location, nil
 }
 
+// Transaction applies the operations atomically. The events of the operations
+// are emitted together in the order of the operations. As with single
+// operations, the events are emitted after the playground is unlocked, so an
+// event of a concurrent operation on other objects may precede them. The
+// events of an object keep their order if the object is locked while it is
+// changed, as game objects do
+func (w *World) Transaction(operations []playground.Operation) error {
+	if err := w.pg.Transaction(operations); err != nil {
+		w.event(Event{
+			Type:    EventTypeError,
+			Payload: err,
+		})
+		return err
+	}
+
+	events := make([]Event, 0, len(operations))
+	for _, op := range operations {
+		events = append(events, operationEvent(op))
+	}
+	w.events(events)
+
+	return nil
+}
+
+func operationEvent(op playground.Operation) Event {
+	switch op.Type {
+	case playground.OperationCreate:
+		return Event{
+			Type:     EventTypeObjectCreate,
+			Payload:  op.Object,
+			location: op.Location,
+		}
+	case playground.OperationUpdate:
+		return Event{
+			Type:     EventTypeObjectUpdate,
+			Payload:  op.Object,
+			location: op.Location,
+			previous: op.Old,
+		}
+	}
+	return Event{
+		Type:     EventTypeObjectDelete,
+		Payload:  op.Object,
+		location: op.Location,
+	}
+}
+
 func (w *World) LocationOccupied(location engine.Location) bool {
 	return w.pg.LocationOccupied(location)
 }
@@ -367,6 +498,24 @@ func (w *World) GetObjects() []engine.Object {
 	return w.pg.GetObjects()
 }
 
+// GetObjectsInRect returns objects in the rect. Spatial queries do not emit
+// events unlike GetObjectByDot
+func (w *World) GetObjectsInRect(rect engine.Rect) []engine.Object {
+	return w.pg.GetObjectsInRect(rect)
+}
+
+func (w *World) GetObjectsInRadius(dot engine.Dot, radius uint8) []engine.Object {
+	return w.pg.GetObjectsInRadius(dot, radius)
+}
+
+func (w *World) GetNearestObjectOfType(dot engine.Dot, objectType reflect.Type) (engine.Object, engine.Dot) {
+	return w.pg.GetNearestObjectOfType(dot, objectType)
+}
+
+func (w *World) CountFreeDotsInRect(rect engine.Rect) uint16 {
+	return w.pg.CountFreeDotsInRect(rect)
+}
+
 func (w *World) IdentifierRegistry() *IdentifierRegistry {
 	return w.identifierRegistry
 }
@@ -3,6 +3,7 @@ package world
 import (
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -11,14 +12,15 @@ import (
 )
 
 func Test_World_Events(t *testing.T) {
-	pg, err := playground.NewPlaygroundCMap(100, 100)
+	pg, err := playground.New(playground.BackendCMap, 100, 100)
 	require.Nil(t, err, "cannot initialize playground")
 	require.NotNil(t, pg, "cannot initialize playground")
 
 	world := &World{
 		pg:          pg,
 		chMain:      make(chan Event, worldEventsChanMainBufferSize),
-		chsProxy:    make([]chan Event, 0),
+		chMainMux:   &sync.Mutex{},
+		chsProxy:    make([]chanProxy, 0),
 		chsProxyMux: &sync.RWMutex{},
 		stopGlobal:  make(chan struct{}, 0),
 		flagStarted: false,
@@ -58,8 +60,95 @@ func Test_World_Events(t *testing.T) {
 
 }
 
+func Test_World_Events_SequenceAndTime(t *testing.T) {
+	pg, err := playground.New(playground.BackendCMap, 100, 100)
+	require.Nil(t, err, "cannot initialize playground")
+
+	world := &World{
+		pg:          pg,
+		chMain:      make(chan Event, worldEventsChanMainBufferSize),
+		chMainMux:   &sync.Mutex{},
+		chsProxy:    make([]chanProxy, 0),
+		chsProxyMux: &sync.RWMutex{},
+		stopGlobal:  make(chan struct{}, 0),
+		flagStarted: false,
+		startedMux:  &sync.Mutex{},
+	}
+
+	stopWorld := make(chan struct{})
+	world.Start(stopWorld)
+	defer close(stopWorld)
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	chEventsFirst := world.Events(stop, 8)
+	chEventsSecond := world.Events(stop, 8)
+
+	object := &struct{}{}
+
+	require.Nil(t, world.CreateObject(object, engine.Location{engine.Dot{0, 0}}))
+	require.Nil(t, world.UpdateObject(object, engine.Location{engine.Dot{0, 0}}, engine.Location{engine.Dot{1, 1}}))
+	require.Nil(t, world.DeleteObject(object, engine.Location{engine.Dot{1, 1}}))
+
+	for _, ch := range []<-chan Event{chEventsFirst, chEventsSecond} {
+		var prev Event
+		for seq := uint64(1); seq <= 3; seq++ {
+			event := <-ch
+			require.Equal(t, seq, event.Seq)
+			require.True(t, event.Time >= prev.Time)
+			prev = event
+		}
+	}
+}
+
+func Test_World_LosslessEvents_DoesNotDropUpdates(t *testing.T) {
+	const updates = 20
+
+	pg, err := playground.New(playground.BackendCMap, 100, 100)
+	require.Nil(t, err, "cannot initialize playground")
+
+	world := &World{
+		pg:          pg,
+		chMain:      make(chan Event, worldEventsChanMainBufferSize),
+		chMainMux:   &sync.Mutex{},
+		chsProxy:    make([]chanProxy, 0),
+		chsProxyMux: &sync.RWMutex{},
+		stopGlobal:  make(chan struct{}, 0),
+		flagStarted: false,
+		startedMux:  &sync.Mutex{},
+	}
+
+	stopWorld := make(chan struct{})
+	world.Start(stopWorld)
+	defer close(stopWorld)
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	chEvents := world.LosslessEvents(stop, 0)
+
+	object := &struct{}{}
+	location := engine.Location{engine.Dot{0, 0}}
+
+	require.Nil(t, world.CreateObject(object, location))
+	for i := 0; i < updates; i++ {
+		next := engine.Location{engine.Dot{uint8(i % 10), uint8(i / 10)}}
+		require.Nil(t, world.UpdateObject(object, location, next))
+		location = next
+	}
+
+	for seq := uint64(1); seq <= updates+1; seq++ {
+		// Let the send timeout of update events expire
+		time.Sleep(worldEventsSendTimeout * 5)
+
+		event := <-chEvents
+		require.Equal(t, seq, event.Seq)
+	}
+}
+
 func Test_World_UpdateObject(t *testing.T) {
-	pg, err := playground.NewPlaygroundCMap(100, 100)
+	pg, err := playground.New(playground.BackendCMap, 100, 100)
 	require.Nil(t, err, "cannot initialize playground")
 	require.NotNil(t, pg, "cannot initialize playground")
 
@@ -70,7 +159,8 @@ func Test_World_UpdateObject(t *testing.T) {
 	world := &World{
 		pg:          pg,
 		chMain:      make(chan Event, worldEventsChanMainBufferSize),
-		chsProxy:    make([]chan Event, 0),
+		chMainMux:   &sync.Mutex{},
+		chsProxy:    make([]chanProxy, 0),
 		chsProxyMux: &sync.RWMutex{},
 		stopGlobal:  make(chan struct{}, 0),
 		flagStarted: false,
@@ -94,3 +184,132 @@ func Benchmark_World_UpdateObject(b *testing.B) {
 	// TODO: Implement benchmark.
 	b.Skip("Not implemented")
 }
+
+func Test_World_FilteredEvents(t *testing.T) {
+	pg, err := playground.New(playground.BackendCMap, 100, 100)
+	require.Nil(t, err, "cannot initialize playground")
+
+	world := &World{
+		pg:          pg,
+		chMain:      make(chan Event, worldEventsChanMainBufferSize),
+		chMainMux:   &sync.Mutex{},
+		chsProxy:    make([]chanProxy, 0),
+		chsProxyMux: &sync.RWMutex{},
+		stopGlobal:  make(chan struct{}, 0),
+		flagStarted: false,
+		startedMux:  &sync.Mutex{},
+	}
+
+	stopWorld := make(chan struct{})
+	world.Start(stopWorld)
+	defer close(stopWorld)
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	region := engine.NewRect(0, 0, 10, 10)
+
+	chDeletes := world.FilteredEvents(stop, 8, EventFilter{
+		Types: []EventType{EventTypeObjectDelete},
+	})
+	chRegion := world.FilteredEvents(stop, 8, EventFilter{
+		Region: &region,
+	})
+	chAll := world.Events(stop, 8)
+
+	object := &struct{ n int }{}
+
+	require.Nil(t, world.CreateObject(object, engine.Location{engine.Dot{50, 50}}))
+	require.Nil(t, world.UpdateObject(object, engine.Location{engine.Dot{50, 50}}, engine.Location{engine.Dot{5, 5}}))
+	require.Nil(t, world.UpdateObject(object, engine.Location{engine.Dot{5, 5}}, engine.Location{engine.Dot{60, 60}}))
+	require.Nil(t, world.DeleteObject(object, engine.Location{engine.Dot{60, 60}}))
+
+	for seq := uint64(1); seq <= 4; seq++ {
+		require.Equal(t, seq, (<-chAll).Seq)
+	}
+
+	event := <-chDeletes
+	require.Equal(t, EventTypeObjectDelete, event.Type)
+	require.Equal(t, uint64(4), event.Seq)
+
+	event = <-chRegion
+	require.Equal(t, EventTypeObjectUpdate, event.Type)
+	require.Equal(t, uint64(2), event.Seq)
+	// The object leaves the region
+	event = <-chRegion
+	require.Equal(t, EventTypeObjectUpdate, event.Type)
+	require.Equal(t, uint64(3), event.Seq)
+
+	select {
+	case event := <-chRegion:
+		t.Fatalf("unexpected event in region: %v", event)
+	case event := <-chDeletes:
+		t.Fatalf("unexpected event: %v", event)
+	default:
+	}
+}
+
+func Test_World_Transaction(t *testing.T) {
+	pg, err := playground.New(playground.BackendCMap, 100, 100)
+	require.Nil(t, err, "cannot initialize playground")
+
+	world := &World{
+		pg:          pg,
+		chMain:      make(chan Event, worldEventsChanMainBufferSize),
+		chMainMux:   &sync.Mutex{},
+		chsProxy:    make([]chanProxy, 0),
+		chsProxyMux: &sync.RWMutex{},
+		stopGlobal:  make(chan struct{}, 0),
+		flagStarted: false,
+		startedMux:  &sync.Mutex{},
+	}
+
+	stopWorld := make(chan struct{})
+	world.Start(stopWorld)
+	defer close(stopWorld)
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	chEvents := world.Events(stop, 8)
+
+	first := &struct{ n int }{}
+	second := &struct{ n int }{}
+
+	require.Nil(t, world.CreateObject(first, engine.Location{engine.Dot{1, 1}}))
+
+	err = world.Transaction([]playground.Operation{
+		playground.DeleteOperation(first, engine.Location{engine.Dot{1, 1}}),
+		playground.CreateOperation(second, engine.Location{engine.Dot{1, 1}}),
+		playground.UpdateOperation(second, engine.Location{engine.Dot{1, 1}}, engine.Location{engine.Dot{2, 2}}),
+	})
+	require.Nil(t, err)
+
+	require.Equal(t, EventTypeObjectCreate, (<-chEvents).Type)
+
+	event := <-chEvents
+	require.Equal(t, EventTypeObjectDelete, event.Type)
+	require.Equal(t, first, event.Payload)
+	require.Equal(t, uint64(2), event.Seq)
+
+	event = <-chEvents
+	require.Equal(t, EventTypeObjectCreate, event.Type)
+	require.Equal(t, second, event.Payload)
+	require.Equal(t, uint64(3), event.Seq)
+
+	event = <-chEvents
+	require.Equal(t, EventTypeObjectUpdate, event.Type)
+	require.Equal(t, second, event.Payload)
+	require.Equal(t, uint64(4), event.Seq)
+
+	// A failed transaction emits an error only
+	err = world.Transaction([]playground.Operation{
+		playground.DeleteOperation(second, engine.Location{engine.Dot{2, 2}}),
+		playground.DeleteOperation(first, engine.Location{engine.Dot{1, 1}}),
+	})
+	require.NotNil(t, err)
+	require.Equal(t, second, world.GetObjectByDot(engine.Dot{2, 2}))
+
+	require.Equal(t, EventTypeError, (<-chEvents).Type)
+	require.Equal(t, EventTypeObjectChecked, (<-chEvents).Type)
+}